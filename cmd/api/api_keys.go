@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/service"
+)
+
+// apiKeysUsage - справка по команде api-keys; список областей действия берется из models.APIKeyScopes
+var apiKeysUsage = `usage:
+  api api-keys create <service-account> <scope,...> [<ttl>]   issue an API key for a service account and print it
+Scopes: ` + strings.Join(models.APIKeyScopes, ", ") + `.
+The TTL is a duration such as 720h; without it the key does not expire.
+Storage settings are read from the config file and environment as for the server.`
+
+// runAPIKeys выполняет команду api-keys: выпускает ключ сервисного аккаунта напрямую в хранилище.
+// Так создается первый ключ с областью api_keys:manage, когда API уже требует ключи.
+func runAPIKeys(args []string) error {
+	if len(args) < 3 || len(args) > 4 || args[0] != "create" {
+		return errors.New(apiKeysUsage)
+	}
+
+	input := models.CreateAPIKeyInput{
+		Name:           args[1],
+		ServiceAccount: args[1],
+		Scopes:         strings.Split(args[2], ","),
+	}
+	for _, scope := range input.Scopes {
+		if !isAPIKeyScope(scope) {
+			return fmt.Errorf("unknown scope %q\n%s", scope, apiKeysUsage)
+		}
+	}
+	if len(args) == 4 {
+		ttl, err := time.ParseDuration(args[3])
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid TTL %q\n%s", args[3], apiKeysUsage)
+		}
+		expiresAt := time.Now().Add(ttl)
+		input.ExpiresAt = &expiresAt
+	}
+
+	cfg := loadConfig(nil)
+	store, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	key, raw, err := service.NewAPIKeyService(store.apiKeys, store.users).Create(context.Background(), input)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created API key %s (%s) for service account %q\n", key.ID, key.Prefix, key.ServiceAccount)
+	fmt.Println("Store it now, it will not be shown again:")
+	fmt.Println(raw)
+	return nil
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -1,47 +1,348 @@
 package main
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/Est1ege/go-user-api/internal/api/handlers"
 	"github.com/Est1ege/go-user-api/internal/api/routes"
 	"github.com/Est1ege/go-user-api/internal/config"
-	"github.com/Est1ege/go-user-api/internal/repository/postgres"
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/outbox"
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/cache"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/repository/sqlrepo"
+	"github.com/Est1ege/go-user-api/internal/server"
 	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/Est1ege/go-user-api/internal/webhook"
 	"github.com/Est1ege/go-user-api/pkg/database"
+	"github.com/Est1ege/go-user-api/pkg/jose"
 	"github.com/Est1ege/go-user-api/pkg/validator"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	args := os.Args[1:]
+
+	// "config print" выводит итоговую конфигурацию со скрытыми секретами и завершает работу
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		cfg := loadConfig(args[2:])
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %s", err.Error())
+		}
+		return
+	}
+
+	// "secrets encrypt|decrypt" работает с зашифрованным файлом секретов
+	if len(args) >= 1 && args[0] == "secrets" {
+		if err := runSecrets(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// "api-keys create" выпускает API-ключ сервисного аккаунта, например первый ключ для управления ключами
+	if len(args) >= 1 && args[0] == "api-keys" {
+		if err := runAPIKeys(args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Загрузка конфигурации
-	cfg := config.LoadConfig()
+	cfg := loadConfig(args)
 
 	// Настройка валидатора
 	validator.SetupValidator()
 
-	// Подключение к базе данных
-	db, err := database.NewPostgresDB(cfg)
+	// Контекст отменяется по SIGINT/SIGTERM: сервер и фоновые процессы завершаются штатно
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Подключение к хранилищу
+	store, err := openStorage(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %s", err.Error())
 	}
 
 	// Инициализация репозиториев
-	userRepo := postgres.NewUserRepository(db)
+	userRepo := store.users
+	if cacheStore := newCacheStore(cfg.Cache); cacheStore != nil {
+		cachedRepo := cache.NewUserRepository(userRepo, cacheStore, cfg.Cache.TTL, cfg.Cache.NegativeTTL)
+		if store.replicas != nil {
+			cachedRepo.WithReplicaLag(cfg.Cache.ReplicaLag)
+		}
+		expvar.Publish("user_cache", expvar.Func(func() any { return cachedRepo.Stats() }))
+		userRepo = cachedRepo
+	}
+	if store.replicas != nil {
+		store.replicas.Check(ctx)
+		go store.replicas.Run(ctx, cfg.DB.ReplicaCheckInterval)
+		expvar.Publish("db_replicas", expvar.Func(func() any { return store.replicas.Stats() }))
+	}
+	auditRepo := store.audit
+	outboxRepo := store.outbox
+	webhookRepo := store.webhooks
+	sessionRepo := store.sessions
+	apiKeyRepo := store.apiKeys
+	identityRepo := store.identities
+	transactor := store.transactor
+
+	// Подсети, в которые разрешено отправлять вебхуки, проверены вместе с конфигурацией
+	webhookNetworks, err := webhook.ParseNetworks(cfg.Webhooks.AllowedNetworks)
+	if err != nil {
+		log.Fatalf("Invalid webhook networks: %s", err.Error())
+	}
 
 	// Инициализация сервисов
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, auditRepo, outboxRepo, transactor)
+	webhookService := service.NewWebhookService(webhookRepo).WithAllowedNetworks(webhookNetworks)
+	sessionService := service.NewSessionService(sessionRepo, userRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	identityService := service.NewIdentityService(identityRepo, userRepo, userService)
+
+	// Администратор веб-интерфейса из конфигурации
+	if cfg.Admin.Email != "" {
+		ctx := service.WithRequestMeta(ctx, service.RequestMeta{Actor: "system"})
+		if _, err := userService.EnsureAdmin(ctx, cfg.Admin.Email, cfg.Admin.Password); err != nil {
+			log.Fatalf("Failed to create admin user: %s", err.Error())
+		}
+	}
+
+	// Доставка вебхуков и публикация доменных событий из outbox
+	// При остановке начатые порции дорабатываются до конца, поэтому процесс ждет их завершения
+	var workers sync.WaitGroup
+	dispatcher := webhook.NewDispatcher(webhookRepo, transactor, webhook.NewClient(webhook.DefaultTimeout, webhookNetworks))
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(ctx)
+	}()
+
+	relay := outbox.NewRelay(outboxRepo, transactor, dispatcher)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		relay.Run(ctx)
+	}()
+
+	// Удаление истекших сессий веб-интерфейса
+	go session.NewCleaner(sessionRepo, cfg.Session.CleanupInterval).Run(ctx)
 
 	// Инициализация обработчиков
 	userHandler := handlers.NewUserHandler(userService)
 	webHandler := handlers.NewWebHandler(userService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	oidcHandler := handlers.NewOIDCHandler(newOIDCProviders(cfg.OIDC), identityService, cfg.OIDC.RedirectBaseURL)
+	webHandler.WithLoginProviders(oidcHandler.Providers())
+	oauthHandler := newOAuthHandler(ctx, cfg.OAuth, store.oauth, userRepo)
 
 	// Настройка маршрутов
-	router := routes.SetupRouter(userHandler, webHandler)
+	sessionStore, err := session.NewStore(sessionRepo, cfg.Session)
+	if err != nil {
+		log.Fatalf("Failed to create session store: %s", err.Error())
+	}
+	limiter, err := newRateLimiter(cfg.RateLimit)
+	if err != nil {
+		log.Fatalf("Failed to create rate limiter: %s", err.Error())
+	}
+	router := routes.SetupRouter(userHandler, webHandler, webhookHandler, sessionHandler, apiKeyHandler, oidcHandler, oauthHandler, sessionStore, userService, apiKeyService, limiter, cfg)
 
 	// Запуск сервера
-	log.Printf("Server starting on port %s", cfg.Server.Port)
-	log.Printf("Web interface available at http://localhost:%s", cfg.Server.Port)
-	if err := router.Run(":" + cfg.Server.Port); err != nil {
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		scheme = "https"
+	}
+	log.Printf("Web interface available at %s://localhost:%s", scheme, cfg.Server.Port)
+	if err := server.ListenAndServe(ctx, cfg.Server, router); err != nil {
 		log.Fatalf("Failed to start server: %s", err.Error())
 	}
-}
\ No newline at end of file
+	workers.Wait()
+	log.Println("Server stopped")
+}
+
+// loadConfig загружает конфигурацию из файла, окружения и флагов args и завершает процесс при ошибках
+func loadConfig(args []string) *config.Config {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// storage - репозитории выбранного хранилища
+type storage struct {
+	users      repository.UserRepository
+	audit      repository.AuditRepository
+	outbox     repository.OutboxRepository
+	webhooks   repository.WebhookRepository
+	sessions   repository.SessionRepository
+	apiKeys    repository.APIKeyRepository
+	identities repository.IdentityRepository
+	oauth      repository.OAuthRepository
+	transactor repository.Transactor
+
+	// replicas - реплики для чтения пользователей; nil, если реплики не настроены
+	replicas *database.ReplicaSet
+}
+
+// openStorage подключается к хранилищу, указанному в cfg.DB.Driver
+func openStorage(cfg *config.Config) (*storage, error) {
+	switch cfg.DB.Driver {
+	case "memory":
+		log.Println("Using in-memory storage: data will be lost on restart")
+		db := memory.NewDB()
+		return &storage{
+			users:      memory.NewUserRepository(db),
+			audit:      memory.NewAuditRepository(db),
+			outbox:     memory.NewOutboxRepository(db),
+			webhooks:   memory.NewWebhookRepository(db),
+			sessions:   memory.NewSessionRepository(db),
+			apiKeys:    memory.NewAPIKeyRepository(db),
+			identities: memory.NewIdentityRepository(db),
+			oauth:      memory.NewOAuthRepository(db),
+			transactor: memory.NewTransactor(db),
+		}, nil
+	case "postgres", "sqlite":
+		db, err := database.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		users := sqlrepo.NewUserRepository(db)
+		var replicas *database.ReplicaSet
+		if len(cfg.DB.ReplicaURLs) > 0 {
+			if cfg.DB.Driver != "postgres" {
+				return nil, fmt.Errorf("read replicas are supported only for postgres, not %q", cfg.DB.Driver)
+			}
+			if replicas, err = database.NewPostgresReplicas(cfg, db); err != nil {
+				return nil, err
+			}
+			users.WithReplicas(replicas)
+			log.Printf("Routing user reads to %d read replica(s)", replicas.Len())
+		}
+
+		return &storage{
+			users:      users,
+			audit:      sqlrepo.NewAuditRepository(db),
+			outbox:     sqlrepo.NewOutboxRepository(db),
+			webhooks:   sqlrepo.NewWebhookRepository(db),
+			sessions:   sqlrepo.NewSessionRepository(db),
+			apiKeys:    sqlrepo.NewAPIKeyRepository(db),
+			identities: sqlrepo.NewIdentityRepository(db),
+			oauth:      sqlrepo.NewOAuthRepository(db),
+			transactor: sqlrepo.NewTransactor(db),
+			replicas:   replicas,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.DB.Driver)
+	}
+}
+
+// newCacheStore создает хранилище кеша пользователей по конфигурации; nil означает, что кеш отключен
+func newCacheStore(cfg config.CacheConfig) cache.Store {
+	switch cfg.Backend {
+	case "":
+		return nil
+	case "memory":
+		return cache.NewLRU(cfg.Size)
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return cache.NewRedis(client, "go-user-api:")
+	default:
+		log.Fatalf("Unknown cache backend: %s", cfg.Backend)
+		return nil
+	}
+}
+
+// newRateLimiter создает ограничитель частоты запросов по конфигурации; nil означает, что ограничение отключено
+func newRateLimiter(cfg config.RateLimitConfig) (*ratelimit.Limiter, error) {
+	var store ratelimit.Store
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		store = ratelimit.NewRedis(client, "go-user-api:ratelimit:")
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+	return ratelimit.New(store, cfg.Policies)
+}
+
+// newOIDCProviders создает клиенты поставщиков входа OpenID Connect; настройки уже проверены при загрузке конфигурации
+func newOIDCProviders(cfg config.OIDCConfig) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(cfg.Providers))
+	for _, spec := range cfg.Providers {
+		providerCfg, err := oidc.ParseProvider(spec)
+		if err != nil {
+			log.Fatalf("Invalid OIDC provider: %s", err.Error())
+		}
+		providers = append(providers, oidc.NewProvider(providerCfg, nil))
+		log.Printf("OIDC login enabled for %s (%s)", providerCfg.Name, providerCfg.Issuer)
+	}
+	return providers
+}
+
+// newOAuthHandler создает сервер авторизации OAuth2/OpenID Connect и запускает удаление истекших кодов и токенов;
+// nil означает, что сервер выключен. Без ключа подписи создается временный ключ EC P-256.
+func newOAuthHandler(ctx context.Context, cfg config.OAuthConfig, repo repository.OAuthRepository, userRepo repository.UserRepository) *handlers.OAuthHandler {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	var signingKey crypto.Signer
+	var err error
+	if cfg.SigningKey != "" {
+		signingKey, err = jose.ParsePrivateKey([]byte(cfg.SigningKey))
+	} else {
+		log.Println("OAuth signing key is not set: using an ephemeral key, ID tokens will not verify after restart")
+		signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		log.Fatalf("Invalid OAuth signing key: %s", err.Error())
+	}
+
+	oauthService, err := service.NewOAuthService(repo, userRepo, service.OAuthOptions{
+		Issuer:         cfg.Issuer,
+		SigningKey:     signingKey,
+		AccessTokenTTL: cfg.AccessTokenTTL,
+		CodeTTL:        cfg.CodeTTL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create OAuth service: %s", err.Error())
+	}
+	go oauthService.RunCleanup(ctx, cfg.CleanupInterval)
+	log.Printf("OAuth authorization server enabled for %s", cfg.Issuer)
+	return handlers.NewOAuthHandler(oauthService)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+)
+
+// secretsUsage - справка по команде secrets
+const secretsUsage = `usage:
+  api secrets encrypt <plain.yaml> <secrets.enc>   encrypt a YAML file with secrets (e.g. "db: {password: ...}")
+  api secrets decrypt <secrets.enc>                print the decrypted secrets
+The master key is read from SECRETS_MASTER_KEY (a "file:<path>" reference is allowed).`
+
+// runSecrets выполняет команду secrets: шифрует и расшифровывает файл секретов мастер-ключом из окружения
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		return errors.New(secretsUsage)
+	}
+
+	masterKey, err := config.ResolveSecret(os.Getenv("SECRETS_MASTER_KEY"))
+	if err != nil {
+		return fmt.Errorf("SECRETS_MASTER_KEY: %w", err)
+	}
+
+	switch {
+	case args[0] == "encrypt" && len(args) == 3:
+		plaintext, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		encrypted, err := config.EncryptSecrets(plaintext, masterKey)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(args[2], encrypted, 0o600)
+	case args[0] == "decrypt" && len(args) == 2:
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		plaintext, err := config.DecryptSecrets(data, masterKey)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(plaintext)
+		return err
+	default:
+		return errors.New(secretsUsage)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler обрабатывает HTTP-запросы управления API-ключами
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyServiceInterface
+}
+
+// NewAPIKeyHandler создает новый экземпляр APIKeyHandler
+func NewAPIKeyHandler(apiKeyService service.APIKeyServiceInterface) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// createdAPIKey - ответ на создание ключа; сам ключ возвращается только один раз
+type createdAPIKey struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// Create обрабатывает POST /api-keys. Запрос, аутентифицированный API-ключом, может выдать новому ключу
+// только области действия, которые есть у него самого: иначе ключ с api_keys:manage получил бы любые права.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var input models.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if caller := middleware.CurrentAPIKey(c); caller != nil {
+		for _, scope := range input.Scopes {
+			if !caller.HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key cannot grant scope " + scope + " it lacks"})
+				return
+			}
+		}
+	}
+
+	key, raw, err := h.apiKeyService.Create(c.Request.Context(), input)
+	if err != nil {
+		apiKeyError(c, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdAPIKey{APIKey: key, Key: raw})
+}
+
+// List обрабатывает GET /api-keys; параметр user_id оставляет только ключи пользователя
+func (h *APIKeyHandler) List(c *gin.Context) {
+	var userID *uuid.UUID
+	if value := c.Query("user_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		userID = &id
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), userID)
+	if err != nil {
+		apiKeyError(c, err, "Failed to list API keys")
+		return
+	}
+	if keys == nil {
+		keys = []*models.APIKey{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// GetByID обрабатывает GET /api-keys/:id
+func (h *APIKeyHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	key, err := h.apiKeyService.Get(c.Request.Context(), id)
+	if err != nil {
+		apiKeyError(c, err, "Failed to get API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Revoke обрабатывает DELETE /api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), id); err != nil {
+		apiKeyError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+// apiKeyError отвечает на ошибку сервиса API-ключей
+func apiKeyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyOwner), errors.Is(err, service.ErrAPIKeyExpired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
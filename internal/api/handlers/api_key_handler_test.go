@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyService имитирует сервис API-ключей для тестирования
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+// Убедимся что MockAPIKeyService реализует service.APIKeyServiceInterface
+var _ service.APIKeyServiceInterface = (*MockAPIKeyService)(nil)
+
+func (m *MockAPIKeyService) Create(ctx context.Context, input models.CreateAPIKeyInput) (*models.APIKey, string, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func setupAPIKeyTestRouter() (*gin.Engine, *MockAPIKeyService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	apiKeys := router.Group("/api-keys")
+	{
+		apiKeys.POST("", handler.Create)
+		apiKeys.GET("", handler.List)
+		apiKeys.GET("/:id", handler.GetByID)
+		apiKeys.DELETE("/:id", handler.Revoke)
+	}
+
+	return router, mockService
+}
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	router, mockService := setupAPIKeyTestRouter()
+
+	t.Run("Success", func(t *testing.T) {
+		input := models.CreateAPIKeyInput{Name: "provisioning", ServiceAccount: "provisioning", Scopes: []string{"users:write"}}
+		key := &models.APIKey{ID: uuid.New(), Name: "provisioning", Prefix: "uak_0123456789ab", Hash: "hash", Scopes: input.Scopes}
+		mockService.On("Create", input).Return(key, "uak_0123456789ab_secret", nil).Once()
+
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "uak_0123456789ab_secret", response["key"])
+		assert.Equal(t, "uak_0123456789ab", response["prefix"])
+		assert.NotContains(t, response, "hash")
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys",
+			bytes.NewBufferString(`{"name":"x","service_account":"x","scopes":["root"]}`)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid owner", func(t *testing.T) {
+		input := models.CreateAPIKeyInput{Name: "orphan", Scopes: []string{"users:read"}}
+		mockService.On("Create", input).Return(nil, "", service.ErrAPIKeyOwner).Once()
+
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "exactly one of user_id and service_account")
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Create_LimitsScopesToCallerKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockService := new(MockAPIKeyService)
+	caller := &models.APIKey{ID: uuid.New(), Scopes: []string{models.ScopeAPIKeysManage, models.ScopeUsersRead}}
+	router.POST("/api-keys", func(c *gin.Context) {
+		c.Set(middleware.APIKeyKey, caller)
+	}, NewAPIKeyHandler(mockService).Create)
+
+	t.Run("Scope the caller lacks", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys",
+			bytes.NewBufferString(`{"name":"x","service_account":"x","scopes":["users:read","users:write"]}`)))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "users:write")
+	})
+
+	t.Run("Subset of caller scopes", func(t *testing.T) {
+		input := models.CreateAPIKeyInput{Name: "reader", ServiceAccount: "reader", Scopes: []string{models.ScopeUsersRead}}
+		key := &models.APIKey{ID: uuid.New(), Name: "reader", Scopes: input.Scopes}
+		mockService.On("Create", input).Return(key, "uak_0123456789ab_secret", nil).Once()
+
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_List(t *testing.T) {
+	router, mockService := setupAPIKeyTestRouter()
+
+	userID := uuid.New()
+	mockService.On("List", &userID).Return([]*models.APIKey{{ID: uuid.New(), UserID: &userID}}, nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api-keys?user_id="+userID.String(), nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string][]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response["api_keys"], 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api-keys?user_id=bad", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_Revoke(t *testing.T) {
+	router, mockService := setupAPIKeyTestRouter()
+
+	t.Run("Success", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("Revoke", id).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api-keys/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("Revoke", id).Return(repository.ErrAPIKeyNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api-keys/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error":"API key not found"}`, w.Body.String())
+	})
+
+	mockService.AssertExpectations(t)
+}
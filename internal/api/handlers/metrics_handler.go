@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"expvar"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics обрабатывает GET /debug/vars: отдает переменные expvar (память, кеш, реплики) в том же формате,
+// что и expvar.Handler, но без cmdline - аргументы командной строки могут содержать секреты
+func Metrics(c *gin.Context) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(c.Writer, "{\n")
+	first := true
+	expvar.Do(func(kv expvar.KeyValue) {
+		if kv.Key == "cmdline" {
+			return
+		}
+		if !first {
+			fmt.Fprint(c.Writer, ",\n")
+		}
+		first = false
+		fmt.Fprintf(c.Writer, "%q: %s", kv.Key, kv.Value)
+	})
+	fmt.Fprint(c.Writer, "\n}\n")
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Пути конечных точек сервера авторизации; документ обнаружения публикует их относительно issuer
+const (
+	OAuthAuthorizePath  = "/oauth/authorize"
+	OAuthTokenPath      = "/oauth/token"
+	OAuthIntrospectPath = "/oauth/introspect"
+	OAuthRevokePath     = "/oauth/revoke"
+	OAuthUserInfoPath   = "/oauth/userinfo"
+	OAuthJWKSPath       = "/oauth/jwks"
+)
+
+// OAuthHandler обрабатывает запросы сервера авторизации OAuth2/OpenID Connect и управление его клиентами
+type OAuthHandler struct {
+	oauthService service.OAuthServiceInterface
+}
+
+// NewOAuthHandler создает новый экземпляр OAuthHandler
+func NewOAuthHandler(oauthService service.OAuthServiceInterface) *OAuthHandler {
+	return &OAuthHandler{oauthService: oauthService}
+}
+
+// discoveryDocument - документ обнаружения OpenID Connect
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	AuthorizationResponseIssParameter bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+// Discovery обрабатывает GET /.well-known/openid-configuration
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	issuer := h.oauthService.Issuer()
+	var algs []string
+	for _, key := range h.oauthService.JWKS().Keys {
+		algs = append(algs, key.Alg)
+	}
+
+	c.JSON(http.StatusOK, discoveryDocument{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + OAuthAuthorizePath,
+		TokenEndpoint:                     issuer + OAuthTokenPath,
+		UserInfoEndpoint:                  issuer + OAuthUserInfoPath,
+		JWKSURI:                           issuer + OAuthJWKSPath,
+		IntrospectionEndpoint:             issuer + OAuthIntrospectPath,
+		RevocationEndpoint:                issuer + OAuthRevokePath,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{models.GrantAuthorizationCode, models.GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		ScopesSupported:                   []string{models.OAuthScopeOpenID, models.OAuthScopeProfile, models.OAuthScopeEmail},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "name", "given_name", "family_name", "updated_at"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		AuthorizationResponseIssParameter: true,
+	})
+}
+
+// JWKS обрабатывает GET /oauth/jwks
+func (h *OAuthHandler) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oauthService.JWKS())
+}
+
+// oauthAuthorizeParams - параметры запроса авторизации, которые страница согласия передает обратно в форме
+var oauthAuthorizeParams = []string{"client_id", "redirect_uri", "response_type", "scope", "nonce", "code_challenge", "code_challenge_method", "state"}
+
+// oauthScopeDescriptions - описания областей действия OpenID Connect для страницы согласия
+var oauthScopeDescriptions = map[string]string{
+	models.OAuthScopeOpenID:  "ваш идентификатор",
+	models.OAuthScopeProfile: "имя и фамилию",
+	models.OAuthScopeEmail:   "адрес email",
+}
+
+// Authorize обрабатывает GET и POST /oauth/authorize для пользователя, вошедшего в веб-интерфейс.
+// Доверенный клиент сразу получает код; для остального клиента GET показывает страницу согласия,
+// а ее форма отправляет те же параметры POST-запросом с ответом пользователя (consent=allow или deny).
+// Ошибка до проверки адреса возврата показывается пользователю, после - возвращается клиенту перенаправлением.
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	param := c.Query
+	consent := ""
+	if c.Request.Method == http.MethodPost {
+		param = c.PostForm
+		consent = c.PostForm("consent")
+	}
+
+	state := param("state")
+	redirectURI, code, err := h.oauthService.Authorize(c.Request.Context(), middleware.CurrentUser(c), service.OAuthAuthorizationRequest{
+		ClientID:            param("client_id"),
+		RedirectURI:         param("redirect_uri"),
+		ResponseType:        param("response_type"),
+		Scope:               param("scope"),
+		Nonce:               param("nonce"),
+		CodeChallenge:       param("code_challenge"),
+		CodeChallengeMethod: param("code_challenge_method"),
+		Consented:           consent == "allow",
+		Denied:              c.Request.Method == http.MethodPost && consent != "allow",
+	})
+
+	params := url.Values{"iss": {h.oauthService.Issuer()}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	var oauthErr *service.OAuthError
+	var consentErr *service.OAuthConsentRequired
+	switch {
+	case err == nil:
+		params.Set("code", code)
+	case redirectURI == "":
+		if !errors.As(err, &oauthErr) {
+			log.Printf("Ошибка авторизации OAuth: %v", err)
+		}
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"Title":   "Ошибка авторизации",
+			"Message": "Приложение отправило неверный запрос входа: неизвестный клиент или незарегистрированный адрес возврата. Сообщите об ошибке администратору приложения.",
+			"Back":    "/web/users",
+		})
+		return
+	case errors.As(err, &consentErr):
+		h.consentPage(c, consentErr, param)
+		return
+	case errors.As(err, &oauthErr):
+		params.Set("error", oauthErr.Code)
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+	default:
+		log.Printf("Ошибка авторизации OAuth: %v", err)
+		params.Set("error", "server_error")
+	}
+
+	// Перенаправление в ответ на форму согласия проверяется директивой CSP form-action 'self' и было бы
+	// заблокировано браузером, поэтому после формы возвращается страница, которая переходит в приложение сама
+	if c.Request.Method == http.MethodPost {
+		c.Header("Cache-Control", "no-store")
+		c.HTML(http.StatusOK, "redirect.html", gin.H{"RedirectTo": withQuery(redirectURI, params)})
+		return
+	}
+	c.Redirect(http.StatusFound, withQuery(redirectURI, params))
+}
+
+// consentPage показывает страницу согласия с параметрами исходного запроса авторизации
+func (h *OAuthHandler) consentPage(c *gin.Context, consent *service.OAuthConsentRequired, param func(string) string) {
+	hidden := make(map[string]string)
+	for _, name := range oauthAuthorizeParams {
+		if value := param(name); value != "" {
+			hidden[name] = value
+		}
+	}
+	var scopes []string
+	for _, scope := range consent.Scopes {
+		if description, ok := oauthScopeDescriptions[scope]; ok {
+			scopes = append(scopes, description)
+		} else {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	// Встраивание страницы запрещают заголовки безопасности веб-интерфейса, а ответ с параметрами запроса не кешируется
+	c.Header("Cache-Control", "no-store")
+	c.HTML(http.StatusOK, "consent.html", pageData(c, gin.H{
+		"ClientName": consent.ClientName,
+		"Scopes":     scopes,
+		"Params":     hidden,
+		"Action":     OAuthAuthorizePath,
+	}))
+}
+
+// Token обрабатывает POST /oauth/token
+func (h *OAuthHandler) Token(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.oauthService.Token(c.Request.Context(), client, service.OAuthTokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		CodeVerifier: c.PostForm("code_verifier"),
+		Scope:        c.PostForm("scope"),
+	})
+	if err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, resp)
+}
+
+// Introspect обрабатывает POST /oauth/introspect (RFC 7662)
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.oauthService.Introspect(c.Request.Context(), client, c.PostForm("token"))
+	if err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, result)
+}
+
+// Revoke обрабатывает POST /oauth/revoke (RFC 7009); ответ одинаков для известных и неизвестных токенов
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	if err := h.oauthService.Revoke(c.Request.Context(), client, c.PostForm("token")); err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// UserInfo обрабатывает GET и POST /oauth/userinfo с токеном доступа в заголовке "Authorization: Bearer ..."
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="oauth"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_request", "error_description": "a bearer access token is required"})
+		return
+	}
+
+	claims, err := h.oauthService.UserInfo(c.Request.Context(), strings.TrimSpace(token))
+	var oauthErr *service.OAuthError
+	switch {
+	case err == nil:
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, claims)
+	case errors.As(err, &oauthErr):
+		status := http.StatusUnauthorized
+		if errors.Is(err, service.ErrOAuthInsufficientScope) {
+			status = http.StatusForbidden
+		}
+		c.Header("WWW-Authenticate", `Bearer realm="oauth", error="`+oauthErr.Code+`"`)
+		c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+	default:
+		writeOAuthError(c, err)
+	}
+}
+
+// authenticateClient аутентифицирует клиента по заголовку Basic (client_secret_basic), параметрам
+// client_id и client_secret (client_secret_post) или только client_id для публичного клиента
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*models.OAuthClient, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// Значения в заголовке Basic кодируются как параметры формы (RFC 6749, раздел 2.3.1)
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		secret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil || c.PostForm("client_secret") != "" {
+			writeOAuthError(c, &service.OAuthError{Code: service.ErrOAuthInvalidRequest.Code, Description: "malformed client credentials"})
+			return nil, false
+		}
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	client, err := h.oauthService.AuthenticateClient(c.Request.Context(), clientID, secret)
+	if err != nil {
+		if basic && errors.Is(err, service.ErrOAuthInvalidClient) {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		writeOAuthError(c, err)
+		return nil, false
+	}
+	return client, true
+}
+
+// writeOAuthError отвечает на ошибку протокола OAuth2 в формате RFC 6749: 401 для invalid_client, иначе 400
+func writeOAuthError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		log.Printf("Ошибка сервера авторизации: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrOAuthInvalidClient) {
+		status = http.StatusUnauthorized
+	}
+	body := gin.H{"error": oauthErr.Code}
+	if oauthErr.Description != "" {
+		body["error_description"] = oauthErr.Description
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, body)
+}
+
+// withQuery добавляет параметры к адресу, сохраняя его собственные параметры
+func withQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// createdOAuthClient - ответ на регистрацию клиента; секрет возвращается только один раз
+type createdOAuthClient struct {
+	*models.OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// CreateClient обрабатывает POST /oauth/clients
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var input models.CreateOAuthClientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, secret, err := h.oauthService.CreateClient(c.Request.Context(), input)
+	if err != nil {
+		oauthClientError(c, err, "Failed to create OAuth client")
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdOAuthClient{OAuthClient: client, ClientSecret: secret})
+}
+
+// ListClients обрабатывает GET /oauth/clients
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauthService.ListClients(c.Request.Context())
+	if err != nil {
+		oauthClientError(c, err, "Failed to list OAuth clients")
+		return
+	}
+	if clients == nil {
+		clients = []*models.OAuthClient{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// GetClient обрабатывает GET /oauth/clients/:id
+func (h *OAuthHandler) GetClient(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client ID"})
+		return
+	}
+
+	client, err := h.oauthService.GetClient(c.Request.Context(), id)
+	if err != nil {
+		oauthClientError(c, err, "Failed to get OAuth client")
+		return
+	}
+
+	c.JSON(http.StatusOK, client)
+}
+
+// DeleteClient обрабатывает DELETE /oauth/clients/:id; выданные клиенту токены перестают действовать
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client ID"})
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(c.Request.Context(), id); err != nil {
+		oauthClientError(c, err, "Failed to delete OAuth client")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "OAuth client deleted successfully"})
+}
+
+// oauthClientError отвечает на ошибку управления клиентами
+func oauthClientError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrOAuthInvalidClientMetadata):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrOAuthClientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "OAuth client not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
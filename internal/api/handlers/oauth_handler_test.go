@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"html"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oauthTestServer - сервер авторизации с пользователем user, который уже вошел в веб-интерфейс
+type oauthTestServer struct {
+	*httptest.Server
+	service *service.OAuthService
+	user    *models.User
+}
+
+func newOAuthTestServer(t *testing.T) *oauthTestServer {
+	gin.SetMode(gin.TestMode)
+	db := memory.NewDB()
+	userRepo := memory.NewUserRepository(db)
+	user := &models.User{Email: "john@example.com", FirstName: "John", LastName: "Doe", Role: models.RoleUser}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	router := gin.New()
+	templates := template.Must(template.New("error.html").Parse(`{{.Title}}`))
+	template.Must(templates.New("consent.html").Parse(`{{.ClientName}}: {{range .Scopes}}{{.}}; {{end}}`))
+	template.Must(templates.New("redirect.html").Parse(`{{.RedirectTo}}`))
+	router.SetHTMLTemplate(templates)
+	server := &oauthTestServer{Server: httptest.NewServer(router), user: user}
+	t.Cleanup(server.Close)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	server.service, err = service.NewOAuthService(memory.NewOAuthRepository(db), userRepo, service.OAuthOptions{
+		Issuer: server.URL, SigningKey: key, AccessTokenTTL: time.Hour, CodeTTL: time.Minute,
+	})
+	require.NoError(t, err)
+
+	handler := NewOAuthHandler(server.service)
+	router.GET("/.well-known/openid-configuration", handler.Discovery)
+	router.GET(OAuthJWKSPath, handler.JWKS)
+	setUser := func(c *gin.Context) { c.Set(middleware.CurrentUserKey, user) }
+	router.GET(OAuthAuthorizePath, setUser, handler.Authorize)
+	router.POST(OAuthAuthorizePath, setUser, handler.Authorize)
+	router.POST(OAuthTokenPath, handler.Token)
+	router.POST(OAuthIntrospectPath, handler.Introspect)
+	router.POST(OAuthRevokePath, handler.Revoke)
+	router.GET(OAuthUserInfoPath, handler.UserInfo)
+	router.POST("/oauth/clients", handler.CreateClient)
+	router.GET("/oauth/clients", handler.ListClients)
+	router.DELETE("/oauth/clients/:id", handler.DeleteClient)
+	return server
+}
+
+// noRedirects - HTTP-клиент, который возвращает перенаправления, а не следует им
+var noRedirects = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+func postForm(t *testing.T, target string, form url.Values, clientID, secret string) (*http.Response, map[string]any) {
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp, body
+}
+
+func TestOAuthHandler_AuthorizationCodeWithOIDCClient(t *testing.T) {
+	server := newOAuthTestServer(t)
+	ctx := context.Background()
+	client, secret, err := server.service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "App", Trusted: true, RedirectURIs: []string{"https://app.example.com/cb"}})
+	require.NoError(t, err)
+
+	// Приложение входит через сервер авторизации тем же клиентом OpenID Connect, что и веб-интерфейс
+	provider := oidc.NewProvider(oidc.ProviderConfig{
+		Name: "users", Issuer: server.URL, ClientID: client.ID.String(), ClientSecret: secret, Scopes: oidc.DefaultScopes,
+	}, server.Client())
+	verifier, err := oidc.NewVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "https://app.example.com/cb", "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+
+	resp, err := noRedirects.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "app.example.com", callback.Host)
+	assert.Equal(t, "state-1", callback.Query().Get("state"))
+	assert.Equal(t, server.URL, callback.Query().Get("iss"))
+
+	claims, err := provider.Exchange(ctx, callback.Query().Get("code"), verifier, "https://app.example.com/cb", "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, server.user.ID.String(), claims.Subject)
+	assert.Equal(t, "john@example.com", claims.Email)
+	assert.Equal(t, "John", claims.GivenName)
+	assert.Equal(t, "Doe", claims.FamilyName)
+
+	// Case: код одноразовый
+	_, err = provider.Exchange(ctx, callback.Query().Get("code"), verifier, "https://app.example.com/cb", "nonce-1")
+	assert.Error(t, err)
+}
+
+func TestOAuthHandler_TokenIntrospectRevoke(t *testing.T) {
+	server := newOAuthTestServer(t)
+	ctx := context.Background()
+	client, secret, err := server.service.CreateClient(ctx, models.CreateOAuthClientInput{
+		Name: "App", Trusted: true, RedirectURIs: []string{"https://app.example.com/cb"}, GrantTypes: []string{"authorization_code", "client_credentials"},
+	})
+	require.NoError(t, err)
+
+	// Case: неверный секрет
+	resp, body := postForm(t, server.URL+OAuthTokenPath, url.Values{"grant_type": {"client_credentials"}}, client.ID.String(), "wrong")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_client", body["error"])
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+
+	// Case: client_secret_post и неверный код
+	resp, body = postForm(t, server.URL+OAuthTokenPath, url.Values{
+		"grant_type": {"authorization_code"}, "code": {"unknown"}, "code_verifier": {"v"},
+		"client_id": {client.ID.String()}, "client_secret": {secret},
+	}, "", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "invalid_grant", body["error"])
+
+	// Код авторизации пользователя: userinfo возвращает утверждения по токену доступа
+	authURL := server.URL + OAuthAuthorizePath + "?" + url.Values{
+		"client_id": {client.ID.String()}, "response_type": {"code"}, "scope": {"openid profile"},
+		"code_challenge": {oidc.Challenge(strings.Repeat("v", 43))}, "code_challenge_method": {"S256"},
+	}.Encode()
+	authResp, err := noRedirects.Get(authURL)
+	require.NoError(t, err)
+	authResp.Body.Close()
+	callback, err := url.Parse(authResp.Header.Get("Location"))
+	require.NoError(t, err)
+	resp, body = postForm(t, server.URL+OAuthTokenPath, url.Values{
+		"grant_type": {"authorization_code"}, "code": {callback.Query().Get("code")},
+		"code_verifier": {strings.Repeat("v", 43)}, "redirect_uri": {"https://app.example.com/cb"},
+	}, client.ID.String(), secret)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.NotEmpty(t, body["id_token"])
+	accessToken := body["access_token"].(string)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+OAuthUserInfoPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	infoResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var info map[string]any
+	require.NoError(t, json.NewDecoder(infoResp.Body).Decode(&info))
+	infoResp.Body.Close()
+	assert.Equal(t, http.StatusOK, infoResp.StatusCode)
+	assert.Equal(t, "John Doe", info["name"])
+	assert.NotContains(t, info, "email")
+
+	// Токен клиента: introspection, затем отзыв
+	resp, body = postForm(t, server.URL+OAuthTokenPath, url.Values{"grant_type": {"client_credentials"}}, client.ID.String(), secret)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.NotContains(t, body, "id_token")
+	clientToken := body["access_token"].(string)
+
+	_, body = postForm(t, server.URL+OAuthIntrospectPath, url.Values{"token": {accessToken}}, client.ID.String(), secret)
+	assert.Equal(t, true, body["active"])
+	assert.Equal(t, server.user.ID.String(), body["sub"])
+	assert.Equal(t, "john@example.com", body["username"])
+
+	resp, _ = postForm(t, server.URL+OAuthRevokePath, url.Values{"token": {clientToken}}, client.ID.String(), secret)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, body = postForm(t, server.URL+OAuthIntrospectPath, url.Values{"token": {clientToken}}, client.ID.String(), secret)
+	assert.Equal(t, map[string]any{"active": false}, body)
+
+	// Case: userinfo без токена
+	infoResp, err = http.Get(server.URL + OAuthUserInfoPath)
+	require.NoError(t, err)
+	infoResp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, infoResp.StatusCode)
+}
+
+func TestOAuthHandler_AuthorizeConsent(t *testing.T) {
+	server := newOAuthTestServer(t)
+	client, _, err := server.service.CreateClient(context.Background(), models.CreateOAuthClientInput{
+		Name: "Partner", Public: true, RedirectURIs: []string{"http://localhost:3000/cb"},
+	})
+	require.NoError(t, err)
+	params := url.Values{
+		"client_id": {client.ID.String()}, "response_type": {"code"}, "scope": {"openid email"}, "state": {"s"},
+		"code_challenge": {oidc.Challenge(strings.Repeat("v", 43))}, "code_challenge_method": {"S256"},
+	}
+
+	// Сторонний клиент не получает код без согласия: GET показывает страницу согласия
+	resp, err := noRedirects.Get(server.URL + OAuthAuthorizePath + "?" + params.Encode())
+	require.NoError(t, err)
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "Partner: ваш идентификатор; адрес email; ", string(page))
+
+	// Ответ на форму - страница перехода в приложение, а не перенаправление
+	respond := func(consent string) url.Values {
+		form := url.Values{"consent": {consent}}
+		for name, values := range params {
+			form[name] = values
+		}
+		resp, err := noRedirects.PostForm(server.URL+OAuthAuthorizePath, form)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		callback, err := url.Parse(html.UnescapeString(string(body)))
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:3000", callback.Host)
+		assert.Equal(t, "s", callback.Query().Get("state"))
+		return callback.Query()
+	}
+
+	query := respond("allow")
+	assert.NotEmpty(t, query.Get("code"))
+	assert.Empty(t, query.Get("error"))
+
+	// Case: отказ
+	query = respond("deny")
+	assert.Empty(t, query.Get("code"))
+	assert.Equal(t, "access_denied", query.Get("error"))
+}
+
+func TestOAuthHandler_AuthorizeErrors(t *testing.T) {
+	server := newOAuthTestServer(t)
+	client, _, err := server.service.CreateClient(context.Background(), models.CreateOAuthClientInput{
+		Name: "SPA", Public: true, RedirectURIs: []string{"http://localhost:3000/cb?tenant=1"},
+	})
+	require.NoError(t, err)
+
+	authorize := func(params url.Values) *http.Response {
+		resp, err := noRedirects.Get(server.URL + OAuthAuthorizePath + "?" + params.Encode())
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Case: незарегистрированный адрес возврата - ошибка показывается пользователю, а не передается по адресу
+	resp := authorize(url.Values{"client_id": {client.ID.String()}, "redirect_uri": {"https://evil.example.com/cb"}, "response_type": {"code"}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Location"))
+
+	// Case: без PKCE - ошибка возвращается клиенту вместе с state и параметрами адреса возврата
+	resp = authorize(url.Values{"client_id": {client.ID.String()}, "response_type": {"code"}, "state": {"s"}})
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/cb", callback.Path)
+	assert.Equal(t, "1", callback.Query().Get("tenant"))
+	assert.Equal(t, "invalid_request", callback.Query().Get("error"))
+	assert.Equal(t, "s", callback.Query().Get("state"))
+
+	// Discovery публикует конечные точки относительно issuer
+	discoveryResp, err := http.Get(server.URL + "/.well-known/openid-configuration")
+	require.NoError(t, err)
+	defer discoveryResp.Body.Close()
+	var metadata map[string]any
+	require.NoError(t, json.NewDecoder(discoveryResp.Body).Decode(&metadata))
+	assert.Equal(t, server.URL, metadata["issuer"])
+	assert.Equal(t, server.URL+OAuthTokenPath, metadata["token_endpoint"])
+	assert.Equal(t, []any{"ES256"}, metadata["id_token_signing_alg_values_supported"])
+}
+
+func TestOAuthHandler_Clients(t *testing.T) {
+	server := newOAuthTestServer(t)
+
+	resp, err := http.Post(server.URL+"/oauth/clients", "application/json",
+		strings.NewReader(`{"name":"Worker","grant_types":["client_credentials"],"scopes":["reports:read"]}`))
+	require.NoError(t, err)
+	var created map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Len(t, created["client_secret"], 64)
+	assert.NotContains(t, created, "secret_hash")
+
+	// Case: клиенту authorization_code нужен адрес возврата
+	resp, err = http.Post(server.URL+"/oauth/clients", "application/json", strings.NewReader(`{"name":"App"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/oauth/clients/"+created["client_id"].(string), nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
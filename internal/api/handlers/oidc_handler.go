@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// oidcLoginKey - ключ сессии с незавершенным входом через поставщика
+const oidcLoginKey = "oidc_login"
+
+// oidcLoginTTL - время, за которое пользователь должен вернуться от поставщика
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcLogin - незавершенный вход через поставщика: state защищает возврат от подделки (CSRF),
+// nonce связывает ID-токен с этим входом, а верификатор PKCE - обмен кода
+type oidcLogin struct {
+	Provider  string `json:"provider"`
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ReturnTo  string `json:"return_to"`
+	StartedAt int64  `json:"started_at"`
+}
+
+// LoginProvider - кнопка входа через внешнего поставщика на странице входа
+type LoginProvider struct {
+	Name  string
+	Title string
+}
+
+// OIDCHandler представляет обработчики входа в веб-интерфейс через поставщиков OpenID Connect
+type OIDCHandler struct {
+	providers       map[string]*oidc.Provider
+	loginProviders  []LoginProvider
+	identityService service.IdentityServiceInterface
+	// redirectBaseURL - внешний адрес приложения для адреса возврата; пустой - адрес берется из запроса
+	redirectBaseURL string
+
+	now func() time.Time
+}
+
+// NewOIDCHandler создает новый экземпляр OIDCHandler для поставщиков providers
+func NewOIDCHandler(providers []*oidc.Provider, identityService service.IdentityServiceInterface, redirectBaseURL string) *OIDCHandler {
+	h := &OIDCHandler{
+		providers:       make(map[string]*oidc.Provider, len(providers)),
+		identityService: identityService,
+		redirectBaseURL: strings.TrimSuffix(redirectBaseURL, "/"),
+		now:             time.Now,
+	}
+	for _, provider := range providers {
+		cfg := provider.Config()
+		h.providers[cfg.Name] = provider
+		h.loginProviders = append(h.loginProviders, LoginProvider{Name: cfg.Name, Title: cfg.Title})
+	}
+	return h
+}
+
+// Providers возвращает поставщиков для кнопок на странице входа в порядке настройки
+func (h *OIDCHandler) Providers() []LoginProvider {
+	return h.loginProviders
+}
+
+// Start начинает вход через поставщика: сохраняет в сессии state, nonce и верификатор PKCE
+// и перенаправляет на страницу входа поставщика
+func (h *OIDCHandler) Start(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	cfg := provider.Config()
+
+	login := oidcLogin{
+		Provider:  cfg.Name,
+		ReturnTo:  middleware.SafeReturnTo(c.Query(middleware.ReturnToParam), webHome),
+		StartedAt: h.now().Unix(),
+	}
+	var err error
+	for _, value := range []*string{&login.State, &login.Nonce} {
+		if *value, err = oidc.RandomString(32); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		login.Verifier, err = oidc.NewVerifier()
+	}
+	var authURL string
+	if err == nil {
+		authURL, err = provider.AuthCodeURL(c.Request.Context(), h.redirectURI(c, cfg.Name), login.State, login.Nonce, login.Verifier)
+	}
+	if err == nil {
+		err = saveOIDCLogin(c, login)
+	}
+	if err != nil {
+		log.Printf("Ошибка при входе через %s: %v", cfg.Name, err)
+		loginFailed(c, "Вход через "+cfg.Title+" сейчас недоступен, попробуйте позже")
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback завершает вход: проверяет state, обменивает код на ID-токен, находит, привязывает
+// или создает пользователя и начинает его сессию
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	cfg := provider.Config()
+
+	// Незавершенный вход одноразовый: он удаляется из сессии при любом исходе
+	login, ok := takeOIDCLogin(c)
+	if !ok || login.Provider != cfg.Name || time.Unix(login.StartedAt, 0).Add(oidcLoginTTL).Before(h.now()) ||
+		subtle.ConstantTimeCompare([]byte(login.State), []byte(c.Query("state"))) != 1 {
+		loginFailed(c, "Сеанс входа через "+cfg.Title+" истек, попробуйте снова")
+		return
+	}
+	if reason := c.Query("error"); reason != "" {
+		log.Printf("Поставщик %s отклонил вход: %s %s", cfg.Name, reason, c.Query("error_description"))
+		loginFailed(c, "Вход через "+cfg.Title+" отменен или не разрешен")
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), login.Verifier, h.redirectURI(c, cfg.Name), login.Nonce)
+	if err != nil {
+		log.Printf("Ошибка при входе через %s: %v", cfg.Name, err)
+		loginFailed(c, "Не удалось выполнить вход через "+cfg.Title+", попробуйте позже")
+		return
+	}
+
+	// Пользователь, созданный при входе, попадает в журнал аудита с исполнителем oidc:<поставщик>
+	meta := service.RequestMetaFromContext(c.Request.Context())
+	meta.Actor = "oidc:" + cfg.Name
+	ctx := service.WithRequestMeta(c.Request.Context(), meta)
+
+	user, err := h.identityService.ResolveUser(ctx, externalIdentity(cfg, claims), cfg.Provision)
+	if errors.Is(err, service.ErrIdentityNotLinked) {
+		loginFailed(c, "Учетная запись "+cfg.Title+" не связана ни с одним пользователем")
+		return
+	}
+	if err == nil {
+		err = signIn(c, user)
+	}
+	if err != nil {
+		log.Printf("Ошибка при входе через %s: %v", cfg.Name, err)
+		loginFailed(c, "Не удалось выполнить вход, попробуйте позже")
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, login.ReturnTo)
+}
+
+// redirectURI возвращает адрес возврата от поставщика; он должен быть зарегистрирован у поставщика
+func (h *OIDCHandler) redirectURI(c *gin.Context, name string) string {
+	base := h.redirectBaseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/web/login/oidc/" + name + "/callback"
+}
+
+// externalIdentity переводит утверждения ID-токена в учетную запись поставщика. Email считается подтвержденным,
+// только если поставщик его подтвердил и домен разрешен для поставщика.
+func externalIdentity(cfg oidc.ProviderConfig, claims *oidc.Claims) service.ExternalIdentity {
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	return service.ExternalIdentity{
+		Provider:      cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified) && cfg.AllowsEmail(claims.Email),
+		FirstName:     firstName,
+		LastName:      strings.TrimSpace(lastName),
+	}
+}
+
+// saveOIDCLogin сохраняет незавершенный вход в сессии строкой JSON
+func saveOIDCLogin(c *gin.Context, login oidcLogin) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+	s := sessions.Default(c)
+	s.Set(oidcLoginKey, string(data))
+	return s.Save()
+}
+
+// takeOIDCLogin извлекает незавершенный вход из сессии и удаляет его; сессию сохраняет вызывающий,
+// начиная сессию пользователя или показывая ошибку
+func takeOIDCLogin(c *gin.Context) (oidcLogin, bool) {
+	var login oidcLogin
+	s := sessions.Default(c)
+	data, ok := s.Get(oidcLoginKey).(string)
+	if !ok {
+		return login, false
+	}
+	s.Delete(oidcLoginKey)
+	return login, json.Unmarshal([]byte(data), &login) == nil
+}
+
+// loginFailed показывает сообщение об ошибке на странице входа
+func loginFailed(c *gin.Context, message string) {
+	s := sessions.Default(c)
+	s.AddFlash(message, "error")
+	if err := s.Save(); err != nil {
+		log.Printf("Ошибка при сохранении сессии: %v", err)
+	}
+	c.Redirect(http.StatusSeeOther, "/web/login")
+}
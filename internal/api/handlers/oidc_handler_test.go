@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/oidc/oidctest"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockIdentityService имитирует сервис учетных записей внешних поставщиков для тестирования
+type MockIdentityService struct {
+	mock.Mock
+}
+
+// Убедимся что MockIdentityService реализует service.IdentityServiceInterface
+var _ service.IdentityServiceInterface = (*MockIdentityService)(nil)
+
+func (m *MockIdentityService) ResolveUser(ctx context.Context, identity service.ExternalIdentity, provision bool) (*models.User, error) {
+	args := m.Called(identity, provision, service.RequestMetaFromContext(ctx).Actor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockIdentityService) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Identity), args.Error(1)
+}
+
+// setupOIDCTestRouter создает маршрутизатор со входом через поставщика idp под именем corp;
+// /whoami возвращает ID пользователя сессии
+func setupOIDCTestRouter(t *testing.T, idp *oidctest.Server, spec string) (*gin.Engine, *MockIdentityService) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default().Session
+	cfg.Keys = []string{"session-key-0123456789abcdef0123"}
+	store, err := session.NewStore(memory.NewSessionRepository(memory.NewDB()), cfg)
+	require.NoError(t, err)
+
+	providerCfg, err := oidc.ParseProvider("name=corp;title=Corp SSO;issuer=" + idp.URL + ";client_id=" + idp.ClientID + ";client_secret=" + idp.ClientSecret + spec)
+	require.NoError(t, err)
+	identityService := new(MockIdentityService)
+	handler := NewOIDCHandler([]*oidc.Provider{oidc.NewProvider(providerCfg, idp.Client())}, identityService, "")
+
+	router := gin.New()
+	router.Use(sessions.Sessions(session.CookieName, store))
+	router.GET("/web/login/oidc/:provider", handler.Start)
+	router.GET("/web/login/oidc/:provider/callback", handler.Callback)
+	router.GET("/whoami", func(c *gin.Context) {
+		userID, _ := sessions.Default(c).Get(session.UserIDKey).(string)
+		c.String(http.StatusOK, userID)
+	})
+	return router, identityService
+}
+
+func serve(router *gin.Engine, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == session.CookieName {
+			return cookie
+		}
+	}
+	t.Fatal("no session cookie in response")
+	return nil
+}
+
+// startLogin начинает вход, проходит авторизацию у поставщика и возвращает адрес возврата и cookie сессии
+func startLogin(t *testing.T, router *gin.Engine, idp *oidctest.Server, returnTo string) (string, *http.Cookie) {
+	w := serve(router, "/web/login/oidc/corp?return_to="+url.QueryEscape(returnTo), nil)
+	require.Equal(t, http.StatusFound, w.Code)
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "S256", authURL.Query().Get("code_challenge_method"))
+	assert.Equal(t, "http://example.com/web/login/oidc/corp/callback", authURL.Query().Get("redirect_uri"))
+
+	callback, err := idp.Authorize(authURL.String())
+	require.NoError(t, err)
+	u, err := url.Parse(callback)
+	require.NoError(t, err)
+	return u.RequestURI(), sessionCookie(t, w)
+}
+
+func TestOIDCHandler_Login(t *testing.T) {
+	idp := oidctest.NewServer("user-api", "secret")
+	defer idp.Close()
+	router, identityService := setupOIDCTestRouter(t, idp, ";provision=true;domains=example.com")
+
+	user := &models.User{ID: uuid.New(), Email: "user@example.com"}
+	identityService.On("ResolveUser", service.ExternalIdentity{
+		Provider: "corp", Subject: "user-1", Email: "user@example.com", EmailVerified: true, FirstName: "Test", LastName: "User",
+	}, true, "oidc:corp").Return(user, nil).Once()
+
+	callback, cookie := startLogin(t, router, idp, "/web/users?page=2")
+	w := serve(router, callback, cookie)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/web/users?page=2", w.Header().Get("Location"))
+
+	// Сессия получила новый токен и пользователя
+	renewed := sessionCookie(t, w)
+	assert.NotEqual(t, cookie.Value, renewed.Value)
+	assert.Equal(t, user.ID.String(), serve(router, "/whoami", renewed).Body.String())
+
+	// Повторный возврат с тем же кодом отклоняется: незавершенный вход одноразовый
+	w = serve(router, callback, renewed)
+	assert.Equal(t, "/web/login", w.Header().Get("Location"))
+	identityService.AssertExpectations(t)
+}
+
+func TestOIDCHandler_LoginFailures(t *testing.T) {
+	idp := oidctest.NewServer("user-api", "")
+	defer idp.Close()
+	router, identityService := setupOIDCTestRouter(t, idp, ";domains=corp.example.com")
+
+	// Case: неизвестный поставщик
+	assert.Equal(t, http.StatusNotFound, serve(router, "/web/login/oidc/other", nil).Code)
+
+	// Case: state не совпадает - код не обменивается
+	callback, cookie := startLogin(t, router, idp, "/web/users")
+	forged, err := url.Parse(callback)
+	require.NoError(t, err)
+	query := forged.Query()
+	query.Set("state", "forged")
+	forged.RawQuery = query.Encode()
+	w := serve(router, forged.RequestURI(), cookie)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/web/login", w.Header().Get("Location"))
+
+	// Case: вход без сессии, в которой он начат
+	callback, _ = startLogin(t, router, idp, "/web/users")
+	assert.Equal(t, "/web/login", serve(router, callback, nil).Header().Get("Location"))
+
+	// Case: учетная запись не привязана; email из чужого домена не считается подтвержденным
+	identityService.On("ResolveUser", mock.MatchedBy(func(identity service.ExternalIdentity) bool {
+		return identity.Subject == "user-1" && !identity.EmailVerified
+	}), false, "oidc:corp").Return(nil, service.ErrIdentityNotLinked).Once()
+	callback, cookie = startLogin(t, router, idp, "/web/users")
+	w = serve(router, callback, cookie)
+	assert.Equal(t, "/web/login", w.Header().Get("Location"))
+	assert.Empty(t, serve(router, "/whoami", sessionCookie(t, w)).Body.String())
+	identityService.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionHandler обрабатывает HTTP-запросы управления сессиями пользователей
+type SessionHandler struct {
+	sessionService service.SessionServiceInterface
+}
+
+// NewSessionHandler создает новый экземпляр SessionHandler
+func NewSessionHandler(sessionService service.SessionServiceInterface) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// List обрабатывает GET /users/:id/sessions
+func (h *SessionHandler) List(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	sessions, err := h.sessionService.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		sessionError(c, err, "Failed to list sessions")
+		return
+	}
+	if sessions == nil {
+		sessions = []*models.Session{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// Revoke обрабатывает DELETE /users/:id/sessions/:sessionId
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, c.Param("sessionId")); err != nil {
+		sessionError(c, err, "Failed to revoke session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAll обрабатывает DELETE /users/:id/sessions - выход на всех устройствах
+func (h *SessionHandler) RevokeAll(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAll(c.Request.Context(), userID)
+	if err != nil {
+		sessionError(c, err, "Failed to revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// sessionError отвечает на ошибку сервиса сессий: отсутствующие пользователь или сессия - 404
+func sessionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionService имитирует сервис сессий для тестирования
+type MockSessionService struct {
+	mock.Mock
+}
+
+// Убедимся что MockSessionService реализует service.SessionServiceInterface
+var _ service.SessionServiceInterface = (*MockSessionService)(nil)
+
+func (m *MockSessionService) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) Revoke(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) RevokeAll(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func setupSessionTestRouter() (*gin.Engine, *MockSessionService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockService := new(MockSessionService)
+	handler := NewSessionHandler(mockService)
+
+	users := router.Group("/users")
+	{
+		users.GET("/:id/sessions", handler.List)
+		users.DELETE("/:id/sessions", handler.RevokeAll)
+		users.DELETE("/:id/sessions/:sessionId", handler.Revoke)
+	}
+
+	return router, mockService
+}
+
+func TestSessionHandler_List(t *testing.T) {
+	router, mockService := setupSessionTestRouter()
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		sessions := []*models.Session{{ID: "abc", UserID: &userID, UserAgent: "Mozilla/5.0", SourceIP: "10.0.0.1"}}
+		mockService.On("ListByUser", userID).Return(sessions, nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/sessions", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string][]map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response["sessions"], 1)
+		assert.Equal(t, "abc", response["sessions"][0]["id"])
+		assert.NotContains(t, w.Body.String(), "data")
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("ListByUser", userID).Return(nil, repository.ErrUserNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/sessions", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestSessionHandler_Revoke(t *testing.T) {
+	router, mockService := setupSessionTestRouter()
+
+	t.Run("Single session", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("Revoke", userID, "abc").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions/abc", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Session of another user", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("Revoke", userID, "foreign").Return(repository.ErrSessionNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions/foreign", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("All sessions", func(t *testing.T) {
+		userID := uuid.New()
+		mockService.On("RevokeAll", userID).Return(int64(3), nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"revoked":3}`, w.Body.String())
+	})
+
+	mockService.AssertExpectations(t)
+}
@@ -1,12 +1,21 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/Est1ege/go-user-api/internal/domain/models"
 	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/pkg/export"
 )
 
 // UserHandler обрабатывает HTTP-запросы для пользователей
@@ -29,13 +38,10 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Create(input)
+	user, err := h.userService.Create(c.Request.Context(), input)
 	if err != nil {
-		if err == service.ErrEmailAlreadyExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		status, message := createErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
@@ -50,7 +56,7 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetByID(id)
+	user, err := h.userService.GetByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -59,6 +65,54 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// GetByEmail обрабатывает GET /users/by-email/:email
+func (h *UserHandler) GetByEmail(c *gin.Context) {
+	email := c.Param("email")
+	if err := binding.Validator.Engine().(*validator.Validate).Var(email, "required,email"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+
+	user, err := h.userService.GetByEmail(c.Request.Context(), email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// AuditLog обрабатывает GET /users/:id/audit
+func (h *UserHandler) AuditLog(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var page models.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = service.DefaultPageLimit
+	}
+
+	events, total, err := h.userService.AuditLog(c.Request.Context(), id, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"total":  total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
 // Update обрабатывает PUT /users/:id
 func (h *UserHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -73,13 +127,10 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Update(id, input)
+	user, err := h.userService.Update(c.Request.Context(), id, input)
 	if err != nil {
-		if err == service.ErrEmailAlreadyExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		status, message := updateErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
@@ -94,10 +145,320 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+	if err := h.userService.Delete(c.Request.Context(), id); err != nil {
+		status, message := deleteErrorResponse(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
-}
\ No newline at end of file
+}
+
+// Batch обрабатывает POST /users/batch
+//
+// Операции выполняются в одной транзакции. Для каждой операции возвращается статус,
+// который вернул бы соответствующий одиночный запрос. При "atomic": true ошибка любой
+// операции откатывает весь пакет, а остальные операции получают статус 424.
+func (h *UserHandler) Batch(c *gin.Context) {
+	var request models.BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]gin.H, len(request.Operations))
+	var ops []models.BatchOperation
+	var opIndexes []int
+	for i, raw := range request.Operations {
+		op, status, message := parseBatchOperation(raw)
+		if status != 0 {
+			results[i] = batchResult(i, raw.Op, status, gin.H{"error": message})
+			continue
+		}
+		ops = append(ops, op)
+		opIndexes = append(opIndexes, i)
+	}
+
+	// В атомарном режиме пакет с некорректными операциями не выполняется вовсе
+	invalid := len(ops) < len(request.Operations)
+	if invalid && request.Atomic {
+		for i, op := range ops {
+			results[opIndexes[i]] = batchResult(opIndexes[i], op.Op, http.StatusFailedDependency, gin.H{"error": batchSkippedMessage})
+		}
+		c.JSON(http.StatusOK, gin.H{"committed": false, "results": results})
+		return
+	}
+
+	committed := false
+	if len(ops) > 0 {
+		opResults, ok, err := h.userService.Batch(c.Request.Context(), ops, request.Atomic)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute batch"})
+			return
+		}
+		committed = ok
+		for i, result := range opResults {
+			index := opIndexes[i]
+			status, body := batchOperationResponse(ops[i].Op, result)
+			results[index] = batchResult(index, ops[i].Op, status, body)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"committed": committed, "results": results})
+}
+
+// Search обрабатывает GET /users/search?q=
+func (h *UserHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	results, err := h.userService.Search(c.Request.Context(), query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	if results == nil {
+		results = []*models.UserSearchResult{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// List обрабатывает GET /users
+func (h *UserHandler) List(c *gin.Context) {
+	var filter models.UserFilter
+	var page models.Page
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = service.DefaultPageLimit
+	}
+
+	users, total, err := h.userService.List(c.Request.Context(), filter, page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"total":  total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+// Export обрабатывает GET /users/export
+//
+// Параметры: format (csv, json, ndjson; по умолчанию csv), fields (список полей через запятую)
+// и те же фильтры, что и у GET /users.
+func (h *UserHandler) Export(c *gin.Context) {
+	var filter models.UserFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatCSV)
+	fields, err := parseExportFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writer, err := export.NewWriter(format, c.Writer, fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format))
+	c.Header("Trailer", ExportStatusTrailer)
+	c.Status(http.StatusOK)
+
+	err = h.userService.Export(c.Request.Context(), filter, func(users []*models.User) error {
+		for _, user := range users {
+			record := make(export.Record, len(fields))
+			for i, field := range fields {
+				value, ok := user.ExportValue(field)
+				if !ok {
+					return fmt.Errorf("unknown export field: %s", field)
+				}
+				record[i] = value
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("User export aborted: %v", err)
+		abortExport(c)
+		return
+	}
+	c.Writer.Header().Set(ExportStatusTrailer, "complete")
+}
+
+// ExportStatusTrailer - трейлер ответа выгрузки: complete, если выгрузка записана целиком, иначе error
+const ExportStatusTrailer = "X-Export-Status"
+
+// abortExport сообщает клиенту об ошибке посреди выгрузки. Статус 200 уже отправлен, поэтому соединение
+// HTTP/1.x обрывается без завершающего блока chunked - клиент получит ошибку чтения, а не усеченный файл;
+// в HTTP/2 поток завершается с трейлером X-Export-Status: error.
+func abortExport(c *gin.Context) {
+	c.Writer.Header().Set(ExportStatusTrailer, "error")
+	if c.Request.ProtoMajor != 1 {
+		return
+	}
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		log.Printf("Failed to abort user export: %v", err)
+		return
+	}
+	conn.Close()
+}
+
+// parseExportFields разбирает список полей выгрузки, по умолчанию выгружаются все доступные поля.
+// Повторенное поле - ошибка: иначе в CSV появятся одинаковые столбцы, а в JSON - одинаковые ключи.
+func parseExportFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return models.UserExportFields, nil
+	}
+
+	allowed := make(map[string]bool, len(models.UserExportFields))
+	for _, field := range models.UserExportFields {
+		allowed[field] = true
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !allowed[field] {
+			return nil, fmt.Errorf("unknown export field: %s", field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("duplicate export field: %s", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// batchSkippedMessage - ошибка операций, отмененных откатом атомарного пакета
+const batchSkippedMessage = "Operation not applied: batch rolled back"
+
+// parseBatchOperation разбирает и валидирует операцию пакета так же, как это делают одиночные обработчики.
+// Ненулевой статус означает, что операция некорректна.
+func parseBatchOperation(raw models.BatchRequestOperation) (models.BatchOperation, int, string) {
+	op := models.BatchOperation{Op: raw.Op}
+
+	if raw.Op == models.BatchOpUpdate || raw.Op == models.BatchOpDelete {
+		id, err := uuid.Parse(raw.ID)
+		if err != nil {
+			return op, http.StatusBadRequest, "Invalid user ID"
+		}
+		op.ID = id
+	}
+
+	var target interface{}
+	switch raw.Op {
+	case models.BatchOpCreate:
+		target = &op.Create
+	case models.BatchOpUpdate:
+		target = &op.Update
+	case models.BatchOpDelete:
+		return op, 0, ""
+	default:
+		return op, http.StatusBadRequest, fmt.Sprintf("Unknown operation: %q", raw.Op)
+	}
+
+	if len(raw.Data) == 0 {
+		return op, http.StatusBadRequest, "Operation data is required"
+	}
+	if err := json.Unmarshal(raw.Data, target); err != nil {
+		return op, http.StatusBadRequest, err.Error()
+	}
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		return op, http.StatusBadRequest, err.Error()
+	}
+	return op, 0, ""
+}
+
+// batchOperationResponse формирует статус и тело ответа операции пакета
+func batchOperationResponse(op string, result models.BatchOperationResult) (int, interface{}) {
+	if result.Skipped {
+		return http.StatusFailedDependency, gin.H{"error": batchSkippedMessage}
+	}
+
+	switch op {
+	case models.BatchOpCreate:
+		if result.Err != nil {
+			status, message := createErrorResponse(result.Err)
+			return status, gin.H{"error": message}
+		}
+		return http.StatusCreated, result.User
+	case models.BatchOpUpdate:
+		if result.Err != nil {
+			status, message := updateErrorResponse(result.Err)
+			return status, gin.H{"error": message}
+		}
+		return http.StatusOK, result.User
+	default:
+		if result.Err != nil {
+			status, message := deleteErrorResponse(result.Err)
+			return status, gin.H{"error": message}
+		}
+		return http.StatusOK, gin.H{"message": "User deleted successfully"}
+	}
+}
+
+func batchResult(index int, op string, status int, body interface{}) gin.H {
+	return gin.H{"index": index, "op": op, "status": status, "body": body}
+}
+
+// createErrorResponse сопоставляет ошибку создания пользователя HTTP-статусу
+func createErrorResponse(err error) (int, string) {
+	if errors.Is(err, service.ErrEmailAlreadyExists) {
+		return http.StatusConflict, "Email already exists"
+	}
+	return http.StatusInternalServerError, "Failed to create user"
+}
+
+// updateErrorResponse сопоставляет ошибку обновления пользователя HTTP-статусу
+func updateErrorResponse(err error) (int, string) {
+	if errors.Is(err, service.ErrEmailAlreadyExists) {
+		return http.StatusConflict, "Email already exists"
+	}
+	return http.StatusInternalServerError, "Failed to update user"
+}
+
+// deleteErrorResponse сопоставляет ошибку удаления пользователя HTTP-статусу
+func deleteErrorResponse(err error) (int, string) {
+	return http.StatusInternalServerError, "Failed to delete user"
+}
@@ -2,16 +2,20 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/Est1ege/go-user-api/internal/domain/models"
 	"github.com/Est1ege/go-user-api/internal/service"
 )
@@ -21,7 +25,7 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) GetAll() ([]*models.User, error) {
+func (m *MockUserService) GetAll(ctx context.Context) ([]*models.User, error) {
     args := m.Called()
     if args.Get(0) == nil {
         return nil, args.Error(1)
@@ -33,7 +37,7 @@ func (m *MockUserService) GetAll() ([]*models.User, error) {
 var _ service.UserServiceInterface = (*MockUserService)(nil)
 
 
-func (m *MockUserService) Create(input models.CreateUserInput) (*models.User, error) {
+func (m *MockUserService) Create(ctx context.Context, input models.CreateUserInput) (*models.User, error) {
 	args := m.Called(input)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -41,7 +45,7 @@ func (m *MockUserService) Create(input models.CreateUserInput) (*models.User, er
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) GetByID(id uuid.UUID)  (*models.User, error) {
+func (m *MockUserService) GetByID(ctx context.Context, id uuid.UUID)  (*models.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -49,7 +53,15 @@ func (m *MockUserService) GetByID(id uuid.UUID)  (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) Update(id uuid.UUID, input models.UpdateUserInput) (*models.User, error) {
+func (m *MockUserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserService) Update(ctx context.Context, id uuid.UUID, input models.UpdateUserInput) (*models.User, error) {
 	args := m.Called(id, input)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -57,11 +69,63 @@ func (m *MockUserService) Update(id uuid.UUID, input models.UpdateUserInput) (*m
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) Delete(id uuid.UUID) error {
+func (m *MockUserService) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockUserService) List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error) {
+	args := m.Called(filter, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) Export(ctx context.Context, filter models.UserFilter, fn func(users []*models.User) error) error {
+	args := m.Called(filter, fn)
+	if batches, ok := args.Get(0).([][]*models.User); ok {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserService) Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]models.BatchOperationResult, bool, error) {
+	args := m.Called(ops, atomic)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).([]models.BatchOperationResult), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserService) Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UserSearchResult), args.Error(1)
+}
+
+func (m *MockUserService) AuditLog(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error) {
+	args := m.Called(userID, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	args := m.Called(email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func setupTestRouter() (*gin.Engine, *MockUserService) {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
@@ -72,7 +136,13 @@ func setupTestRouter() (*gin.Engine, *MockUserService) {
 	userRoutes := router.Group("/users")
 	{
 		userRoutes.POST("", handler.Create)
+		userRoutes.GET("", handler.List)
+		userRoutes.GET("/export", handler.Export)
+		userRoutes.POST("/batch", handler.Batch)
+		userRoutes.GET("/search", handler.Search)
 		userRoutes.GET("/:id", handler.GetByID)
+		userRoutes.GET("/by-email/:email", handler.GetByEmail)
+		userRoutes.GET("/:id/audit", handler.AuditLog)
 		userRoutes.PUT("/:id", handler.Update)
 		userRoutes.DELETE("/:id", handler.Delete)
 	}
@@ -188,6 +258,43 @@ func TestUserHandler_GetByID(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestUserHandler_GetByEmail(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	// Test case: пользователь найден
+	mockService.On("GetByEmail", "Test@Example.com").Return(user, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/by-email/Test@Example.com", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, user.ID, response.ID)
+
+	// Test case: пользователь не найден
+	mockService.On("GetByEmail", "missing@example.com").Return(nil, errors.New("user not found")).Once()
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/by-email/missing@example.com", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Test case: некорректный email
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/by-email/not-an-email", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
 func TestUserHandler_Update(t *testing.T) {
 	// Arrange
 	router, mockService := setupTestRouter()
@@ -265,4 +372,305 @@ func TestUserHandler_Delete(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	
 	mockService.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestUserHandler_List(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	users := []*models.User{{ID: uuid.New(), Email: "test@example.com"}}
+	filter := models.UserFilter{Email: "example"}
+
+	// Test case: лимит по умолчанию и фильтр из query-параметров
+	mockService.On("List", filter, models.Page{Limit: service.DefaultPageLimit, Offset: 10}).Return(users, int64(11), nil).Once()
+
+	// Act
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users?email=example&offset=10", nil)
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Users []models.User `json:"users"`
+		Total int64         `json:"total"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), response.Total)
+	assert.Len(t, response.Users, 1)
+
+	// Test case: некорректный лимит
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users?limit=100000", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_Export(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	firstID, secondID := uuid.New(), uuid.New()
+	batches := [][]*models.User{
+		{{ID: firstID, Email: "a@example.com", FirstName: "Ann", Password: "hash"}},
+		{{ID: secondID, Email: "b@example.com", FirstName: "Bob", Password: "hash"}},
+	}
+	mockService.On("Export", models.UserFilter{}, mock.Anything).Return(batches, nil)
+
+	// Test case: CSV с выбранными полями
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/export?format=csv&fields=id,email", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,email\n"+firstID.String()+",a@example.com\n"+secondID.String()+",b@example.com\n", w.Body.String())
+	assert.NotContains(t, w.Body.String(), "hash")
+
+	// Test case: JSON-массив
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?format=json&fields=email", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rows []map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]string{{"email": "a@example.com"}, {"email": "b@example.com"}}, rows)
+
+	// Test case: ключи объектов идут в порядке fields
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?format=ndjson&fields=last_name,email,id", nil)
+	router.ServeHTTP(w, req)
+
+	assert.True(t, strings.HasPrefix(w.Body.String(), `{"last_name":"","email":"a@example.com","id":"`), w.Body.String())
+
+	// Test case: NDJSON, все поля по умолчанию, без пароля
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?format=ndjson", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	for _, line := range lines {
+		var row map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &row))
+		assert.Len(t, row, len(models.UserExportFields))
+		assert.NotContains(t, row, "password")
+	}
+
+	// Test case: неизвестное поле
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?fields=password", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Test case: повторенное поле
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?format=json&fields=email,id,email", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"duplicate export field: email"}`, w.Body.String())
+
+	// Test case: неизвестный формат
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/export?format=xml", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserHandler_ExportAbort(t *testing.T) {
+	// Arrange: настоящий сервер, чтобы проверить трейлеры и обрыв соединения
+	router, mockService := setupTestRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	batches := [][]*models.User{{{ID: uuid.New(), Email: "a@example.com"}}}
+	mockService.On("Export", models.UserFilter{Email: "ok"}, mock.Anything).Return(batches, nil).Once()
+	mockService.On("Export", models.UserFilter{Email: "fail"}, mock.Anything).Return(batches, errors.New("connection lost")).Once()
+
+	// Test case: полная выгрузка завершается трейлером complete
+	resp, err := http.Get(server.URL + "/users/export?format=json&email=ok")
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "complete", resp.Trailer.Get(ExportStatusTrailer))
+
+	// Test case: ошибка после начала записи обрывает ответ, а не отдает усеченный JSON
+	resp, err = http.Get(server.URL + "/users/export?format=json&email=fail")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Error(t, err)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_Batch(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	id := uuid.New()
+	createdUser := &models.User{ID: uuid.New(), Email: "new@example.com"}
+	body := func(atomic bool) *bytes.Buffer {
+		payload, _ := json.Marshal(gin.H{
+			"atomic": atomic,
+			"operations": []gin.H{
+				{"op": "create", "data": gin.H{"email": "new@example.com", "first_name": "New", "last_name": "User", "password": "password123"}},
+				{"op": "update", "id": id.String(), "data": gin.H{"email": "taken@example.com"}},
+				{"op": "delete", "id": "invalid-id"},
+			},
+		})
+		return bytes.NewBuffer(payload)
+	}
+	type batchResponse struct {
+		Committed bool `json:"committed"`
+		Results   []struct {
+			Index  int             `json:"index"`
+			Status int             `json:"status"`
+			Body   json.RawMessage `json:"body"`
+		} `json:"results"`
+	}
+
+	// Test case: неатомарный пакет - некорректная операция не выполняется, остальные получают свои статусы
+	mockService.On("Batch", mock.MatchedBy(func(ops []models.BatchOperation) bool {
+		return len(ops) == 2 && ops[0].Op == models.BatchOpCreate && ops[1].ID == id
+	}), false).Return([]models.BatchOperationResult{
+		{User: createdUser},
+		{Err: service.ErrEmailAlreadyExists},
+	}, true, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users/batch", body(false))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response batchResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Committed)
+	assert.Len(t, response.Results, 3)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, http.StatusConflict, response.Results[1].Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[2].Status)
+	assert.Contains(t, string(response.Results[0].Body), createdUser.ID.String())
+
+	// Test case: атомарный пакет с некорректной операцией не выполняется
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/batch", body(true))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response = batchResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Committed)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[1].Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[2].Status)
+
+	// Test case: пустой пакет
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/batch", bytes.NewBufferString(`{"operations": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_Search(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", FirstName: "John", LastName: "Doe"}
+	results := []*models.UserSearchResult{{
+		User:       user,
+		Rank:       0.5,
+		Highlights: map[string]string{"first_name": "<mark>Jo</mark>hn"},
+	}}
+
+	// Test case: успешный поиск
+	mockService.On("Search", "jo", 5).Return(results, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/search?q=jo&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Results []models.UserSearchResult `json:"results"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 1)
+	assert.Equal(t, user.ID, response.Results[0].User.ID)
+	assert.Equal(t, "<mark>Jo</mark>hn", response.Results[0].Highlights["first_name"])
+
+	// Test case: пустой запрос
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/search?q=%20", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Test case: некорректный лимит
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/search?q=jo&limit=abc", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserHandler_AuditLog(t *testing.T) {
+	// Arrange
+	router, mockService := setupTestRouter()
+
+	id := uuid.New()
+	events := []*models.AuditEvent{{
+		ID:      uuid.New(),
+		UserID:  id,
+		Action:  models.AuditActionUpdate,
+		Actor:   "admin@example.com",
+		Changes: map[string]models.FieldChange{"password": {New: models.AuditPasswordChanged}},
+	}}
+
+	// Test case: успешное получение журнала
+	mockService.On("AuditLog", id, models.Page{Limit: service.DefaultPageLimit}).Return(events, int64(1), nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/"+id.String()+"/audit", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Events []models.AuditEvent `json:"events"`
+		Total  int64               `json:"total"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+	assert.Equal(t, "admin@example.com", response.Events[0].Actor)
+	assert.Equal(t, models.AuditPasswordChanged, response.Events[0].Changes["password"].New)
+
+	// Test case: некорректный ID
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/invalid-id/audit", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
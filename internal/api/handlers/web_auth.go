@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// webHome - страница, на которую веб-интерфейс возвращается после входа по умолчанию
+const webHome = "/web/users"
+
+// loginInput - данные формы входа
+type loginInput struct {
+	Email    string `form:"email" binding:"required"`
+	Password string `form:"password" binding:"required"`
+	ReturnTo string `form:"return_to"`
+}
+
+// LoginPage отображает форму входа; вошедший пользователь сразу перенаправляется дальше
+func (h *WebHandler) LoginPage(c *gin.Context) {
+	returnTo := middleware.SafeReturnTo(c.Query(middleware.ReturnToParam), webHome)
+	if _, ok := sessions.Default(c).Get(session.UserIDKey).(string); ok {
+		c.Redirect(http.StatusSeeOther, returnTo)
+		return
+	}
+
+	data := gin.H{"ReturnTo": returnTo}
+	if flashes := sessions.Default(c).Flashes("error"); len(flashes) > 0 {
+		data["Error"] = flashes[0]
+		sessions.Default(c).Save()
+	}
+	h.loginPage(c, http.StatusOK, data)
+}
+
+// Login проверяет email и пароль и выполняет вход: сессия получает новый токен и ID пользователя
+func (h *WebHandler) Login(c *gin.Context) {
+	var input loginInput
+	if err := c.ShouldBind(&input); err != nil {
+		h.loginPage(c, http.StatusBadRequest, gin.H{
+			"Error":    "Введите email и пароль",
+			"Email":    input.Email,
+			"ReturnTo": middleware.SafeReturnTo(input.ReturnTo, webHome),
+		})
+		return
+	}
+	returnTo := middleware.SafeReturnTo(input.ReturnTo, webHome)
+
+	user, err := h.userService.Authenticate(c.Request.Context(), input.Email, input.Password)
+	if err != nil {
+		status, message := http.StatusUnauthorized, "Неверный email или пароль"
+		if !errors.Is(err, service.ErrInvalidCredentials) {
+			log.Printf("Ошибка при входе: %v", err)
+			status, message = http.StatusInternalServerError, "Не удалось выполнить вход, попробуйте позже"
+		}
+		h.loginPage(c, status, gin.H{
+			"Error":    message,
+			"Email":    input.Email,
+			"ReturnTo": returnTo,
+		})
+		return
+	}
+
+	if err := signIn(c, user); err != nil {
+		log.Printf("Ошибка при сохранении сессии: %v", err)
+		h.loginPage(c, http.StatusInternalServerError, gin.H{
+			"Error":    "Не удалось выполнить вход, попробуйте позже",
+			"Email":    input.Email,
+			"ReturnTo": returnTo,
+		})
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, returnTo)
+}
+
+// signIn начинает сессию пользователя: прежние данные сессии удаляются, а сессия получает новый токен
+func signIn(c *gin.Context, user *models.User) error {
+	s := sessions.Default(c)
+	s.Clear()
+	s.Set(session.UserIDKey, user.ID.String())
+	session.Renew(s)
+	return s.Save()
+}
+
+// Logout завершает сессию и возвращает на страницу входа
+func (h *WebHandler) Logout(c *gin.Context) {
+	s := sessions.Default(c)
+	s.Clear()
+	s.Options(sessions.Options{MaxAge: -1})
+	if err := s.Save(); err != nil {
+		log.Printf("Ошибка при завершении сессии: %v", err)
+	}
+
+	c.Redirect(http.StatusSeeOther, "/web/login")
+}
+
+// WithLoginProviders добавляет на страницу входа кнопки входа через внешних поставщиков (см. OIDCHandler)
+func (h *WebHandler) WithLoginProviders(providers []LoginProvider) *WebHandler {
+	h.loginProviders = providers
+	return h
+}
+
+// loginPage отображает форму входа с кнопками внешних поставщиков
+func (h *WebHandler) loginPage(c *gin.Context, status int, data gin.H) {
+	data["Providers"] = h.loginProviders
+	c.HTML(status, "login.html", pageData(c, data))
+}
+
+// pageData добавляет в данные шаблона пользователя, вошедшего в веб-интерфейс, и CSRF-токен для форм
+func pageData(c *gin.Context, data gin.H) gin.H {
+	data["CurrentUser"] = middleware.CurrentUser(c)
+	data["CSRFToken"] = middleware.CSRFToken(c)
+	return data
+}
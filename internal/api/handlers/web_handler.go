@@ -1,8 +1,11 @@
 package handlers
 
 import (
+    "errors"
+    "html/template"
     "log"  // Добавьте импорт для логирования
     "net/http"
+    "strings"
     
     "github.com/gin-contrib/sessions"  // Добавьте импорт для сессий
     "github.com/gin-gonic/gin"
@@ -14,6 +17,8 @@ import (
 // WebHandler представляет обработчики для веб-интерфейса
 type WebHandler struct {
     userService *service.UserService
+    // loginProviders - внешние поставщики для входа, кнопки которых показываются на странице входа
+    loginProviders []LoginProvider
 }
 
 // NewWebHandler создает новый экземпляр WebHandler
@@ -33,13 +38,20 @@ func (h *WebHandler) Index(c *gin.Context) {
     // Сохраняем сессию
     session.Save()
     
+    // Поиск пользователей, если задан запрос
+    query := strings.TrimSpace(c.Query("q"))
+    if query != "" {
+        h.search(c, query, success, error)
+        return
+    }
+    
     // Получение всех пользователей
-    users, err := h.userService.GetAll()
+    users, err := h.userService.GetAll(c.Request.Context())
     if err != nil {
         log.Printf("Ошибка при получении списка пользователей: %v", err)
-        c.HTML(http.StatusInternalServerError, "index.html", gin.H{
+        c.HTML(http.StatusInternalServerError, "index.html", pageData(c, gin.H{
             "Error": "Ошибка при получении списка пользователей: " + err.Error(),
-        })
+        }))
         return
     }
     
@@ -55,7 +67,44 @@ func (h *WebHandler) Index(c *gin.Context) {
     }
     
     // Рендерим шаблон
-    c.HTML(http.StatusOK, "index.html", data)
+    c.HTML(http.StatusOK, "index.html", pageData(c, data))
+}
+
+// search отображает результаты поиска с подсветкой совпадений
+func (h *WebHandler) search(c *gin.Context, query string, success, error []interface{}) {
+    results, err := h.userService.Search(c.Request.Context(), query, service.MaxSearchLimit)
+    if err != nil {
+        log.Printf("Ошибка при поиске пользователей: %v", err)
+        c.HTML(http.StatusInternalServerError, "index.html", pageData(c, gin.H{
+            "Error": "Ошибка при поиске пользователей, попробуйте позже",
+            "Query": query,
+        }))
+        return
+    }
+    
+    // Подсветка уже экранирована сервисом, поэтому передаем ее в шаблон как HTML
+    users := make([]*models.User, 0, len(results))
+    highlights := make(map[string]map[string]template.HTML, len(results))
+    for _, result := range results {
+        users = append(users, result.User)
+        fields := make(map[string]template.HTML, len(result.Highlights))
+        for field, value := range result.Highlights {
+            fields[field] = template.HTML(value)
+        }
+        highlights[result.User.ID.String()] = fields
+    }
+    
+    data := gin.H{"Users": users, "Highlights": highlights, "Query": query}
+    
+    if len(success) > 0 {
+        data["Success"] = success[0]
+    }
+    
+    if len(error) > 0 {
+        data["Error"] = error[0]
+    }
+    
+    c.HTML(http.StatusOK, "index.html", pageData(c, data))
 }
 
 // Create создает нового пользователя через веб-форму
@@ -74,33 +123,33 @@ func (h *WebHandler) Create(c *gin.Context) {
         log.Printf("Ошибка привязки данных: %v", err)
         
         // Получаем всех пользователей для отображения на странице
-        users, _ := h.userService.GetAll()
+        users, _ := h.userService.GetAll(c.Request.Context())
         
-        c.HTML(http.StatusOK, "index.html", gin.H{
+        c.HTML(http.StatusOK, "index.html", pageData(c, gin.H{
             "Error": "Ошибка валидации данных: " + err.Error(),
             "Users": users,
-        })
+        }))
         return
     }
     
     log.Printf("Данные для создания пользователя: %+v", input)
     
-    _, err := h.userService.Create(input)
+    _, err := h.userService.Create(c.Request.Context(), input)
     if err != nil {
         log.Printf("Ошибка при создании пользователя: %v", err)
         
         errorMessage := "Ошибка при создании пользователя"
-        if err == service.ErrEmailAlreadyExists {
+        if errors.Is(err, service.ErrEmailAlreadyExists) {
             errorMessage = "Email уже используется"
         }
         
         // Получаем всех пользователей для отображения на странице
-        users, _ := h.userService.GetAll()
+        users, _ := h.userService.GetAll(c.Request.Context())
         
-        c.HTML(http.StatusOK, "index.html", gin.H{
+        c.HTML(http.StatusOK, "index.html", pageData(c, gin.H{
             "Error": errorMessage,
             "Users": users,
-        })
+        }))
         return
     }
     
@@ -140,12 +189,12 @@ func (h *WebHandler) Update(c *gin.Context) {
         return
     }
     
-    _, err = h.userService.Update(id, input)
+    _, err = h.userService.Update(c.Request.Context(), id, input)
     if err != nil {
         log.Printf("Ошибка при обновлении пользователя: %v", err)
         
         errorMessage := "Ошибка при обновлении пользователя"
-        if err == service.ErrEmailAlreadyExists {
+        if errors.Is(err, service.ErrEmailAlreadyExists) {
             errorMessage = "Email уже используется"
         }
         
@@ -181,7 +230,7 @@ func (h *WebHandler) Delete(c *gin.Context) {
         return
     }
     
-    if err := h.userService.Delete(id); err != nil {
+    if err := h.userService.Delete(c.Request.Context(), id); err != nil {
         log.Printf("Ошибка при удалении пользователя: %v", err)
         
         // Добавляем сообщение об ошибке в сессию
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebhookHandler обрабатывает HTTP-запросы управления вебхуками
+type WebhookHandler struct {
+	webhookService service.WebhookServiceInterface
+}
+
+// NewWebhookHandler создает новый экземпляр WebhookHandler
+func NewWebhookHandler(webhookService service.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// createdWebhook - ответ на создание подписки; секрет возвращается только один раз
+type createdWebhook struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// Create обрабатывает POST /webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var input models.CreateWebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.webhookService.Create(c.Request.Context(), input)
+	if errors.Is(err, service.ErrWebhookURLNotAllowed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdWebhook{WebhookSubscription: subscription, Secret: subscription.Secret})
+}
+
+// List обрабатывает GET /webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	subscriptions, err := h.webhookService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhooks"})
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []*models.WebhookSubscription{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": subscriptions})
+}
+
+// GetByID обрабатывает GET /webhooks/:id
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	subscription, err := h.webhookService.Get(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err, "Failed to get webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// Delete обрабатывает DELETE /webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhookService.Delete(c.Request.Context(), id); err != nil {
+		webhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// Deliveries обрабатывает GET /webhooks/:id/deliveries
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var page models.Page
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page.Limit == 0 {
+		page.Limit = service.DefaultPageLimit
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), id, page)
+	if err != nil {
+		webhookError(c, err, "Failed to list deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+	})
+}
+
+// Replay обрабатывает POST /webhooks/deliveries/:id/replay
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.Replay(c.Request.Context(), id)
+	if err != nil {
+		webhookError(c, err, "Failed to replay delivery")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// webhookError отвечает 404, если подписка или доставка не найдена, иначе 500 с указанным сообщением
+func webhookError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
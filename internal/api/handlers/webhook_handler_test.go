@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookService имитирует сервис вебхуков для тестирования
+type MockWebhookService struct {
+	mock.Mock
+}
+
+// Убедимся что MockWebhookService реализует service.WebhookServiceInterface
+var _ service.WebhookServiceInterface = (*MockWebhookService)(nil)
+
+func (m *MockWebhookService) Create(ctx context.Context, input models.CreateWebhookInput) (*models.WebhookSubscription, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) List(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) Get(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error) {
+	args := m.Called(subscriptionID, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockWebhookService) Replay(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func setupWebhookTestRouter() (*gin.Engine, *MockWebhookService) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	webhooks := router.Group("/webhooks")
+	{
+		webhooks.POST("", handler.Create)
+		webhooks.GET("", handler.List)
+		webhooks.GET("/:id", handler.GetByID)
+		webhooks.DELETE("/:id", handler.Delete)
+		webhooks.GET("/:id/deliveries", handler.Deliveries)
+		webhooks.POST("/deliveries/:id/replay", handler.Replay)
+	}
+
+	return router, mockService
+}
+
+func TestWebhookHandler_Create(t *testing.T) {
+	router, mockService := setupWebhookTestRouter()
+
+	t.Run("Secret is returned on creation only", func(t *testing.T) {
+		input := models.CreateWebhookInput{URL: "https://example.com/hooks", EventTypes: []string{models.EventUserCreated}}
+		subscription := &models.WebhookSubscription{ID: uuid.New(), URL: input.URL, EventTypes: input.EventTypes, Secret: "whsec_test", Active: true}
+		mockService.On("Create", input).Return(subscription, nil).Once()
+		mockService.On("Get", subscription.ID).Return(subscription, nil).Once()
+
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "whsec_test", created["secret"])
+		assert.Equal(t, input.URL, created["url"])
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/"+subscription.ID.String(), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "whsec_test")
+	})
+
+	t.Run("Loopback URL", func(t *testing.T) {
+		input := models.CreateWebhookInput{URL: "http://127.0.0.1:8080/hooks", EventTypes: []string{models.EventUserCreated}}
+		mockService.On("Create", input).Return(nil, fmt.Errorf("%w: loopback", service.ErrWebhookURLNotAllowed)).Once()
+
+		body, _ := json.Marshal(input)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "webhook url is not allowed")
+	})
+
+	t.Run("Unknown event type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := `{"url":"https://example.com/hooks","event_types":["user.renamed"]}`
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
+
+func TestWebhookHandler_Replay(t *testing.T) {
+	router, mockService := setupWebhookTestRouter()
+
+	t.Run("Accepted", func(t *testing.T) {
+		originalID := uuid.New()
+		replay := &models.WebhookDelivery{ID: uuid.New(), Status: models.WebhookDeliveryPending, ReplayOf: &originalID}
+		mockService.On("Replay", originalID).Return(replay, nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/"+originalID.String()+"/replay", nil))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Contains(t, w.Body.String(), originalID.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		id := uuid.New()
+		mockService.On("Replay", id).Return(nil, repository.ErrWebhookNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/deliveries/"+id.String()+"/replay", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	mockService.AssertExpectations(t)
+}
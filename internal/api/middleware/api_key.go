@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyScheme - схема заголовка Authorization с API-ключом: "Authorization: ApiKey uak_..."
+const APIKeyScheme = "ApiKey"
+
+// APIKeyKey - ключ контекста gin с API-ключом, которым аутентифицирован запрос
+const APIKeyKey = "api_key"
+
+// APIKeyIDKey - ключ контекста gin с ID API-ключа, которым аутентифицирован запрос
+const APIKeyIDKey = "api_key_id"
+
+// apiKeyAuthKey - ключ контекста gin, который APIKeyAuth ставит каждому пропущенному запросу, даже без ключа:
+// по нему RateLimit узнает, что маршрут проверяет API-ключи
+const apiKeyAuthKey = "api_key_auth"
+
+// APIKeyAuth проверяет API-ключ из заголовка Authorization. Запрос с недействительным ключом получает 401.
+// Запрос без ключа получает 401, если required, и иначе пропускается без аутентификации.
+// Ключ сохраняется в контексте (см. CurrentAPIKey), его ID используется правилами ограничения частоты
+// с ключом api_key, а исполнителем изменений в журнале аудита становится владелец ключа.
+func APIKeyAuth(apiKeyService service.APIKeyServiceInterface, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiKeyAuthKey, true)
+		raw, ok := apiKeyFromHeader(c.GetHeader("Authorization"))
+		if !ok {
+			if required {
+				unauthorized(c, "API key required")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(c.Request.Context(), raw)
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			unauthorized(c, "Invalid API key")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to authenticate API key: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate API key"})
+			return
+		}
+
+		c.Set(APIKeyKey, key)
+		c.Set(APIKeyIDKey, key.ID.String())
+		meta := service.RequestMetaFromContext(c.Request.Context())
+		if key.UserID != nil {
+			meta.Actor = key.UserID.String()
+		} else {
+			meta.Actor = "service:" + key.ServiceAccount
+		}
+		c.Request = c.Request.WithContext(service.WithRequestMeta(c.Request.Context(), meta))
+
+		c.Next()
+	}
+}
+
+// RequireScope пропускает запросы с API-ключом, которому выдана область действия scope, и запросы без ключа
+// (их допускает или отклоняет APIKeyAuth); ключ без нужной области действия получает 403
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := CurrentAPIKey(c); key != nil && !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key lacks scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentAPIKey возвращает API-ключ запроса или nil
+func CurrentAPIKey(c *gin.Context) *models.APIKey {
+	value, _ := c.Get(APIKeyKey)
+	key, _ := value.(*models.APIKey)
+	return key
+}
+
+// apiKeyFromHeader извлекает ключ из значения заголовка Authorization; схема сравнивается без учета регистра
+func apiKeyFromHeader(header string) (string, bool) {
+	scheme, key, ok := strings.Cut(strings.TrimSpace(header), " ")
+	key = strings.TrimSpace(key)
+	if !ok || !strings.EqualFold(scheme, APIKeyScheme) || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func unauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", APIKeyScheme)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAPIKeyRouter создает маршрутизатор с чтением и изменением пользователей и сервис ключей для него
+func setupAPIKeyRouter(t *testing.T, required bool) (*gin.Engine, *service.APIKeyService) {
+	db := memory.NewDB()
+	apiKeyService := service.NewAPIKeyService(memory.NewAPIKeyRepository(db), memory.NewUserRepository(db))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", APIKeyAuth(apiKeyService, required))
+	api.GET("/users", RequireScope(models.ScopeUsersRead), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(APIKeyIDKey)+" "+service.RequestMetaFromContext(c.Request.Context()).Actor)
+	})
+	api.POST("/users", RequireScope(models.ScopeUsersWrite), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return router, apiKeyService
+}
+
+func apiKeyRequest(router *gin.Engine, method, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/users", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	router, apiKeyService := setupAPIKeyRouter(t, true)
+	key, raw, err := apiKeyService.Create(context.Background(), models.CreateAPIKeyInput{
+		Name: "provisioning", ServiceAccount: "provisioning", Scopes: []string{models.ScopeUsersRead},
+	})
+	require.NoError(t, err)
+
+	t.Run("Valid key", func(t *testing.T) {
+		w := apiKeyRequest(router, http.MethodGet, "ApiKey "+raw)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, key.ID.String()+" service:provisioning", w.Body.String())
+	})
+
+	t.Run("Scheme is case-insensitive", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "apikey "+raw).Code)
+	})
+
+	t.Run("Missing scope", func(t *testing.T) {
+		w := apiKeyRequest(router, http.MethodPost, "ApiKey "+raw)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"API key lacks scope users:write"}`, w.Body.String())
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		w := apiKeyRequest(router, http.MethodGet, "ApiKey "+raw+"x")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "ApiKey", w.Header().Get("WWW-Authenticate"))
+		assert.JSONEq(t, `{"error":"Invalid API key"}`, w.Body.String())
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		for _, header := range []string{"", "Bearer " + raw, "ApiKey "} {
+			w := apiKeyRequest(router, http.MethodGet, header)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+			assert.JSONEq(t, `{"error":"API key required"}`, w.Body.String(), header)
+		}
+	})
+
+	t.Run("Revoked key", func(t *testing.T) {
+		require.NoError(t, apiKeyService.Revoke(context.Background(), key.ID))
+
+		assert.Equal(t, http.StatusUnauthorized, apiKeyRequest(router, http.MethodGet, "ApiKey "+raw).Code)
+	})
+}
+
+func TestAPIKeyAuth_Optional(t *testing.T) {
+	router, _ := setupAPIKeyRouter(t, false)
+
+	// Без ключа запросы проходят без проверки областей действия, неверный ключ отклоняется
+	assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, "").Code)
+	assert.Equal(t, http.StatusCreated, apiKeyRequest(router, http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, apiKeyRequest(router, http.MethodGet, "ApiKey uak_unknown").Code)
+}
+
+func TestAPIKeyAuth_RateLimitByKey(t *testing.T) {
+	db := memory.NewDB()
+	apiKeyService := service.NewAPIKeyService(memory.NewAPIKeyRepository(db), memory.NewUserRepository(db))
+	limiter, err := ratelimit.New(ratelimit.NewMemoryStore(), []string{"api:api_key:1/1m"})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/users", APIKeyAuth(apiKeyService, false), RateLimit(limiter, "api"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	var raws []string
+	for _, name := range []string{"first", "second"} {
+		_, raw, err := apiKeyService.Create(context.Background(), models.CreateAPIKeyInput{
+			Name: name, ServiceAccount: name, Scopes: []string{models.ScopeUsersRead},
+		})
+		require.NoError(t, err)
+		raws = append(raws, raw)
+	}
+
+	// Ключи с одного IP считаются по отдельности, запросы без ключа - по IP
+	for _, authorization := range []string{"ApiKey " + raws[0], "ApiKey " + raws[1], ""} {
+		assert.Equal(t, http.StatusOK, apiKeyRequest(router, http.MethodGet, authorization).Code, authorization)
+		assert.Equal(t, http.StatusTooManyRequests, apiKeyRequest(router, http.MethodGet, authorization).Code, authorization)
+	}
+}
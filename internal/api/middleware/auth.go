@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CurrentUserKey - ключ контекста gin с пользователем, вошедшим в веб-интерфейс
+const CurrentUserKey = "current_user"
+
+// ReturnToParam - параметр страницы входа с адресом, на который нужно вернуться после входа
+const ReturnToParam = "return_to"
+
+// RequireWebLogin пропускает только запросы с сессией вошедшего пользователя; остальные перенаправляются
+// на страницу входа loginPath с адресом текущей страницы. Пользователь сохраняется в контексте
+// (см. CurrentUser) и становится исполнителем изменений в журнале аудита.
+func RequireWebLogin(userService service.UserServiceInterface, loginPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := sessionUser(c, userService)
+		if err != nil {
+			log.Printf("Failed to load session user: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			c.Redirect(http.StatusSeeOther, loginPath+"?"+ReturnToParam+"="+url.QueryEscape(returnTo(c)))
+			c.Abort()
+			return
+		}
+
+		c.Set(CurrentUserKey, user)
+		meta := service.RequestMetaFromContext(c.Request.Context())
+		meta.Actor = user.ID.String()
+		c.Request = c.Request.WithContext(service.WithRequestMeta(c.Request.Context(), meta))
+
+		c.Next()
+	}
+}
+
+// RequireAdmin пропускает только администраторов; остальным показывается сообщение об ошибке на странице redirectTo.
+// Используется после RequireWebLogin.
+func RequireAdmin(redirectTo string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if user := CurrentUser(c); user != nil && user.IsAdmin() {
+			c.Next()
+			return
+		}
+
+		session := sessions.Default(c)
+		session.AddFlash("Недостаточно прав: действие доступно только администраторам", "error")
+		session.Save()
+		c.Redirect(http.StatusSeeOther, redirectTo)
+		c.Abort()
+	}
+}
+
+// CurrentUser возвращает пользователя, вошедшего в веб-интерфейс, или nil
+func CurrentUser(c *gin.Context) *models.User {
+	user, _ := c.Get(CurrentUserKey)
+	current, _ := user.(*models.User)
+	return current
+}
+
+// sessionUser возвращает пользователя сессии или nil, если вход не выполнен.
+// Сессия удаленного пользователя очищается.
+func sessionUser(c *gin.Context, userService service.UserServiceInterface) (*models.User, error) {
+	s := sessions.Default(c)
+	value, _ := s.Get(session.UserIDKey).(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, nil
+	}
+
+	user, err := userService.GetByID(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		s.Clear()
+		s.Save()
+		return nil, nil
+	}
+	return user, err
+}
+
+// returnTo возвращает адрес, на который нужно вернуться после входа: текущую страницу для GET-запросов
+// и страницу, с которой отправлена форма, для остальных
+func returnTo(c *gin.Context) string {
+	if c.Request.Method == http.MethodGet {
+		return c.Request.URL.RequestURI()
+	}
+	if referer, err := url.Parse(c.Request.Referer()); err == nil && referer.Host == c.Request.Host {
+		return referer.RequestURI()
+	}
+	return ""
+}
+
+// SafeReturnTo возвращает target, если это путь на этом же сайте, и fallback в остальных случаях,
+// чтобы параметр возврата нельзя было использовать для перенаправления на чужой сайт
+func SafeReturnTo(target, fallback string) string {
+	u, err := url.Parse(target)
+	if err != nil || target == "" || u.Scheme != "" || u.Host != "" || u.User != nil ||
+		target[0] != '/' || (len(target) > 1 && (target[1] == '/' || target[1] == '\\')) {
+		return fallback
+	}
+	return target
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserService отдает пользователей по ID; остальные методы сервиса не используются
+type stubUserService struct {
+	service.UserServiceInterface
+	users map[uuid.UUID]*models.User
+}
+
+func (s *stubUserService) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if user, ok := s.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// setupAuthRouter создает маршрутизатор: /login?user= входит, /pages и /admin требуют входа и роли администратора
+func setupAuthRouter(t *testing.T, users ...*models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default().Session
+	cfg.Keys = []string{"session-key-0123456789abcdef0123"}
+	store, err := session.NewStore(memory.NewSessionRepository(memory.NewDB()), cfg)
+	require.NoError(t, err)
+
+	userService := &stubUserService{users: make(map[uuid.UUID]*models.User)}
+	for _, user := range users {
+		userService.users[user.ID] = user
+	}
+
+	router := gin.New()
+	router.Use(sessions.Sessions(session.CookieName, store))
+	router.GET("/login", func(c *gin.Context) {
+		s := sessions.Default(c)
+		s.Set(session.UserIDKey, c.Query("user"))
+		require.NoError(t, s.Save())
+	})
+
+	authorized := router.Group("", RequireWebLogin(userService, "/login"))
+	authorized.GET("/pages", func(c *gin.Context) {
+		c.String(http.StatusOK, CurrentUser(c).Email+" "+service.RequestMetaFromContext(c.Request.Context()).Actor)
+	})
+	authorized.POST("/admin", RequireAdmin("/pages"), func(c *gin.Context) {
+		c.String(http.StatusOK, "done")
+	})
+	return router
+}
+
+func request(router *gin.Engine, method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func loginAs(t *testing.T, router *gin.Engine, user *models.User) *http.Cookie {
+	cookies := request(router, http.MethodGet, "/login?user="+user.ID.String(), nil).Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+func TestRequireWebLogin(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+	router := setupAuthRouter(t, user)
+
+	t.Run("Anonymous request is redirected with return URL", func(t *testing.T) {
+		w := request(router, http.MethodGet, "/pages?q=john", nil)
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/login?return_to="+url.QueryEscape("/pages?q=john"), w.Header().Get("Location"))
+	})
+
+	t.Run("Logged in user becomes the audit actor", func(t *testing.T) {
+		w := request(router, http.MethodGet, "/pages", loginAs(t, router, user))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user@example.com "+user.ID.String(), w.Body.String())
+	})
+
+	t.Run("Deleted user is logged out", func(t *testing.T) {
+		deleted := &models.User{ID: uuid.New()}
+		w := request(router, http.MethodGet, "/pages", loginAs(t, router, deleted))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+	})
+}
+
+func TestRequireAdmin(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+	admin := &models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin}
+	router := setupAuthRouter(t, user, admin)
+
+	w := request(router, http.MethodPost, "/admin", loginAs(t, router, user))
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/pages", w.Header().Get("Location"))
+
+	w = request(router, http.MethodPost, "/admin", loginAs(t, router, admin))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "done", w.Body.String())
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	cases := map[string]string{
+		"/web/users?q=john":      "/web/users?q=john",
+		"":                       "/home",
+		"https://evil.example":   "/home",
+		"//evil.example/path":    "/home",
+		"/\\evil.example":        "/home",
+		"javascript:alert(1)":    "/home",
+		"web/users":              "/home",
+		"http:///evil.example/x": "/home",
+	}
+	for target, expected := range cases {
+		assert.Equal(t, expected, SafeReturnTo(target, "/home"), target)
+	}
+}
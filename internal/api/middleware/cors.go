@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS разрешает браузерам обращаться к запросам с путем, начинающимся с pathPrefix, с источников
+// из cfg.AllowedOrigins. Предварительные запросы (OPTIONS с Access-Control-Request-Method) обрабатываются
+// сразу, поэтому middleware подключается ко всему маршрутизатору, а не к группе: для OPTIONS
+// маршрутов нет. Запросы с неразрешенных источников проходят без заголовков CORS, и браузер
+// не отдает ответ странице; такой предварительный запрос отклоняется со статусом 403.
+func CORS(cfg config.CORSConfig, pathPrefix string) gin.HandlerFunc {
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origins[strings.ToLower(origin)] = true
+	}
+	anyOrigin := origins["*"]
+
+	methods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		methods[strings.ToUpper(method)] = true
+	}
+	headers := make(map[string]bool, len(cfg.AllowedHeaders))
+	for _, header := range cfg.AllowedHeaders {
+		headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		if len(origins) == 0 || !strings.HasPrefix(c.Request.URL.Path, pathPrefix) {
+			c.Next()
+			return
+		}
+
+		// Ответ зависит от Origin, поэтому кеши должны хранить его отдельно для каждого источника
+		c.Writer.Header().Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		allowed := anyOrigin || origins[strings.ToLower(origin)]
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed || !methods[strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))] ||
+				!allowedHeaders(headers, c.GetHeader("Access-Control-Request-Headers")) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+		} else if !allowed {
+			c.Next()
+			return
+		}
+
+		if anyOrigin && !cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", allowHeaders)
+			}
+			c.Header("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
+
+// allowedHeaders проверяет, что все заголовки из Access-Control-Request-Headers разрешены
+func allowedHeaders(allowed map[string]bool, requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !allowed[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
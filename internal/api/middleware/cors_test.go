@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCORSRouter(cfg config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(cfg, "/api/"))
+	router.GET("/api/users", func(c *gin.Context) { c.String(http.StatusOK, "users") })
+	router.GET("/web/users", func(c *gin.Context) { c.String(http.StatusOK, "page") })
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.AllowCredentials = true
+	router := setupCORSRouter(cfg)
+
+	t.Run("Allowed origin", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "/api/users", "https://app.example.com", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("Other origin gets no CORS headers", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "/api/users", "https://evil.example.com", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("Preflight", func(t *testing.T) {
+		w := corsRequest(router, http.MethodOptions, "/api/users", "https://app.example.com", map[string]string{
+			"Access-Control-Request-Method":  "DELETE",
+			"Access-Control-Request-Headers": "content-type, x-request-id",
+		})
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST, PUT, PATCH, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, Authorization, X-Request-ID", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("Preflight with forbidden method, header or origin", func(t *testing.T) {
+		for _, tc := range []struct{ origin, method, headers string }{
+			{"https://app.example.com", "TRACE", ""},
+			{"https://app.example.com", "GET", "X-Custom"},
+			{"https://evil.example.com", "GET", ""},
+		} {
+			w := corsRequest(router, http.MethodOptions, "/api/users", tc.origin, map[string]string{
+				"Access-Control-Request-Method":  tc.method,
+				"Access-Control-Request-Headers": tc.headers,
+			})
+			assert.Equal(t, http.StatusForbidden, w.Code, tc)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), tc)
+		}
+	})
+
+	t.Run("Web UI is not shared", func(t *testing.T) {
+		w := corsRequest(router, http.MethodGet, "/web/users", "https://app.example.com", nil)
+
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	cfg := config.Default().CORS
+	cfg.AllowedOrigins = []string{"*"}
+	router := setupCORSRouter(cfg)
+
+	w := corsRequest(router, http.MethodGet, "/api/users", "https://any.example.com", nil)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFTokenKey - ключ контекста gin с CSRF-токеном текущей сессии
+	CSRFTokenKey = "csrf_token"
+	// CSRFFormField - поле формы с CSRF-токеном
+	CSRFFormField = "csrf_token"
+	// CSRFHeader - заголовок с CSRF-токеном для запросов из JavaScript
+	CSRFHeader = "X-CSRF-Token"
+
+	// csrfSessionKey - значение сессии с CSRF-токеном
+	csrfSessionKey = "csrf_token"
+)
+
+// CSRF защищает формы от подделки межсайтовых запросов. Каждая сессия получает случайный токен,
+// который формы передают в поле CSRFFormField (см. CSRFField) или заголовке CSRFHeader. Запросы,
+// изменяющие данные (кроме GET, HEAD и OPTIONS), без совпадающего токена отклоняются страницей
+// ошибки error.html со статусом 403.
+func CSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		token, _ := session.Get(csrfSessionKey).(string)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if token == "" {
+				var err error
+				if token, err = newCSRFToken(); err != nil {
+					log.Printf("Failed to generate CSRF token: %v", err)
+					c.AbortWithStatus(http.StatusInternalServerError)
+					return
+				}
+				session.Set(csrfSessionKey, token)
+				if err := session.Save(); err != nil {
+					log.Printf("Failed to save CSRF token: %v", err)
+				}
+			}
+		default:
+			sent := c.GetHeader(CSRFHeader)
+			if sent == "" {
+				sent = c.PostForm(CSRFFormField)
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				c.HTML(http.StatusForbidden, "error.html", gin.H{
+					"Title":   "Форма устарела",
+					"Message": "Не удалось подтвердить, что форма отправлена с этой страницы. Возможно, сессия истекла или страница была открыта слишком давно. Вернитесь назад, обновите страницу и повторите действие.",
+					"Back":    "/web/users",
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set(CSRFTokenKey, token)
+		c.Next()
+	}
+}
+
+// CSRFToken возвращает CSRF-токен текущей сессии для передачи в шаблон
+func CSRFToken(c *gin.Context) string {
+	return c.GetString(CSRFTokenKey)
+}
+
+// CSRFField - функция шаблонов: скрытое поле формы с CSRF-токеном, например {{csrfField .CSRFToken}}
+func CSRFField(token string) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + CSRFFormField + `" value="` + template.HTMLEscapeString(token) + `">`)
+}
+
+func newCSRFToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
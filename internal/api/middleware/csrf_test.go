@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCSRFRouter создает маршрутизатор: GET /form отдает поле с токеном, POST /form принимает форму
+func setupCSRFRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default().Session
+	cfg.Keys = []string{"session-key-0123456789abcdef0123"}
+	store, err := session.NewStore(memory.NewSessionRepository(memory.NewDB()), cfg)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.SetHTMLTemplate(template.Must(template.New("error.html").Parse(`{{.Title}}`)))
+	router.Use(sessions.Sessions(session.CookieName, store), CSRF())
+	router.GET("/form", func(c *gin.Context) {
+		c.String(http.StatusOK, string(CSRFField(CSRFToken(c))))
+	})
+	router.POST("/form", func(c *gin.Context) {
+		c.String(http.StatusOK, "accepted")
+	})
+	return router
+}
+
+// csrfForm получает форму и возвращает cookie сессии и токен из скрытого поля
+func csrfForm(t *testing.T, router *gin.Engine, cookie *http.Cookie) (*http.Cookie, string) {
+	w := request(router, http.MethodGet, "/form", cookie)
+	require.Equal(t, http.StatusOK, w.Code)
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		cookie = cookies[0]
+	}
+	body := w.Body.String()
+	start := strings.Index(body, `value="`) + len(`value="`)
+	return cookie, body[start:strings.LastIndex(body, `"`)]
+}
+
+func postForm(router *gin.Engine, cookie *http.Cookie, token string, header bool) *httptest.ResponseRecorder {
+	form := url.Values{"name": {"value"}}
+	if token != "" && !header {
+		form.Set(CSRFFormField, token)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if header {
+		req.Header.Set(CSRFHeader, token)
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCSRF(t *testing.T) {
+	router := setupCSRFRouter(t)
+	cookie, token := csrfForm(t, router, nil)
+	require.NotEmpty(t, token)
+
+	t.Run("Token is stable within the session", func(t *testing.T) {
+		_, again := csrfForm(t, router, cookie)
+		assert.Equal(t, token, again)
+	})
+
+	t.Run("Form field and header are accepted", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, postForm(router, cookie, token, false).Code)
+		assert.Equal(t, http.StatusOK, postForm(router, cookie, token, true).Code)
+	})
+
+	t.Run("Missing or foreign token is rejected with the error page", func(t *testing.T) {
+		w := postForm(router, cookie, "", false)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "Форма устарела", w.Body.String())
+
+		otherCookie, otherToken := csrfForm(t, router, nil)
+		assert.NotEqual(t, token, otherToken)
+		assert.Equal(t, http.StatusForbidden, postForm(router, cookie, otherToken, false).Code)
+		assert.Equal(t, http.StatusForbidden, postForm(router, otherCookie, token, false).Code)
+	})
+
+	t.Run("Request without a session is rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, postForm(router, nil, token, false).Code)
+	})
+}
@@ -22,12 +22,13 @@ func Logger() gin.HandlerFunc {
 
 		// Логирование информации о запросе
 		log.Printf(
-			"[%s] %s %s %d %s",
+			"[%s] %s %s %d %s %s",
 			c.Request.Method,
 			c.Request.URL.Path,
 			c.ClientIP(),
 			c.Writer.Status(),
 			latency,
+			c.GetString("request_id"),
 		)
 	}
 }
\ No newline at end of file
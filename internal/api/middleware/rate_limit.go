@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает частоту запросов по правилам группы group. Каждое правило считает запросы
+// по своему ключу (IP, пользователь, API-ключ); запрос проходит, только если токен нашелся по всем правилам.
+// Ответ содержит заголовки RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset
+// по самому строгому правилу, а отклоненный запрос получает статус 429 и Retry-After.
+// Правила с ключом пользователя подключаются после RequireWebLogin, с ключом API-ключа - после APIKeyAuth;
+// если маршрут не проверяет API-ключи, правило с ключом api_key считает запросы по IP, и об этом один раз
+// пишется предупреждение в журнал. Если хранилище недоступно, запросы пропускаются. При limiter, равном nil,
+// или группе без правил middleware ничего не делает.
+func RateLimit(limiter *ratelimit.Limiter, group string) gin.HandlerFunc {
+	if limiter == nil || len(limiter.Policies(group)) == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	policies := limiter.Policies(group)
+	var warnAPIKey sync.Once
+
+	headers := make([]string, len(policies))
+	for i, policy := range policies {
+		headers[i] = policy.Header()
+	}
+	policyHeader := strings.Join(headers, ", ")
+
+	return func(c *gin.Context) {
+		var strictest *ratelimit.Result
+		for _, policy := range policies {
+			if policy.Key == ratelimit.KeyAPIKey && !c.GetBool(apiKeyAuthKey) {
+				warnAPIKey.Do(func() {
+					log.Printf("Rate limit group %s counts requests by API key, but %s does not authenticate API keys: requests are counted by IP", group, c.FullPath())
+				})
+			}
+			result, err := limiter.Take(c.Request.Context(), policy, rateLimitKey(c, policy.Key))
+			if err != nil {
+				log.Printf("Rate limit store failed: %v", err)
+				continue
+			}
+			if strictest == nil || !result.Allowed && strictest.Allowed ||
+				result.Allowed == strictest.Allowed && result.Remaining < strictest.Remaining {
+				strictest = &result
+			}
+		}
+		if strictest == nil {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policyHeader)
+		c.Header("RateLimit-Limit", strconv.Itoa(strictest.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(strictest.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(strictest.Reset))
+		if strictest.Allowed {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", ceilSeconds(strictest.RetryAfter))
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+			c.HTML(http.StatusTooManyRequests, "error.html", gin.H{
+				"Title":   "Слишком много запросов",
+				"Message": "Вы отправляете запросы слишком часто. Подождите " + ceilSeconds(strictest.RetryAfter) + " с и попробуйте снова.",
+			})
+		} else {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+		}
+		c.Abort()
+	}
+}
+
+// rateLimitKey возвращает ключ корзины запроса; без пользователя или API-ключа запросы считаются по IP
+func rateLimitKey(c *gin.Context, key ratelimit.KeyType) string {
+	switch key {
+	case ratelimit.KeyUser:
+		if user := CurrentUser(c); user != nil {
+			return "user:" + user.ID.String()
+		}
+	case ratelimit.KeyAPIKey:
+		if id := c.GetString(APIKeyIDKey); id != "" {
+			return "api_key:" + id
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRateLimitRouter создает маршрутизатор с группой api (по IP) и web (по пользователю из заголовка X-User)
+func setupRateLimitRouter(t *testing.T, policies ...string) *gin.Engine {
+	limiter, err := ratelimit.New(ratelimit.NewMemoryStore(), policies)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.SetHTMLTemplate(template.Must(template.New("error.html").Parse(`{{.Title}}`)))
+	router.GET("/api/users", RateLimit(limiter, "api"), func(c *gin.Context) { c.String(http.StatusOK, "users") })
+	router.GET("/web/users", func(c *gin.Context) {
+		if id, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			c.Set(CurrentUserKey, &models.User{ID: id})
+		}
+	}, RateLimit(limiter, "web"), func(c *gin.Context) { c.String(http.StatusOK, "page") })
+	router.GET("/health", RateLimit(limiter, "health"), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	return router
+}
+
+func rateLimitRequest(router *gin.Engine, path, ip string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	router := setupRateLimitRouter(t, "api:ip:2/1m")
+
+	w := rateLimitRequest(router, "/api/users", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, rateLimitRequest(router, "/api/users", "10.0.0.1", nil).Code)
+
+	w = rateLimitRequest(router, "/api/users", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.JSONEq(t, `{"error":"Too many requests"}`, w.Body.String())
+
+	// Браузер получает страницу ошибки
+	w = rateLimitRequest(router, "/api/users", "10.0.0.1", map[string]string{"Accept": "text/html,application/xhtml+xml"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "Слишком много запросов", w.Body.String())
+
+	// Другой IP считается отдельно, а группа без правил не ограничена
+	assert.Equal(t, http.StatusOK, rateLimitRequest(router, "/api/users", "10.0.0.2", nil).Code)
+	w = rateLimitRequest(router, "/health", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimit_ByUser(t *testing.T) {
+	router := setupRateLimitRouter(t, "web:user:1/1m", "web:ip:10/1m")
+	first, second := uuid.New().String(), uuid.New().String()
+
+	// Пользователи за одним IP считаются по отдельности; заголовки - по самому строгому правилу
+	w := rateLimitRequest(router, "/web/users", "10.0.0.1", map[string]string{"X-User": first})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1;w=60;burst=1, 10;w=60;burst=10", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, rateLimitRequest(router, "/web/users", "10.0.0.1", map[string]string{"X-User": second}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(router, "/web/users", "10.0.0.1", map[string]string{"X-User": first}).Code)
+
+	// Анонимные запросы считаются по IP
+	assert.Equal(t, http.StatusOK, rateLimitRequest(router, "/web/users", "10.0.0.1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitRequest(router, "/web/users", "10.0.0.1", nil).Code)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// RequestContext присваивает запросу идентификатор (или берет его из X-Request-ID)
+// и сохраняет в контексте запроса сведения для журнала аудита. Запрос также образует область
+// согласованности: после записи в нем чтения выполняются на основной базе, а не на репликах.
+// Если клиент предъявил проверенный сертификат (mTLS), действующим лицом аудита становится "service:<CN>".
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		meta := service.RequestMeta{
+			SourceIP:  c.ClientIP(),
+			RequestID: requestID,
+		}
+		if state := c.Request.TLS; state != nil && len(state.VerifiedChains) > 0 {
+			meta.Actor = "service:" + state.VerifiedChains[0][0].Subject.CommonName
+		}
+		ctx := service.WithRequestMeta(c.Request.Context(), meta)
+		ctx = repository.WithReadYourWrites(ctx)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityPolicy - заголовки безопасности для группы маршрутов; пустое значение - не отправлять заголовок
+type SecurityPolicy struct {
+	ContentSecurityPolicy   string
+	FrameOptions            string
+	ReferrerPolicy          string
+	StrictTransportSecurity string
+}
+
+// WebSecurityPolicy возвращает политику для страниц веб-интерфейса
+func WebSecurityPolicy(cfg config.SecurityConfig) SecurityPolicy {
+	return newSecurityPolicy(cfg, cfg.WebCSP)
+}
+
+// APISecurityPolicy возвращает политику для API: ответы API не должны отображаться как страницы
+func APISecurityPolicy(cfg config.SecurityConfig) SecurityPolicy {
+	return newSecurityPolicy(cfg, cfg.APICSP)
+}
+
+func newSecurityPolicy(cfg config.SecurityConfig, csp string) SecurityPolicy {
+	policy := SecurityPolicy{
+		ContentSecurityPolicy: csp,
+		FrameOptions:          cfg.FrameOptions,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+	}
+	if cfg.HSTSMaxAge > 0 {
+		policy.StrictTransportSecurity = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			policy.StrictTransportSecurity += "; includeSubDomains"
+		}
+	}
+	return policy
+}
+
+// SecurityHeaders добавляет в ответы заголовки безопасности policy и X-Content-Type-Options: nosniff.
+// Заголовки заменяют ранее установленные, поэтому политику маршрутизатора можно переопределить для группы.
+func SecurityHeaders(policy SecurityPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		set := func(name, value string) {
+			if value == "" {
+				header.Del(name)
+				return
+			}
+			header.Set(name, value)
+		}
+		set("Content-Security-Policy", policy.ContentSecurityPolicy)
+		set("X-Frame-Options", policy.FrameOptions)
+		set("Referrer-Policy", policy.ReferrerPolicy)
+		set("Strict-Transport-Security", policy.StrictTransportSecurity)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.Default().Security
+	cfg.HSTSMaxAge = 365 * 24 * time.Hour
+	cfg.HSTSIncludeSubdomains = true
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecurityHeaders(APISecurityPolicy(cfg)))
+	router.GET("/api/users", func(c *gin.Context) { c.String(http.StatusOK, "users") })
+	web := router.Group("/web", SecurityHeaders(WebSecurityPolicy(cfg)))
+	web.GET("/users", func(c *gin.Context) { c.String(http.StatusOK, "page") })
+
+	w := corsRequest(router, http.MethodGet, "/api/users", "", nil)
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	// Политика группы заменяет политику маршрутизатора
+	w = corsRequest(router, http.MethodGet, "/web/users", "", nil)
+	assert.Equal(t, cfg.WebCSP, w.Header().Get("Content-Security-Policy"))
+	assert.Len(t, w.Header().Values("Content-Security-Policy"), 1)
+}
@@ -1,27 +1,51 @@
 package routes
 
 import (
+	"html/template"
 	"log"
     "os"
 	"net/http"
 
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	"github.com/Est1ege/go-user-api/internal/api/handlers"
 	"github.com/Est1ege/go-user-api/internal/api/middleware"
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
 )
 
-// SetupRouter настраивает маршруты API и веб-интерфейса
-func SetupRouter(userHandler *handlers.UserHandler, webHandler *handlers.WebHandler) *gin.Engine {
+// SetupRouter настраивает маршруты API и веб-интерфейса; oidcHandler выполняет вход через внешних поставщиков,
+// oauthHandler - сервер авторизации OAuth2/OpenID Connect (nil - сервер выключен), sessionStore хранит сессии веб-интерфейса (см. session.NewStore),
+// userService загружает пользователя, вошедшего в веб-интерфейс, apiKeyService проверяет API-ключи,
+// limiter ограничивает частоту запросов (nil - без ограничения),
+// а cfg задает доверенные прокси, обязательность API-ключей и политики CORS и заголовков безопасности
+func SetupRouter(userHandler *handlers.UserHandler, webHandler *handlers.WebHandler, webhookHandler *handlers.WebhookHandler, sessionHandler *handlers.SessionHandler, apiKeyHandler *handlers.APIKeyHandler, oidcHandler *handlers.OIDCHandler, oauthHandler *handlers.OAuthHandler, sessionStore sessions.Store, userService service.UserServiceInterface, apiKeyService service.APIKeyServiceInterface, limiter *ratelimit.Limiter, cfg *config.Config) *gin.Engine {
 	router := gin.Default()
-	
+
+	// IP клиента из X-Forwarded-For принимается только от доверенных прокси
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies: %v", err)
+	}
+
+	// Заголовки безопасности: по умолчанию - строгая политика API, веб-интерфейс переопределяет ее ниже
+	router.Use(middleware.SecurityHeaders(middleware.APISecurityPolicy(cfg.Security)))
+
+	// CORS для API; подключается к маршрутизатору, чтобы обрабатывать предварительные запросы OPTIONS
+	router.Use(middleware.CORS(cfg.CORS, "/api/"))
+	// Браузерные приложения (публичные клиенты) обмениваются кодом на токен напрямую с сервером авторизации
+	router.Use(middleware.CORS(cfg.CORS, "/oauth/"))
+
+	// Идентификатор запроса и сведения для журнала аудита
+	router.Use(middleware.RequestContext())
+
 	// Добавляем middleware для логирования
 	router.Use(middleware.Logger())
 	
 	// Настройка сессий
-	store := cookie.NewStore([]byte("secret"))
-	router.Use(sessions.Sessions("user-api-session", store))
+	router.Use(sessions.Sessions(session.CookieName, sessionStore))
 
 	// Обработка флеш-сообщений
 	router.Use(func(c *gin.Context) {
@@ -37,31 +61,112 @@ func SetupRouter(userHandler *handlers.UserHandler, webHandler *handlers.WebHand
 	
 	pwd, _ := os.Getwd()
 	log.Printf("Current working directory: %s", pwd)
-	templatePath := "templates/users/*.html"
+	templatePath := "templates/*/*.html"
 	log.Printf("Loading templates from: %s", templatePath)
+	router.SetFuncMap(template.FuncMap{"csrfField": middleware.CSRFField})
 	router.LoadHTMLGlob(templatePath)
 
+	// Метрики процесса, кеша и реплик (expvar) - только с API-ключом с областью действия metrics:read,
+	// даже если API_KEYS_REQUIRED=false
+	router.GET("/debug/vars", middleware.APIKeyAuth(apiKeyService, true), middleware.RequireScope(models.ScopeMetricsRead), handlers.Metrics)
+
 	// API v1
-	v1 := router.Group("/api/v1")
+	// Запрос аутентифицируется API-ключом до ограничения частоты, чтобы правила с ключом api_key считали по ключу;
+	// каждая группа маршрутов требует от ключа своей области действия
+	v1 := router.Group("/api/v1", middleware.APIKeyAuth(apiKeyService, cfg.APIKeys.Required), middleware.RateLimit(limiter, "api"))
 	{
 		users := v1.Group("/users")
 		{
-			users.POST("", userHandler.Create)
-			users.GET("/:id", userHandler.GetByID)
-			users.PUT("/:id", userHandler.Update)
-			users.DELETE("/:id", userHandler.Delete)
+			read := users.Group("", middleware.RequireScope(models.ScopeUsersRead))
+			read.GET("", userHandler.List)
+			read.GET("/export", userHandler.Export)
+			read.GET("/search", userHandler.Search)
+			read.GET("/:id", userHandler.GetByID)
+			read.GET("/by-email/:email", userHandler.GetByEmail)
+			read.GET("/:id/audit", userHandler.AuditLog)
+
+			// Изменения пользователей дополнительно ограничены правилами группы users.write
+			write := users.Group("", middleware.RequireScope(models.ScopeUsersWrite), middleware.RateLimit(limiter, "users.write"))
+			write.POST("", userHandler.Create)
+			write.POST("/batch", userHandler.Batch)
+			write.PUT("/:id", userHandler.Update)
+			write.DELETE("/:id", userHandler.Delete)
+
+			userSessions := users.Group("", middleware.RequireScope(models.ScopeSessionsManage))
+			userSessions.GET("/:id/sessions", sessionHandler.List)
+			userSessions.DELETE("/:id/sessions", sessionHandler.RevokeAll)
+			userSessions.DELETE("/:id/sessions/:sessionId", sessionHandler.Revoke)
+		}
+
+		webhooks := v1.Group("/webhooks", middleware.RequireScope(models.ScopeWebhooksManage))
+		{
+			webhooks.POST("", webhookHandler.Create)
+			webhooks.GET("", webhookHandler.List)
+			webhooks.GET("/:id", webhookHandler.GetByID)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.Deliveries)
+			webhooks.POST("/deliveries/:id/replay", webhookHandler.Replay)
 		}
+
+		apiKeys := v1.Group("/api-keys", middleware.RequireScope(models.ScopeAPIKeysManage))
+		{
+			apiKeys.POST("", apiKeyHandler.Create)
+			apiKeys.GET("", apiKeyHandler.List)
+			apiKeys.GET("/:id", apiKeyHandler.GetByID)
+			apiKeys.DELETE("/:id", apiKeyHandler.Revoke)
+		}
+
+		if oauthHandler != nil {
+			oauthClients := v1.Group("/oauth/clients", middleware.RequireScope(models.ScopeOAuthClientsManage))
+			oauthClients.POST("", oauthHandler.CreateClient)
+			oauthClients.GET("", oauthHandler.ListClients)
+			oauthClients.GET("/:id", oauthHandler.GetClient)
+			oauthClients.DELETE("/:id", oauthHandler.DeleteClient)
+		}
+	}
+
+	// Сервер авторизации OAuth2/OpenID Connect: страница авторизации требует входа в веб-интерфейс,
+	// остальные конечные точки аутентифицируют клиента или токен доступа сами
+	if oauthHandler != nil {
+		router.GET("/.well-known/openid-configuration", oauthHandler.Discovery)
+		router.GET(handlers.OAuthJWKSPath, oauthHandler.JWKS)
+		// Форма страницы согласия отправляется POST-запросом и проверяет CSRF-токен сессии
+		authorize := router.Group(handlers.OAuthAuthorizePath, middleware.SecurityHeaders(middleware.WebSecurityPolicy(cfg.Security)),
+			middleware.CSRF(), middleware.RequireWebLogin(userService, "/web/login"), middleware.RateLimit(limiter, "web"))
+		authorize.GET("", oauthHandler.Authorize)
+		authorize.POST("", oauthHandler.Authorize)
+
+		oauth := router.Group("", middleware.RateLimit(limiter, "api"))
+		oauth.POST(handlers.OAuthTokenPath, oauthHandler.Token)
+		oauth.POST(handlers.OAuthIntrospectPath, oauthHandler.Introspect)
+		oauth.POST(handlers.OAuthRevokePath, oauthHandler.Revoke)
+		oauth.GET(handlers.OAuthUserInfoPath, oauthHandler.UserInfo)
+		oauth.POST(handlers.OAuthUserInfoPath, oauthHandler.UserInfo)
 	}
 	
 	// Веб-интерфейс
-	web := router.Group("/web")
+	// Все формы веб-интерфейса, включая вход, проверяют CSRF-токен сессии
+	web := router.Group("/web", middleware.SecurityHeaders(middleware.WebSecurityPolicy(cfg.Security)), middleware.CSRF())
 	{
-		users := web.Group("/users")
+		web.GET("/login", webHandler.LoginPage)
+		web.POST("/login", middleware.RateLimit(limiter, "login"), webHandler.Login)
+
+		// Вход через поставщиков OpenID Connect; возврат от поставщика - GET-запрос, его защищает state
+		web.GET("/login/oidc/:provider", middleware.RateLimit(limiter, "login"), oidcHandler.Start)
+		web.GET("/login/oidc/:provider/callback", middleware.RateLimit(limiter, "login"), oidcHandler.Callback)
+
+		// Остальные страницы доступны после входа, а управление пользователями - только администраторам
+		authorized := web.Group("", middleware.RequireWebLogin(userService, "/web/login"), middleware.RateLimit(limiter, "web"))
+		authorized.POST("/logout", webHandler.Logout)
+
+		users := authorized.Group("/users")
 		{
 			users.GET("", webHandler.Index)
-			users.POST("", webHandler.Create)
-			users.POST("/:id", webHandler.Update)
-			users.POST("/:id/delete", webHandler.Delete)
+
+			admin := users.Group("", middleware.RequireAdmin("/web/users"), middleware.RateLimit(limiter, "users.write"))
+			admin.POST("", webHandler.Create)
+			admin.POST("/:id", webHandler.Update)
+			admin.POST("/:id/delete", webHandler.Delete)
 		}
 	}
 	
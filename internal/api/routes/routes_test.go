@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRouter собирает маршрутизатор приложения поверх хранилища в памяти.
+// Шаблоны загружаются по пути относительно корня репозитория, поэтому тест временно переходит в него.
+func setupTestRouter(t *testing.T) (*gin.Engine, *service.APIKeyService) {
+	gin.SetMode(gin.TestMode)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir("../../.."))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cfg := config.Default()
+	cfg.Session.Keys = []string{"0123456789abcdef0123456789abcdef"}
+	db := memory.NewDB()
+	store, err := session.NewStore(memory.NewSessionRepository(db), cfg.Session)
+	require.NoError(t, err)
+	userRepo := memory.NewUserRepository(db)
+	apiKeyService := service.NewAPIKeyService(memory.NewAPIKeyRepository(db), userRepo)
+
+	router := SetupRouter(nil, nil, nil, nil, nil, nil, nil, store, nil, apiKeyService, nil, cfg)
+	return router, apiKeyService
+}
+
+func TestSetupRouter_MetricsRequireAPIKey(t *testing.T) {
+	router, apiKeyService := setupTestRouter(t)
+	ctx := context.Background()
+	_, metricsKey, err := apiKeyService.Create(ctx, models.CreateAPIKeyInput{Name: "Monitoring", ServiceAccount: "monitoring", Scopes: []string{models.ScopeMetricsRead}})
+	require.NoError(t, err)
+	_, usersKey, err := apiKeyService.Create(ctx, models.CreateAPIKeyInput{Name: "Provisioning", ServiceAccount: "provisioning", Scopes: []string{models.ScopeUsersRead}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		// API-ключи необязательны (API_KEYS_REQUIRED=false), но метрики без ключа все равно недоступны
+		{"Anonymous", "", http.StatusUnauthorized},
+		{"Key without scope", "ApiKey " + usersKey, http.StatusForbidden},
+		{"Metrics key", "ApiKey " + metricsKey, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.True(t, json.Valid(w.Body.Bytes()))
+				assert.Contains(t, w.Body.String(), `"memstats"`)
+				assert.NotContains(t, w.Body.String(), `"cmdline"`)
+			}
+		})
+	}
+}
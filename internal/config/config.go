@@ -1,69 +1,345 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"time"
 )
 
-// Config представляет конфигурацию приложения
+// Окружения приложения
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config представляет конфигурацию приложения.
+//
+// Каждое поле описывается тегами: config - ключ в файле конфигурации и имя флага командной строки
+// (через точку для вложенных секций, например db.host), env - переменная окружения,
+// secret - признак секрета, который маскируется в "config print" ("true" - значение целиком,
+// "url" - только пароль в строке подключения, "client_secret" - только client_secret в настройках поставщика OIDC). Секрет можно задать ссылкой "file:<путь>" - тогда значением
+// станет содержимое файла (например, секрета Docker или Kubernetes), - или в зашифрованном файле секретов.
 type Config struct {
-	Server ServerConfig
-	DB     DBConfig
+	// Env - окружение: development или production; в production проверки конфигурации строже
+	Env string `config:"env" env:"APP_ENV"`
+
+	Server  ServerConfig  `config:"server"`
+	DB      DBConfig      `config:"db"`
+	Cache   CacheConfig   `config:"cache"`
+	Session SessionConfig `config:"session"`
+	Admin   AdminConfig   `config:"admin"`
+	APIKeys APIKeysConfig `config:"api_keys"`
+	OIDC    OIDCConfig    `config:"oidc"`
+	OAuth   OAuthConfig   `config:"oauth"`
+
+	Webhooks WebhooksConfig `config:"webhooks"`
+
+	CORS     CORSConfig     `config:"cors"`
+	Security SecurityConfig `config:"security"`
+
+	RateLimit RateLimitConfig `config:"rate_limit"`
+
+	Secrets SecretsConfig `config:"secrets"`
 }
 
 // ServerConfig представляет конфигурацию сервера
 type ServerConfig struct {
-	Port string
+	Port string `config:"port" env:"SERVER_PORT"`
+
+	// TrustedProxies - адреса и подсети прокси, которым доверяются заголовки X-Forwarded-For и X-Real-IP;
+	// от остальных клиентов IP-адрес берется из соединения. IP клиента используется в журнале аудита и ограничении частоты.
+	TrustedProxies []string `config:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+
+	TLS TLSConfig `config:"tls"`
+}
+
+// TLSConfig представляет конфигурацию HTTPS. Если CertFile и KeyFile не заданы, сервер работает по HTTP.
+// Сертификат перечитывается с диска каждые ReloadInterval, если файлы изменились, поэтому его можно обновить без перезапуска.
+type TLSConfig struct {
+	CertFile string `config:"cert_file" env:"SERVER_TLS_CERT_FILE"`
+	KeyFile  string `config:"key_file" env:"SERVER_TLS_KEY_FILE"`
+
+	// MinVersion - минимальная версия TLS: 1.2 или 1.3
+	MinVersion string `config:"min_version" env:"SERVER_TLS_MIN_VERSION"`
+	// CipherSuites - имена наборов шифров для TLS 1.2 (например, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256);
+	// пусто - наборы Go по умолчанию. Наборы TLS 1.3 не настраиваются.
+	CipherSuites []string `config:"cipher_suites" env:"SERVER_TLS_CIPHER_SUITES"`
+
+	ReloadInterval time.Duration `config:"reload_interval" env:"SERVER_TLS_RELOAD_INTERVAL"`
+
+	// RedirectPort - порт HTTP, запросы на который перенаправляются на HTTPS; пусто - не слушать HTTP
+	RedirectPort string `config:"redirect_port" env:"SERVER_TLS_REDIRECT_PORT"`
+
+	// ClientAuth - проверка клиентских сертификатов (mTLS): none, optional (проверяется, если предъявлен) или require;
+	// ClientCAFile - сертификаты удостоверяющих центров клиентов в PEM
+	ClientAuth   string `config:"client_auth" env:"SERVER_TLS_CLIENT_AUTH"`
+	ClientCAFile string `config:"client_ca_file" env:"SERVER_TLS_CLIENT_CA_FILE"`
+}
+
+// Enabled сообщает, включен ли HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
 }
 
-// DBConfig представляет конфигурацию базы данных
+// DBConfig представляет конфигурацию базы данных.
+// Driver: "postgres", "sqlite" (файл Path или ":memory:") или "memory" - хранилище в памяти процесса
+// без SQL для тестов и локального запуска.
 type DBConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
+	Driver   string `config:"driver" env:"DB_DRIVER"`
+	Host     string `config:"host" env:"DB_HOST"`
+	Port     string `config:"port" env:"DB_PORT"`
+	User     string `config:"user" env:"DB_USER"`
+	Password string `config:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `config:"name" env:"DB_NAME"`
+	Path     string `config:"path" env:"DB_PATH"`
+
+	// URL - строка подключения PostgreSQL (postgres://...); если задана, Host, Port, User, Password и Name
+	// не используются, а SSL-параметры и StatementTimeout добавляются, только если их нет в URL
+	URL string `config:"url" env:"DATABASE_URL" secret:"url"`
+
+	// SSL-подключение к PostgreSQL: режим (disable, allow, prefer, require, verify-ca, verify-full),
+	// корневой сертификат сервера и клиентские сертификат и ключ
+	SSLMode     string `config:"ssl_mode" env:"DB_SSLMODE"`
+	SSLRootCert string `config:"ssl_root_cert" env:"DB_SSLROOTCERT"`
+	SSLCert     string `config:"ssl_cert" env:"DB_SSLCERT"`
+	SSLKey      string `config:"ssl_key" env:"DB_SSLKEY"`
+
+	// Пул соединений; нулевые значения означают отсутствие ограничения
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `config:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	// StatementTimeout ограничивает время выполнения запроса в PostgreSQL (0 - без ограничения)
+	StatementTimeout time.Duration `config:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+
+	// LogLevel - уровень журнала запросов GORM (silent, error, warn, info);
+	// запросы дольше SlowQueryThreshold записываются в журнал на уровне warn
+	LogLevel           string        `config:"log_level" env:"DB_LOG_LEVEL"`
+	SlowQueryThreshold time.Duration `config:"slow_query_threshold" env:"DB_SLOW_QUERY_THRESHOLD"`
+
+	// ConnectAttempts - число попыток подключения при запуске; пауза между попытками
+	// начинается с ConnectBackoff и удваивается
+	ConnectAttempts int           `config:"connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	ConnectBackoff  time.Duration `config:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+
+	// ReplicaURLs - строки подключения к репликам PostgreSQL для чтения пользователей;
+	// доступность реплик проверяется каждые ReplicaCheckInterval
+	ReplicaURLs          []string      `config:"replica_urls" env:"DB_REPLICA_URLS" secret:"url"`
+	ReplicaCheckInterval time.Duration `config:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+// CacheConfig представляет конфигурацию кеша пользователей.
+// Backend: "memory" - LRU в памяти процесса, "redis" - общий Redis; пустое значение отключает кеш.
+// ReplicaLag - время после изменения пользователя, в течение которого прочитанное не кешируется
+// (применяется, только если заданы реплики).
+type CacheConfig struct {
+	Backend       string        `config:"backend" env:"CACHE_BACKEND"`
+	Size          int           `config:"size" env:"CACHE_SIZE"`
+	TTL           time.Duration `config:"ttl" env:"CACHE_TTL"`
+	NegativeTTL   time.Duration `config:"negative_ttl" env:"CACHE_NEGATIVE_TTL"`
+	ReplicaLag    time.Duration `config:"replica_lag" env:"CACHE_REPLICA_LAG"`
+	RedisAddr     string        `config:"redis_addr" env:"CACHE_REDIS_ADDR"`
+	RedisPassword string        `config:"redis_password" env:"CACHE_REDIS_PASSWORD" secret:"true"`
+	RedisDB       int           `config:"redis_db" env:"CACHE_REDIS_DB"`
+}
+
+// SessionConfig представляет конфигурацию сессий веб-интерфейса. Сессии хранятся на сервере,
+// в хранилище DB.Driver, а cookie содержит только подписанный и зашифрованный токен.
+//
+// Keys - ключи сессий, новый первым: из каждого ключа выводятся ключи подписи и шифрования cookie.
+// Новые cookie подписываются первым ключом, а остальные принимаются, пока не истекут выданные ими сессии,
+// поэтому для смены ключа достаточно добавить новый ключ в начало списка, а старый удалить через MaxAge.
+// Если ключей нет, при запуске (кроме production) создается случайный ключ, и сессии не переживают перезапуск.
+type SessionConfig struct {
+	Keys []string `config:"keys" env:"SESSION_KEYS" secret:"true"`
+
+	// Сессия истекает после IdleTimeout без запросов (0 - не истекает по бездействию) или через MaxAge
+	// после создания; истекшие сессии удаляются из хранилища каждые CleanupInterval
+	MaxAge          time.Duration `config:"max_age" env:"SESSION_MAX_AGE"`
+	IdleTimeout     time.Duration `config:"idle_timeout" env:"SESSION_IDLE_TIMEOUT"`
+	CleanupInterval time.Duration `config:"cleanup_interval" env:"SESSION_CLEANUP_INTERVAL"`
+
+	// Параметры cookie сессии; SameSite: lax, strict или none (только вместе с CookieSecure)
+	CookieDomain   string `config:"cookie_domain" env:"SESSION_COOKIE_DOMAIN"`
+	CookiePath     string `config:"cookie_path" env:"SESSION_COOKIE_PATH"`
+	CookieSecure   bool   `config:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieHTTPOnly bool   `config:"cookie_http_only" env:"SESSION_COOKIE_HTTP_ONLY"`
+	CookieSameSite string `config:"cookie_same_site" env:"SESSION_COOKIE_SAME_SITE"`
+}
+
+// AdminConfig задает администратора веб-интерфейса, который создается при запуске, если пользователя
+// с таким email еще нет; существующий пользователь получает роль администратора. Пустой Email - не создавать.
+type AdminConfig struct {
+	Email    string `config:"email" env:"ADMIN_EMAIL"`
+	Password string `config:"password" env:"ADMIN_PASSWORD" secret:"true"`
+}
+
+// APIKeysConfig представляет настройки аутентификации API по ключам (заголовок "Authorization: ApiKey ...").
+// Если Required выключен, запросы без ключа обслуживаются без аутентификации, а ключ, если передан, проверяется.
+type APIKeysConfig struct {
+	Required bool `config:"required" env:"API_KEYS_REQUIRED"`
 }
 
-// LoadConfig загружает конфигурацию из переменных окружения
-func LoadConfig() *Config {
+// OIDCConfig представляет вход в веб-интерфейс через внешних поставщиков OpenID Connect.
+// Providers - настройки поставщиков вида "name=corp;issuer=https://...;client_id=...;client_secret=..."
+// (см. oidc.ParseProvider); содержат секреты клиентов, поэтому каждый элемент можно задать через file:.
+// RedirectBaseURL - внешний адрес приложения для адреса возврата (<base>/web/login/oidc/<name>/callback),
+// который регистрируется у поставщика; пустой - адрес берется из запроса.
+type OIDCConfig struct {
+	Providers       []string `config:"providers" env:"OIDC_PROVIDERS" secret:"client_secret"`
+	RedirectBaseURL string   `config:"redirect_base_url" env:"OIDC_REDIRECT_BASE_URL"`
+}
+
+// OAuthConfig представляет сервер авторизации OAuth2/OpenID Connect для внутренних приложений.
+// Issuer - внешний адрес сервиса (https://host[:port] без пути), по которому приложения находят документ
+// обнаружения; пустой выключает сервер. SigningKey - закрытый ключ подписи ID-токенов в PEM (RSA не короче
+// 2048 бит или EC P-256); пустой - при запуске создается временный ключ, и после перезапуска ID-токены
+// перестают проверяться. Истекшие коды и токены удаляются из хранилища каждые CleanupInterval.
+type OAuthConfig struct {
+	Issuer          string        `config:"issuer" env:"OAUTH_ISSUER"`
+	SigningKey      string        `config:"signing_key" env:"OAUTH_SIGNING_KEY" secret:"true"`
+	AccessTokenTTL  time.Duration `config:"access_token_ttl" env:"OAUTH_ACCESS_TOKEN_TTL"`
+	CodeTTL         time.Duration `config:"code_ttl" env:"OAUTH_CODE_TTL"`
+	CleanupInterval time.Duration `config:"cleanup_interval" env:"OAUTH_CLEANUP_INTERVAL"`
+}
+
+// Enabled сообщает, включен ли сервер авторизации
+func (c OAuthConfig) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// WebhooksConfig представляет доставку вебхуков. AllowedNetworks - подсети (CIDR или отдельные IP-адреса),
+// в которые разрешено отправлять вебхуки, хотя их адреса запрещены (частные, CGNAT, loopback и т. п.,
+// см. webhook.AllowedIP): например, внутренняя сеть, где работают получатели. Пустой список ничего не разрешает.
+type WebhooksConfig struct {
+	AllowedNetworks []string `config:"allowed_networks" env:"WEBHOOKS_ALLOWED_NETWORKS"`
+}
+
+// CORSConfig представляет политику CORS для API (/api/...). AllowedOrigins - разрешенные источники
+// вида "https://app.example.com" или "*" (любой источник, только без AllowCredentials); пустой список
+// отключает CORS. Ответ на предварительный запрос кешируется браузером на MaxAge.
+type CORSConfig struct {
+	AllowedOrigins   []string      `config:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `config:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `config:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `config:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `config:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `config:"max_age" env:"CORS_MAX_AGE"`
+}
+
+// SecurityConfig представляет заголовки безопасности ответов. Content-Security-Policy задается
+// отдельно для веб-интерфейса (WebCSP) и API (APICSP); пустое значение - не отправлять заголовок.
+// HSTS отправляется, если HSTSMaxAge больше нуля.
+type SecurityConfig struct {
+	WebCSP                string        `config:"web_csp" env:"SECURITY_WEB_CSP"`
+	APICSP                string        `config:"api_csp" env:"SECURITY_API_CSP"`
+	FrameOptions          string        `config:"frame_options" env:"SECURITY_FRAME_OPTIONS"`
+	ReferrerPolicy        string        `config:"referrer_policy" env:"SECURITY_REFERRER_POLICY"`
+	HSTSMaxAge            time.Duration `config:"hsts_max_age" env:"SECURITY_HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool          `config:"hsts_include_subdomains" env:"SECURITY_HSTS_INCLUDE_SUBDOMAINS"`
+}
+
+// RateLimitConfig представляет ограничение частоты запросов. Backend: "memory" - корзины в памяти процесса,
+// "redis" - общий Redis для нескольких экземпляров; пустое значение отключает ограничение.
+// Policies - правила вида "<группа>:<ключ>:<запросы>/<период>[:<всплеск>]" (см. ratelimit.ParsePolicy),
+// где группа - api, users.write, web или login, а ключ - ip, user или api_key.
+type RateLimitConfig struct {
+	Backend       string   `config:"backend" env:"RATE_LIMIT_BACKEND"`
+	Policies      []string `config:"policies" env:"RATE_LIMIT_POLICIES"`
+	RedisAddr     string   `config:"redis_addr" env:"RATE_LIMIT_REDIS_ADDR"`
+	RedisPassword string   `config:"redis_password" env:"RATE_LIMIT_REDIS_PASSWORD" secret:"true"`
+	RedisDB       int      `config:"redis_db" env:"RATE_LIMIT_REDIS_DB"`
+}
+
+// SecretsConfig представляет настройки зашифрованного файла секретов.
+// File - файл, созданный командой "secrets encrypt"; MasterKey - ключ для его расшифровки.
+type SecretsConfig struct {
+	File      string `config:"file" env:"SECRETS_FILE"`
+	MasterKey string `config:"master_key" env:"SECRETS_MASTER_KEY" secret:"true"`
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
 	return &Config{
+		Env: EnvDevelopment,
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port: "8080",
+			TLS: TLSConfig{
+				MinVersion:     "1.2",
+				ReloadInterval: time.Minute,
+				ClientAuth:     "none",
+			},
 		},
 		DB: DBConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			Name:     getEnv("DB_NAME", "user_api"),
-		},
-	}
-}
+			Driver:   "postgres",
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "user_api",
+			Path:     "user_api.db",
 
-// Вспомогательные функции для получения переменных окружения
-func getEnv(key, defaultValue string) string {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		return defaultValue
-	}
-	return value
-}
+			SSLMode: "prefer",
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	valueStr := getEnv(key, strconv.FormatBool(defaultValue))
-	value, err := strconv.ParseBool(valueStr)
-	if err != nil {
-		return defaultValue
-	}
-	return value
-}
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			StatementTimeout: 30 * time.Second,
+
+			LogLevel:           "warn",
+			SlowQueryThreshold: 200 * time.Millisecond,
+
+			ConnectAttempts: 10,
+			ConnectBackoff:  time.Second,
 
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, strconv.Itoa(defaultValue))
-	value, err := strconv.Atoi(valueStr)
-	if err != nil {
-		return defaultValue
+			ReplicaCheckInterval: 5 * time.Second,
+		},
+		Cache: CacheConfig{
+			Backend:     "memory",
+			Size:        10000,
+			TTL:         time.Minute,
+			NegativeTTL: 10 * time.Second,
+			ReplicaLag:  5 * time.Second,
+			RedisAddr:   "localhost:6379",
+		},
+		Session: SessionConfig{
+			MaxAge:          24 * time.Hour,
+			IdleTimeout:     30 * time.Minute,
+			CleanupInterval: 10 * time.Minute,
+			CookiePath:      "/",
+			CookieHTTPOnly:  true,
+			CookieSameSite:  "lax",
+		},
+		OAuth: OAuthConfig{
+			AccessTokenTTL:  time.Hour,
+			CodeTTL:         time.Minute,
+			CleanupInterval: 10 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-Request-ID"},
+			ExposedHeaders: []string{"X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			// Шаблоны веб-интерфейса подключают Bootstrap с CDN и содержат встроенные стили и скрипты
+			WebCSP: "default-src 'self'; script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; " +
+				"style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net; img-src 'self' data:; " +
+				"form-action 'self'; frame-ancestors 'none'; base-uri 'self'",
+			APICSP:         "default-src 'none'; frame-ancestors 'none'",
+			FrameOptions:   "DENY",
+			ReferrerPolicy: "strict-origin-when-cross-origin",
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+			Policies: []string{
+				"api:ip:300/1m:60",
+				"users.write:ip:30/1m:10",
+				"web:user:300/1m:60",
+				"login:ip:10/1m:5",
+			},
+			RedisAddr: "localhost:6379",
+		},
 	}
-	return value
-}
\ No newline at end of file
+}
@@ -0,0 +1,329 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv - переменная окружения с путем к файлу конфигурации (флаг -config имеет приоритет)
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Load собирает конфигурацию из значений по умолчанию, файла конфигурации (YAML или TOML),
+// зашифрованного файла секретов, переменных окружения и флагов командной строки args - каждый следующий
+// источник переопределяет предыдущий, - подставляет содержимое файлов в секреты вида "file:<путь>"
+// и проверяет результат. Все найденные ошибки возвращаются вместе.
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv, os.Stderr)
+}
+
+// load - Load с подменяемыми окружением и выводом справки по флагам
+func load(args []string, lookupEnv func(string) (string, bool), output io.Writer) (*Config, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	flags := flag.NewFlagSet("api", flag.ContinueOnError)
+	flags.SetOutput(output)
+	configFile := flags.String("config", "", "path to a YAML or TOML config file (overrides "+ConfigFileEnv+")")
+	for _, f := range fields {
+		usage := "overrides config key " + f.key
+		if f.env != "" {
+			usage += " and " + f.env
+		}
+		defaultValue := f.String()
+		if f.secret != "" {
+			defaultValue = ""
+			usage += " (only " + FileRefPrefix + "<path>: flags are visible in the process list)"
+		}
+		flags.String(f.key, defaultValue, usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	byKey := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+
+	var errs []error
+
+	// Файл конфигурации
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(ConfigFileEnv)
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, apply(path, values, byKey, nil)...)
+	}
+
+	// Переменные окружения и флаги; запоминаем заданные ими ключи, чтобы файл секретов их не перезаписал
+	overridden := make(map[string]bool)
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		value, ok := lookupEnv(f.env)
+		// Пустая переменная сбрасывает строку, но для чисел, флагов и длительностей означает "не задана"
+		if !ok || (value == "" && f.value.Kind() != reflect.String) {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+		overridden[f.key] = true
+	}
+
+	// Флаги командной строки: применяются только явно указанные. Флаги видны в списке процессов,
+	// поэтому секреты принимаются в них только ссылками на файлы.
+	flags.Visit(func(fl *flag.Flag) {
+		if f, ok := byKey[fl.Name]; ok {
+			if f.secret != "" && !fileRefsOnly(fl.Value.String()) {
+				errs = append(errs, fmt.Errorf("-%s: secrets on the command line must be file references (%s<path>); use %s or the secrets file", fl.Name, FileRefPrefix, f.env))
+				return
+			}
+			if err := f.set(fl.Value.String()); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", fl.Name, err))
+			}
+			overridden[f.key] = true
+		}
+	})
+
+	// Файл секретов: расшифровывается мастер-ключом и заполняет только секреты, не заданные окружением и флагами
+	if err := resolveFileRef(byKey["secrets.master_key"]); err != nil {
+		errs = append(errs, err)
+	} else if cfg.Secrets.File != "" {
+		values, err := readSecretsFile(cfg.Secrets.File, cfg.Secrets.MasterKey)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, apply(cfg.Secrets.File, values, byKey, overridden)...)
+	}
+
+	// Ссылки на файлы в секретах
+	for _, f := range fields {
+		if err := resolveFileRef(f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Ошибки значений и проверки сообщаются вместе, чтобы все можно было исправить за один раз
+	if err := invalid(append(errs, cfg.validate()...)); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// fileRefsOnly сообщает, состоит ли значение флага (для списков - каждый элемент через запятую)
+// только из ссылок на файлы
+func fileRefsOnly(value string) bool {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" && !strings.HasPrefix(item, FileRefPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply присваивает полям значения из файла source. Если задан overridden, файл считается файлом секретов:
+// он может содержать только секреты, а ключи из overridden пропускаются.
+func apply(source string, values map[string]any, byKey map[string]*field, overridden map[string]bool) []error {
+	var errs []error
+	for _, key := range sortedKeys(values) {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", source, key))
+			continue
+		}
+		if overridden != nil {
+			if f.secret == "" || f.key == "secrets.master_key" {
+				errs = append(errs, fmt.Errorf("%s: %s is not a secret and must be set in the config file", source, key))
+				continue
+			}
+			if overridden[key] {
+				continue
+			}
+		}
+		if err := f.set(values[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", source, key, err))
+		}
+	}
+	return errs
+}
+
+// readFile читает файл конфигурации и возвращает значения по ключам вида "db.host"
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	return decode(path, filepath.Ext(path), data)
+}
+
+// decode разбирает содержимое файла конфигурации в формате ext (.yaml, .yml или .toml)
+func decode(path, ext string, data []byte) (map[string]any, error) {
+	var err error
+	var tree map[string]any
+	switch ext = strings.ToLower(ext); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]any)
+	flatten("", tree, values)
+	return values, nil
+}
+
+// flatten раскладывает вложенные секции в значения с ключами через точку
+func flatten(prefix string, tree map[string]any, values map[string]any) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if section, ok := value.(map[string]any); ok {
+			flatten(key, section, values)
+			continue
+		}
+		values[key] = value
+	}
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// field - поле конфигурации, описанное тегами config, env и secret
+type field struct {
+	key    string
+	env    string
+	secret string
+	value  reflect.Value
+}
+
+// fields возвращает все поля конфигурации в порядке объявления
+func (c *Config) fields() []*field {
+	var fields []*field
+	collectFields("", reflect.ValueOf(c).Elem(), &fields)
+	return fields
+}
+
+func collectFields(prefix string, value reflect.Value, fields *[]*field) {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		key := structField.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if structField.Type.Kind() == reflect.Struct {
+			collectFields(key, value.Field(i), fields)
+			continue
+		}
+		*fields = append(*fields, &field{
+			key:    key,
+			env:    structField.Tag.Get("env"),
+			secret: structField.Tag.Get("secret"),
+			value:  value.Field(i),
+		})
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set присваивает полю значение из файла, окружения или флага
+func (f *field) set(raw any) error {
+	// Пустое значение в YAML (key:) читается как nil
+	if raw == nil {
+		raw = ""
+	}
+
+	switch {
+	case f.value.Type() == durationType:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("invalid duration %v: use a string with a unit, e.g. \"30s\"", raw)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(fmt.Sprint(raw))
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(fmt.Sprint(raw))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", fmt.Sprint(raw))
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(fmt.Sprint(raw))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", fmt.Sprint(raw))
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+		var items []string
+		switch raw := raw.(type) {
+		case []any:
+			for _, item := range raw {
+				items = append(items, fmt.Sprint(item))
+			}
+		default:
+			// В окружении и флагах список задается через запятую
+			for _, item := range strings.Split(fmt.Sprint(raw), ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", f.value.Type())
+	}
+	return nil
+}
+
+// String возвращает значение поля в том виде, в котором его принимают окружение и флаги
+func (f *field) String() string {
+	switch v := f.value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretMask заменяет значения секретов при выводе конфигурации
+const secretMask = "******"
+
+// Print выводит конфигурацию в формате YAML, пригодном для файла конфигурации; секреты маскируются
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	sections := map[string]*yaml.Node{}
+
+	for _, f := range c.fields() {
+		// Вложенные секции (например, server.tls) выводятся внутри родительской
+		parent, path := root, strings.Split(f.key, ".")
+		for i := range path[:len(path)-1] {
+			section := strings.Join(path[:i+1], ".")
+			if sections[section] == nil {
+				sections[section] = &yaml.Node{Kind: yaml.MappingNode}
+				parent.Content = append(parent.Content, scalar(path[i]), sections[section])
+			}
+			parent = sections[section]
+		}
+		parent.Content = append(parent.Content, scalar(path[len(path)-1]), f.node())
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// node возвращает значение поля для вывода с учетом тега secret
+func (f *field) node() *yaml.Node {
+	if values, ok := f.value.Interface().([]string); ok {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, value := range values {
+			list.Content = append(list.Content, scalar(mask(f.secret, value)))
+		}
+		return list
+	}
+	return scalar(mask(f.secret, f.String()))
+}
+
+// mask скрывает секрет: значение целиком, только пароль в строке подключения или только секрет клиента
+// в настройках поставщика OIDC
+func mask(secret, value string) string {
+	switch {
+	case value == "" || secret == "":
+		return value
+	case secret == "url":
+		u, err := url.Parse(value)
+		if err != nil {
+			return secretMask
+		}
+		return u.Redacted()
+	case secret == "client_secret":
+		settings := strings.Split(value, ";")
+		for i, setting := range settings {
+			if key, _, ok := strings.Cut(setting, "="); ok && strings.TrimSpace(key) == "client_secret" {
+				settings[i] = key + "=" + secretMask
+			}
+		}
+		return strings.Join(settings, ";")
+	default:
+		return secretMask
+	}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// FileRefPrefix - префикс секрета, значение которого читается из файла
+const FileRefPrefix = "file:"
+
+// secretsHeader - заголовок зашифрованного файла секретов и версия формата
+const secretsHeader = "user-api-secrets.v1"
+
+// Параметры scrypt для получения ключа шифрования из мастер-ключа
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// ErrWrongMasterKey возвращается, если файл секретов не удалось расшифровать мастер-ключом
+var ErrWrongMasterKey = errors.New("wrong master key or corrupted secrets file")
+
+// EncryptSecrets шифрует содержимое файла секретов (YAML с ключами конфигурации, например db.password)
+// мастер-ключом: ключ шифрования получается из мастер-ключа через scrypt со случайной солью,
+// а содержимое шифруется AES-256-GCM. Результат - одна строка "user-api-secrets.v1.<соль>.<nonce>.<шифротекст>".
+func EncryptSecrets(plaintext []byte, masterKey string) ([]byte, error) {
+	if masterKey == "" {
+		return nil, errors.New("master key is empty")
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newSecretsCipher(masterKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, []byte(secretsHeader))
+
+	encode := base64.RawURLEncoding.EncodeToString
+	return []byte(strings.Join([]string{secretsHeader, encode(salt), encode(nonce), encode(ciphertext)}, ".") + "\n"), nil
+}
+
+// DecryptSecrets расшифровывает результат EncryptSecrets
+func DecryptSecrets(data []byte, masterKey string) ([]byte, error) {
+	if masterKey == "" {
+		return nil, errors.New("master key is empty")
+	}
+
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(string(data)), secretsHeader+".")
+	parts := strings.Split(encoded, ".")
+	if !ok || len(parts) != 3 {
+		return nil, errors.New("not a secrets file: unexpected format")
+	}
+
+	var decoded [3][]byte
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = base64.RawURLEncoding.DecodeString(part); err != nil {
+			return nil, errors.New("not a secrets file: invalid encoding")
+		}
+	}
+	salt, nonce, ciphertext := decoded[0], decoded[1], decoded[2]
+
+	aead, err := newSecretsCipher(masterKey, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("not a secrets file: invalid nonce")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(secretsHeader))
+	if err != nil {
+		return nil, ErrWrongMasterKey
+	}
+	return plaintext, nil
+}
+
+// newSecretsCipher создает AES-256-GCM с ключом, полученным из мастер-ключа и соли
+func newSecretsCipher(masterKey string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(masterKey), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readSecretsFile расшифровывает файл секретов и возвращает значения по ключам вида "db.password"
+func readSecretsFile(path, masterKey string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+	if masterKey == "" {
+		return nil, fmt.Errorf("secrets file %s: secrets.master_key (SECRETS_MASTER_KEY) is not set", path)
+	}
+	plaintext, err := DecryptSecrets(data, masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file %s: %w", path, err)
+	}
+	return decode(path, ".yaml", plaintext)
+}
+
+// ResolveSecret возвращает содержимое файла без завершающего перевода строки, если value имеет вид
+// "file:<путь>", иначе само value
+func ResolveSecret(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, FileRefPrefix)
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveFileRef подставляет в секрет содержимое файлов, на которые он ссылается (см. ResolveSecret)
+func resolveFileRef(f *field) error {
+	if f.secret == "" {
+		return nil
+	}
+
+	switch value := f.value.Interface().(type) {
+	case string:
+		resolved, err := ResolveSecret(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+		f.value.SetString(resolved)
+	case []string:
+		if len(value) == 0 {
+			return nil
+		}
+		resolved := make([]string, len(value))
+		for i := range value {
+			var err error
+			if resolved[i], err = ResolveSecret(value[i]); err != nil {
+				return fmt.Errorf("%s: %w", f.key, err)
+			}
+		}
+		f.value.Set(reflect.ValueOf(resolved))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// strongSessionKey - ключ сессий, проходящий проверки production
+const strongSessionKey = "9c1e5b7a2f4d8e0c3b6a9f1e4d7c0b3a"
+
+func TestEncryptSecrets_RoundTrip(t *testing.T) {
+	plaintext := []byte("db:\n  password: s3cret\n")
+
+	encrypted, err := EncryptSecrets(plaintext, "master-key")
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "s3cret")
+
+	decrypted, err := DecryptSecrets(encrypted, "master-key")
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = DecryptSecrets(encrypted, "other-key")
+	assert.ErrorIs(t, err, ErrWrongMasterKey)
+
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-3] ^= 1
+	_, err = DecryptSecrets(tampered, "master-key")
+	assert.Error(t, err)
+
+	_, err = DecryptSecrets([]byte("db:\n  password: plain\n"), "master-key")
+	assert.ErrorContains(t, err, "not a secrets file")
+}
+
+func TestLoad_SecretsFile(t *testing.T) {
+	encrypted, err := EncryptSecrets([]byte(`
+db:
+  password: from-secrets-file
+cache:
+  redis_password: redis-from-secrets-file
+`), "master-key")
+	require.NoError(t, err)
+	secretsPath := writeFile(t, "secrets.enc", string(encrypted))
+	masterKeyPath := writeFile(t, "master_key", "master-key\n")
+
+	cfg, err := load(nil, env(map[string]string{
+		"SECRETS_FILE":         secretsPath,
+		"SECRETS_MASTER_KEY":   "file:" + masterKeyPath,
+		"CACHE_REDIS_PASSWORD": "redis-from-env",
+	}), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "from-secrets-file", cfg.DB.Password)
+	assert.Equal(t, "redis-from-env", cfg.Cache.RedisPassword, "env overrides the secrets file")
+
+	_, err = load(nil, env(map[string]string{"SECRETS_FILE": secretsPath, "SECRETS_MASTER_KEY": "wrong"}), io.Discard)
+	assert.ErrorIs(t, err, ErrWrongMasterKey)
+}
+
+func TestLoad_SecretsFileAcceptsOnlySecrets(t *testing.T) {
+	encrypted, err := EncryptSecrets([]byte("db:\n  host: elsewhere\n"), "master-key")
+	require.NoError(t, err)
+	path := writeFile(t, "secrets.enc", string(encrypted))
+
+	_, err = load([]string{"-secrets.file", path}, env(map[string]string{"SECRETS_MASTER_KEY": "master-key"}), io.Discard)
+	assert.ErrorContains(t, err, "db.host is not a secret")
+}
+
+func TestLoad_FileReferences(t *testing.T) {
+	passwordPath := writeFile(t, "db_password", "from-docker-secret\n")
+
+	cfg, err := load(nil, env(map[string]string{"DB_PASSWORD": "file:" + passwordPath}), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "from-docker-secret", cfg.DB.Password)
+
+	// Ссылки разрешаются только в секретах
+	cfg, err = load(nil, env(map[string]string{"DB_PATH": "file:users.db"}), io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, "file:users.db", cfg.DB.Path)
+
+	_, err = load(nil, env(map[string]string{"DB_PASSWORD": "file:" + os.DevNull + "/missing"}), io.Discard)
+	assert.ErrorContains(t, err, "db.password: read secret")
+}
+
+func TestValidate_ProductionSecrets(t *testing.T) {
+	production := func() *Config {
+		cfg := Default()
+		cfg.Env = EnvProduction
+		cfg.DB.Password = "k7#pQ2!vX9zL"
+		cfg.Session.Keys = []string{strongSessionKey}
+		cfg.Session.CookieSecure = true
+		cfg.APIKeys.Required = true
+		return cfg
+	}
+	require.NoError(t, production().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{"Default DB password", func(cfg *Config) { cfg.DB.Password = "postgres" }, "db.password: is a default"},
+		{"Short DB password", func(cfg *Config) { cfg.DB.Password = "abc123" }, "db.password: must be at least 12"},
+		{"Weak password in URL", func(cfg *Config) { cfg.DB.URL = "postgres://app:secret@db/user_api" }, "db.url: is a default"},
+		{"Missing session keys", func(cfg *Config) { cfg.Session.Keys = nil }, "session.keys: must not be empty"},
+		{"Weak previous session key", func(cfg *Config) {
+			cfg.Session.Keys = append(cfg.Session.Keys, strings.Repeat("a", 40))
+		}, "session.keys[1]: must not repeat"},
+		{"Insecure session cookie", func(cfg *Config) { cfg.Session.CookieSecure = false }, "session.cookie_secure"},
+		{"API without keys", func(cfg *Config) { cfg.APIKeys.Required = false }, "api_keys.required"},
+		{"OAuth without signing key", func(cfg *Config) { cfg.OAuth.Issuer = "https://users.example.com" }, "oauth.signing_key"},
+		{"OAuth over http", func(cfg *Config) { cfg.OAuth.Issuer = "http://users.example.com" }, "oauth.issuer: must be an https URL"},
+		{"Weak Redis password", func(cfg *Config) { cfg.Cache.Backend, cfg.Cache.RedisPassword = "redis", "changeme" }, "cache.redis_password"},
+		{"Short master key", func(cfg *Config) { cfg.Secrets.File, cfg.Secrets.MasterKey = "secrets.enc", "short" }, "secrets.master_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := production()
+			tt.modify(cfg)
+			assert.ErrorContains(t, cfg.Validate(), tt.want)
+		})
+	}
+
+	// В development слабые секреты допустимы
+	cfg := production()
+	cfg.Env, cfg.DB.Password, cfg.Session.Keys, cfg.Session.CookieSecure = EnvDevelopment, "postgres", nil, false
+	assert.NoError(t, cfg.Validate())
+}
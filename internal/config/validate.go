@@ -0,0 +1,329 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/ratelimit"
+	"github.com/Est1ege/go-user-api/pkg/jose"
+)
+
+// Допустимые значения перечислимых параметров
+var (
+	envs          = []string{EnvDevelopment, EnvProduction}
+	dbDrivers     = []string{"postgres", "sqlite", "memory"}
+	sslModes      = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	dbLogLevels   = []string{"silent", "error", "warn", "info"}
+	cacheBackends = []string{"", "memory", "redis"}
+	sameSites     = []string{"lax", "strict", "none"}
+	frameOptions  = []string{"", "DENY", "SAMEORIGIN"}
+	rateLimiters  = []string{"", "memory", "redis"}
+	tlsVersions   = []string{"1.2", "1.3"}
+	clientAuths   = []string{"none", "optional", "require"}
+
+	referrerPolicies = []string{"", "no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+		"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url"}
+)
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки вместе
+func (c *Config) Validate() error {
+	return invalid(c.validate())
+}
+
+// invalid объединяет ошибки конфигурации в одну; nil, если ошибок нет
+func invalid(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+}
+
+// validate возвращает ошибки конфигурации
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(oneOf(c.Env, envs), "env", "must be one of %v, got %q", envs, c.Env)
+	check(validPort(c.Server.Port), "server.port", "invalid port %q", c.Server.Port)
+
+	tlsCfg := c.Server.TLS
+	check((tlsCfg.CertFile == "") == (tlsCfg.KeyFile == ""), "server.tls.cert_file", "cert_file and key_file must be set together")
+	check(oneOf(tlsCfg.MinVersion, tlsVersions), "server.tls.min_version",
+		"must be one of %v, got %q", tlsVersions, tlsCfg.MinVersion)
+	for _, name := range tlsCfg.CipherSuites {
+		check(validCipherSuite(name), "server.tls.cipher_suites", "unknown or insecure cipher suite %q", name)
+	}
+	check(tlsCfg.ReloadInterval > 0, "server.tls.reload_interval", "must be positive")
+	check(oneOf(tlsCfg.ClientAuth, clientAuths), "server.tls.client_auth",
+		"must be one of %v, got %q", clientAuths, tlsCfg.ClientAuth)
+	if tlsCfg.RedirectPort != "" {
+		check(validPort(tlsCfg.RedirectPort), "server.tls.redirect_port", "invalid port %q", tlsCfg.RedirectPort)
+		check(tlsCfg.RedirectPort != c.Server.Port, "server.tls.redirect_port", "must differ from server.port")
+	}
+	if !tlsCfg.Enabled() {
+		check(tlsCfg.RedirectPort == "", "server.tls.redirect_port", "requires cert_file")
+		check(tlsCfg.ClientAuth == "none", "server.tls.client_auth", "requires cert_file")
+	}
+	check(tlsCfg.ClientAuth == "none" || tlsCfg.ClientCAFile != "", "server.tls.client_ca_file",
+		"must not be empty when client_auth is %q", tlsCfg.ClientAuth)
+
+	db := c.DB
+	check(oneOf(db.Driver, dbDrivers), "db.driver", "must be one of %v, got %q", dbDrivers, db.Driver)
+	switch db.Driver {
+	case "postgres":
+		if db.URL == "" {
+			check(db.Host != "", "db.host", "must not be empty")
+			check(validPort(db.Port), "db.port", "invalid port %q", db.Port)
+			check(db.User != "", "db.user", "must not be empty")
+			check(db.Name != "", "db.name", "must not be empty")
+			check(db.Password != "" || c.Env != EnvProduction, "db.password", "must not be empty in production")
+		}
+		check(oneOf(db.SSLMode, sslModes), "db.ssl_mode", "must be one of %v, got %q", sslModes, db.SSLMode)
+		check((db.SSLCert == "") == (db.SSLKey == ""), "db.ssl_cert", "ssl_cert and ssl_key must be set together")
+		check(db.StatementTimeout >= 0, "db.statement_timeout", "must not be negative")
+		check(db.ReplicaCheckInterval > 0 || len(db.ReplicaURLs) == 0, "db.replica_check_interval", "must be positive")
+	case "sqlite":
+		check(db.Path != "", "db.path", "must not be empty")
+	}
+	if db.Driver != "postgres" {
+		check(len(db.ReplicaURLs) == 0, "db.replica_urls", "read replicas are supported only for postgres")
+	}
+	check(db.MaxOpenConns >= 0, "db.max_open_conns", "must not be negative")
+	check(db.MaxIdleConns >= 0, "db.max_idle_conns", "must not be negative")
+	check(db.MaxOpenConns == 0 || db.MaxIdleConns <= db.MaxOpenConns, "db.max_idle_conns",
+		"must not exceed max_open_conns (%d)", db.MaxOpenConns)
+	check(db.ConnMaxLifetime >= 0, "db.conn_max_lifetime", "must not be negative")
+	check(db.ConnMaxIdleTime >= 0, "db.conn_max_idle_time", "must not be negative")
+	check(oneOf(db.LogLevel, dbLogLevels), "db.log_level", "must be one of %v, got %q", dbLogLevels, db.LogLevel)
+	check(db.ConnectAttempts >= 1, "db.connect_attempts", "must be at least 1")
+	check(db.ConnectBackoff >= 0, "db.connect_backoff", "must not be negative")
+
+	cache := c.Cache
+	check(oneOf(cache.Backend, cacheBackends), "cache.backend", "must be one of %v, got %q", cacheBackends, cache.Backend)
+	if cache.Backend != "" {
+		check(cache.TTL > 0, "cache.ttl", "must be positive")
+		check(cache.NegativeTTL >= 0, "cache.negative_ttl", "must not be negative")
+		check(cache.ReplicaLag >= 0, "cache.replica_lag", "must not be negative")
+	}
+	switch cache.Backend {
+	case "memory":
+		check(cache.Size > 0, "cache.size", "must be positive")
+	case "redis":
+		check(cache.RedisAddr != "", "cache.redis_addr", "must not be empty")
+		check(cache.RedisDB >= 0, "cache.redis_db", "must not be negative")
+	}
+
+	session := c.Session
+	check(session.MaxAge > 0, "session.max_age", "must be positive")
+	check(session.IdleTimeout >= 0, "session.idle_timeout", "must not be negative")
+	check(session.CleanupInterval > 0, "session.cleanup_interval", "must be positive")
+	check(session.CookiePath != "", "session.cookie_path", "must not be empty")
+	check(oneOf(session.CookieSameSite, sameSites), "session.cookie_same_site",
+		"must be one of %v, got %q", sameSites, session.CookieSameSite)
+	check(session.CookieSameSite != "none" || session.CookieSecure, "session.cookie_same_site",
+		"none requires cookie_secure")
+
+	cors := c.CORS
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			check(!cors.AllowCredentials, "cors.allowed_origins", "\"*\" cannot be used with allow_credentials")
+			continue
+		}
+		u, err := url.Parse(origin)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" &&
+			u.RawQuery == "" && u.User == nil, "cors.allowed_origins", "invalid origin %q: use scheme://host[:port]", origin)
+	}
+	check(cors.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	security := c.Security
+	check(oneOf(security.FrameOptions, frameOptions), "security.frame_options",
+		"must be one of %v, got %q", frameOptions, security.FrameOptions)
+	check(oneOf(security.ReferrerPolicy, referrerPolicies), "security.referrer_policy",
+		"must be one of %v, got %q", referrerPolicies, security.ReferrerPolicy)
+	check(security.HSTSMaxAge >= 0, "security.hsts_max_age", "must not be negative")
+
+	rateLimit := c.RateLimit
+	check(oneOf(rateLimit.Backend, rateLimiters), "rate_limit.backend",
+		"must be one of %v, got %q", rateLimiters, rateLimit.Backend)
+	for _, policy := range rateLimit.Policies {
+		_, err := ratelimit.ParsePolicy(policy)
+		check(err == nil, "rate_limit.policies", "%v", err)
+	}
+	if rateLimit.Backend == "redis" {
+		check(rateLimit.RedisAddr != "", "rate_limit.redis_addr", "must not be empty")
+		check(rateLimit.RedisDB >= 0, "rate_limit.redis_db", "must not be negative")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		check(net.ParseIP(proxy) != nil || validCIDR(proxy), "server.trusted_proxies", "invalid IP address or CIDR %q", proxy)
+	}
+	for _, network := range c.Webhooks.AllowedNetworks {
+		check(net.ParseIP(network) != nil || validCIDR(network), "webhooks.allowed_networks", "invalid IP address or CIDR %q", network)
+	}
+
+	if c.Admin.Email != "" {
+		check(strings.Contains(c.Admin.Email, "@"), "admin.email", "must be an email address, got %q", c.Admin.Email)
+		check(c.Admin.Password == "" || len(c.Admin.Password) >= 8, "admin.password", "must be at least 8 characters long")
+	}
+
+	providers := make(map[string]bool, len(c.OIDC.Providers))
+	for _, spec := range c.OIDC.Providers {
+		provider, err := oidc.ParseProvider(spec)
+		// Текст ошибки не содержит настроек целиком, поэтому секрет клиента не попадает в вывод
+		check(err == nil, "oidc.providers", "%v", err)
+		if err == nil {
+			check(!providers[provider.Name], "oidc.providers", "duplicate provider %q", provider.Name)
+			providers[provider.Name] = true
+		}
+	}
+	// С SameSite=Strict браузер не отправит cookie сессии при возврате от поставщика, и вход не завершится
+	check(len(c.OIDC.Providers) == 0 || session.CookieSameSite != "strict", "session.cookie_same_site",
+		"strict breaks the OIDC login callback; use lax")
+	if base := c.OIDC.RedirectBaseURL; base != "" {
+		u, err := url.Parse(base)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.RawQuery == "" &&
+			u.Fragment == "", "oidc.redirect_base_url", "invalid URL %q: use scheme://host[:port][/path]", base)
+	}
+
+	if oauth := c.OAuth; oauth.Enabled() {
+		u, err := url.Parse(oauth.Issuer)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/") &&
+			u.RawQuery == "" && u.Fragment == "", "oauth.issuer", "invalid URL %q: use scheme://host[:port]", oauth.Issuer)
+		check(oauth.AccessTokenTTL > 0, "oauth.access_token_ttl", "must be positive")
+		check(oauth.CodeTTL > 0, "oauth.code_ttl", "must be positive")
+		check(oauth.CleanupInterval > 0, "oauth.cleanup_interval", "must be positive")
+		if oauth.SigningKey != "" {
+			_, err := jose.ParsePrivateKey([]byte(oauth.SigningKey))
+			check(err == nil, "oauth.signing_key", "%v", err)
+		}
+	}
+
+	if c.Env == EnvProduction {
+		errs = append(errs, c.validateProductionSecrets()...)
+	}
+	return errs
+}
+
+// Минимальная длина секретов в production
+const (
+	minSessionKeyLength = 32
+	minPasswordLength   = 12
+	minMasterKeyLength  = 16
+)
+
+// wellKnownSecrets - значения по умолчанию и распространенные пароли, недопустимые в production
+var wellKnownSecrets = []string{
+	"secret", "postgres", "password", "changeme", "admin", "root", "default", "test", "123456", "qwerty",
+}
+
+// validateProductionSecrets запрещает в production пустые, известные и слабые секреты
+func (c *Config) validateProductionSecrets() []error {
+	var errs []error
+	check := func(key, value string, minLength int) {
+		if problem := weakSecret(value, minLength); problem != "" {
+			errs = append(errs, fmt.Errorf("%s: %s; set a strong secret for production", key, problem))
+		}
+	}
+
+	if len(c.Session.Keys) == 0 {
+		errs = append(errs, errors.New("session.keys: must not be empty in production"))
+	}
+	for i, key := range c.Session.Keys {
+		check(fmt.Sprintf("session.keys[%d]", i), key, minSessionKeyLength)
+	}
+	if !c.Session.CookieSecure {
+		errs = append(errs, errors.New("session.cookie_secure: must be true in production"))
+	}
+	if !c.APIKeys.Required {
+		errs = append(errs, errors.New("api_keys.required: must be true in production"))
+	}
+	if len(c.OIDC.Providers) > 0 && !strings.HasPrefix(c.OIDC.RedirectBaseURL, "https://") {
+		errs = append(errs, errors.New("oidc.redirect_base_url: must be an https URL in production"))
+	}
+	if c.OAuth.Enabled() {
+		if !strings.HasPrefix(c.OAuth.Issuer, "https://") {
+			errs = append(errs, errors.New("oauth.issuer: must be an https URL in production"))
+		}
+		if c.OAuth.SigningKey == "" {
+			errs = append(errs, errors.New("oauth.signing_key: must not be empty in production"))
+		}
+	}
+	if c.DB.Driver == "postgres" {
+		if c.DB.URL == "" {
+			// Пустой пароль уже отмечен общей проверкой
+			if c.DB.Password != "" {
+				check("db.password", c.DB.Password, minPasswordLength)
+			}
+		} else if u, err := url.Parse(c.DB.URL); err == nil {
+			if password, ok := u.User.Password(); ok {
+				check("db.url", password, minPasswordLength)
+			}
+		}
+	}
+	if c.Cache.Backend == "redis" && c.Cache.RedisPassword != "" {
+		check("cache.redis_password", c.Cache.RedisPassword, minPasswordLength)
+	}
+	if c.RateLimit.Backend == "redis" && c.RateLimit.RedisPassword != "" {
+		check("rate_limit.redis_password", c.RateLimit.RedisPassword, minPasswordLength)
+	}
+	if c.Admin.Password != "" {
+		check("admin.password", c.Admin.Password, minPasswordLength)
+	}
+	if c.Secrets.File != "" {
+		check("secrets.master_key", c.Secrets.MasterKey, minMasterKeyLength)
+	}
+	return errs
+}
+
+// weakSecret возвращает причину, по которой секрет слабый, или пустую строку
+func weakSecret(value string, minLength int) string {
+	switch {
+	case value == "":
+		return "must not be empty"
+	case oneOf(strings.ToLower(value), wellKnownSecrets):
+		return "is a default or well-known value"
+	case len(value) < minLength:
+		return fmt.Sprintf("must be at least %d characters long", minLength)
+	case strings.Count(value, value[:1]) == len(value):
+		return "must not repeat a single character"
+	}
+	return ""
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// validCipherSuite сообщает, является ли name набором шифров, который Go считает безопасным
+func validCipherSuite(name string) bool {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validCIDR(value string) bool {
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Области действия API-ключей
+const (
+	ScopeUsersRead          = "users:read"
+	ScopeUsersWrite         = "users:write"
+	ScopeSessionsManage     = "sessions:manage"
+	ScopeWebhooksManage     = "webhooks:manage"
+	ScopeAPIKeysManage      = "api_keys:manage"
+	ScopeOAuthClientsManage = "oauth_clients:manage"
+	ScopeMetricsRead        = "metrics:read"
+)
+
+// APIKeyScopes перечисляет области действия, которые можно выдать ключу
+var APIKeyScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeSessionsManage, ScopeWebhooksManage, ScopeAPIKeysManage, ScopeOAuthClientsManage, ScopeMetricsRead}
+
+// APIKey представляет ключ доступа к API для машинных клиентов. Ключ принадлежит пользователю (UserID)
+// или сервисному аккаунту (ServiceAccount - имя системы, например provisioning).
+// Сам ключ не хранится: Prefix - его видимое начало для поиска и отображения, Hash - хеш SHA-256 всего ключа.
+type APIKey struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix         string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"prefix"`
+	Hash           string     `gorm:"type:varchar(64);not null" json:"-"`
+	UserID         *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	ServiceAccount string     `gorm:"type:varchar(100)" json:"service_account,omitempty"`
+	Scopes         []string   `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// HasScope сообщает, выдана ли ключу область действия scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Active сообщает, действует ли ключ в момент now: он не отозван и не истек
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return
+}
+
+// CreateAPIKeyInput определяет структуру для создания API-ключа; задается ровно один владелец - UserID или ServiceAccount
+type CreateAPIKeyInput struct {
+	Name           string     `json:"name" binding:"required,max=100"`
+	UserID         *uuid.UUID `json:"user_id"`
+	ServiceAccount string     `json:"service_account" binding:"omitempty,max=100"`
+	Scopes         []string   `json:"scopes" binding:"required,min=1,dive,oneof=users:read users:write sessions:manage webhooks:manage api_keys:manage oauth_clients:manage metrics:read"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
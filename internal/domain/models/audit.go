@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Действия, фиксируемые в журнале аудита
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditPasswordChanged - значение, которым в журнале аудита обозначается смена пароля (хеш не сохраняется)
+const AuditPasswordChanged = "changed"
+
+// FieldChange описывает изменение одного поля
+type FieldChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// AuditEvent представляет запись журнала аудита изменений пользователя.
+// Таблица только дополняется: изменение и удаление записей запрещено триггером.
+type AuditEvent struct {
+	ID        uuid.UUID              `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID              `gorm:"type:uuid;index" json:"user_id"`
+	Action    string                 `gorm:"type:varchar(20)" json:"action"`
+	Actor     string                 `gorm:"type:varchar(255)" json:"actor"`
+	SourceIP  string                 `gorm:"type:varchar(64)" json:"source_ip"`
+	RequestID string                 `gorm:"type:varchar(128)" json:"request_id"`
+	Changes   map[string]FieldChange `gorm:"type:jsonb;serializer:json" json:"changes"`
+	CreatedAt time.Time              `gorm:"index" json:"created_at"`
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
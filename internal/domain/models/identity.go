@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Identity - учетная запись пользователя у внешнего поставщика удостоверений (OpenID Connect).
+// Пара Provider и Subject однозначно определяет учетную запись и привязана к одному пользователю.
+type Identity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	Provider string    `gorm:"type:varchar(50);uniqueIndex:idx_identities_provider_subject;not null" json:"provider"`
+	Subject  string    `gorm:"type:varchar(255);uniqueIndex:idx_identities_provider_subject;not null" json:"subject"`
+	// Email - адрес из ID-токена при привязке; справочный, вход выполняется по Subject
+	Email     string    `gorm:"type:varchar(255)" json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (i *Identity) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Типы разрешений OAuth2, которые поддерживает сервер авторизации
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// Области действия OpenID Connect, которые определяют утверждения о пользователе в ID-токене и userinfo
+const (
+	OAuthScopeOpenID  = "openid"
+	OAuthScopeProfile = "profile"
+	OAuthScopeEmail   = "email"
+)
+
+// OAuthClient - приложение, зарегистрированное на сервере авторизации. ID служит идентификатором
+// клиента (client_id). Конфиденциальный клиент аутентифицируется секретом, который хранится только
+// в виде хеша SHA-256; публичный клиент (Public) секрета не имеет и защищен только PKCE.
+// Доверенному клиенту (Trusted) - собственному приложению организации - пользователь не подтверждает доступ;
+// остальным клиентам доступ подтверждается на странице согласия.
+type OAuthClient struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"client_id"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	SecretHash   string    `gorm:"type:varchar(64)" json:"-"`
+	Public       bool      `gorm:"not null;default:false" json:"public"`
+	Trusted      bool      `gorm:"not null;default:false" json:"trusted"`
+	RedirectURIs []string  `gorm:"type:jsonb;serializer:json" json:"redirect_uris"`
+	GrantTypes   []string  `gorm:"type:jsonb;serializer:json" json:"grant_types"`
+	// Scopes - области действия, которые клиент может запросить
+	Scopes    []string  `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HasGrant сообщает, разрешен ли клиенту тип разрешения grant
+func (c *OAuthClient) HasGrant(grant string) bool {
+	return containsString(c.GrantTypes, grant)
+}
+
+// HasRedirectURI сообщает, зарегистрирован ли адрес возврата; адрес сравнивается целиком
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return containsString(c.RedirectURIs, uri)
+}
+
+// HasScope сообщает, может ли клиент запросить область действия scope
+func (c *OAuthClient) HasScope(scope string) bool {
+	return containsString(c.Scopes, scope)
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (c *OAuthClient) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return
+}
+
+// CreateOAuthClientInput определяет структуру для регистрации клиента; пустые GrantTypes и Scopes означают
+// authorization_code и openid profile email
+type CreateOAuthClientInput struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	Public       bool     `json:"public"`
+	Trusted      bool     `json:"trusted"`
+	RedirectURIs []string `json:"redirect_uris" binding:"omitempty,dive,url"`
+	GrantTypes   []string `json:"grant_types" binding:"omitempty,dive,oneof=authorization_code client_credentials"`
+	Scopes       []string `json:"scopes" binding:"omitempty,dive,min=1,max=100"`
+}
+
+// OAuthAuthorizationCode - одноразовый код авторизации, выданный пользователю UserID для клиента ClientID.
+// Код хранится в виде хеша SHA-256; CodeChallenge - код подтверждения PKCE (метод S256).
+// RedirectURIProvided сообщает, передал ли клиент redirect_uri в запросе авторизации: только тогда
+// этот адрес обязателен при обмене кода (RFC 6749, раздел 4.1.3).
+type OAuthAuthorizationCode struct {
+	CodeHash            string    `gorm:"type:varchar(64);primary_key" json:"-"`
+	ClientID            uuid.UUID `gorm:"type:uuid;not null" json:"client_id"`
+	UserID              uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	RedirectURI         string    `gorm:"type:text;not null" json:"redirect_uri"`
+	RedirectURIProvided bool      `gorm:"not null;default:false" json:"-"`
+	Scopes              []string  `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	Nonce               string    `gorm:"type:varchar(255)" json:"nonce,omitempty"`
+	CodeChallenge       string    `gorm:"type:varchar(128);not null" json:"-"`
+	ExpiresAt           time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// OAuthToken - выданный клиенту токен доступа. Токен непрозрачный и хранится в виде хеша SHA-256,
+// поэтому его можно проверить (introspection) и отозвать. UserID пуст для токенов client_credentials.
+type OAuthToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ClientID  uuid.UUID  `gorm:"type:uuid;index;not null" json:"client_id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Scopes    []string   `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active сообщает, действует ли токен в момент now: он не отозван и не истек
+func (t *OAuthToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (t *OAuthToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
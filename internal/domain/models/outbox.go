@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Типы доменных событий пользователя
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// OutboxEvent представляет доменное событие, ожидающее публикации (transactional outbox).
+// Событие записывается в одной транзакции с изменением пользователя, а публикуется фоновым процессом.
+// Порядок событий одного пользователя определяется возрастанием ID.
+//
+// Неопубликованное событие ждет попытки публикации до NextAttemptAt; после исчерпания попыток оно
+// переводится в dead letter (DeadAt) и больше не публикуется и не задерживает следующие события пользователя.
+type OutboxEvent struct {
+	ID            uint64          `gorm:"primaryKey;autoIncrement" json:"-"`
+	EventID       uuid.UUID       `gorm:"type:uuid;uniqueIndex" json:"id"`
+	AggregateID   uuid.UUID       `gorm:"type:uuid;index" json:"aggregate_id"`
+	Type          string          `gorm:"type:varchar(50)" json:"type"`
+	Payload       json.RawMessage `gorm:"type:jsonb" json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   *time.Time      `gorm:"index" json:"-"`
+	Attempts      int             `json:"-"`
+	LastError     string          `gorm:"type:text" json:"-"`
+	NextAttemptAt time.Time       `gorm:"not null;index" json:"-"`
+	DeadAt        *time.Time      `json:"-"`
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи:
+// событие без времени попытки публикации можно публиковать сразу после записи
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = tx.NowFunc()
+	}
+	if e.NextAttemptAt.IsZero() {
+		e.NextAttemptAt = e.CreatedAt
+	}
+	return
+}
+
+// UserEventPayload - содержимое событий пользователя
+type UserEventPayload struct {
+	User          *User    `json:"user"`
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
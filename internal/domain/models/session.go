@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session представляет сессию веб-интерфейса, хранящуюся на сервере.
+// ID - хеш SHA-256 токена из cookie: по записи в базе нельзя восстановить cookie.
+// Сессия действует до ExpiresAt - раньше из двух сроков: LastSeenAt плюс время бездействия
+// и CreatedAt плюс максимальный срок жизни.
+type Session struct {
+	ID         string     `gorm:"type:varchar(64);primary_key" json:"id"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Data       []byte     `json:"-"`
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	SourceIP   string     `gorm:"type:varchar(64)" json:"source_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"`
+}
@@ -1,19 +1,24 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// User представляет модель пользователя
+// User представляет модель пользователя.
+// Уникальность email обеспечивается индексом idx_users_email_lower по lower(email), который создается миграцией.
 type User struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
-	Email     string    `gorm:"type:varchar(100);unique_index" json:"email" binding:"required,email"`
+	Email     string    `gorm:"type:varchar(100);not null" json:"email" binding:"required,email"`
 	FirstName string    `gorm:"type:varchar(100)" json:"first_name" binding:"required"`
 	LastName  string    `gorm:"type:varchar(100)" json:"last_name" binding:"required"`
 	Password  string    `gorm:"type:varchar(255)" json:"-"` // Не отправляем пароль в JSON
+	Role      string    `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -24,18 +29,152 @@ type CreateUserInput struct {
 	FirstName string `json:"first_name" form:"first_name" binding:"required"`
 	LastName  string `json:"last_name" form:"last_name" binding:"required"`
 	Password  string `json:"password" form:"password" binding:"required,min=8"`
+	Role      string `json:"role" form:"role" binding:"omitempty,oneof=user admin"`
 }
 
 // UpdateUserInput определяет структуру для обновления данных пользователя
 type UpdateUserInput struct {
-    Email     string `json:"email" form:"email" binding:"omitempty,email"`
-    FirstName string `json:"first_name" form:"first_name"`
-    LastName  string `json:"last_name" form:"last_name"`
-    Password  string `json:"password" form:"password" binding:"omitempty,min=8"`
+	Email     string `json:"email" form:"email" binding:"omitempty,email"`
+	FirstName string `json:"first_name" form:"first_name"`
+	LastName  string `json:"last_name" form:"last_name"`
+	Password  string `json:"password" form:"password" binding:"omitempty,min=8"`
+	Role      string `json:"role" form:"role" binding:"omitempty,oneof=user admin"`
+}
+
+// Роли пользователей: управлять пользователями в веб-интерфейсе может только администратор
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsAdmin сообщает, является ли пользователь администратором
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// NormalizeEmail приводит email к каноническому виду, в котором он хранится в БД
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // BeforeCreate - хук GORM, который выполняется перед созданием записи
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	u.ID = uuid.New()
 	return
-}
\ No newline at end of file
+}
+
+// UserFilter определяет условия отбора пользователей для списков и выгрузок
+type UserFilter struct {
+	Email         string     `form:"email"`
+	FirstName     string     `form:"first_name"`
+	LastName      string     `form:"last_name"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Page определяет параметры постраничного вывода
+type Page struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// UserExportFields перечисляет поля, доступные для выгрузки (пароль исключен намеренно)
+var UserExportFields = []string{"id", "email", "first_name", "last_name", "created_at", "updated_at"}
+
+// ExportValue возвращает значение поля пользователя для выгрузки
+func (u *User) ExportValue(field string) (interface{}, bool) {
+	switch field {
+	case "id":
+		return u.ID.String(), true
+	case "email":
+		return u.Email, true
+	case "first_name":
+		return u.FirstName, true
+	case "last_name":
+		return u.LastName, true
+	case "created_at":
+		return u.CreatedAt.UTC().Format(time.RFC3339), true
+	case "updated_at":
+		return u.UpdatedAt.UTC().Format(time.RFC3339), true
+	}
+	return nil, false
+}
+
+// Типы операций пакетного запроса
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchRequest определяет структуру пакетного запроса
+type BatchRequest struct {
+	Atomic     bool                    `json:"atomic"`
+	Operations []BatchRequestOperation `json:"operations" binding:"required,min=1,max=100"`
+}
+
+// BatchRequestOperation определяет операцию пакетного запроса в том виде, в котором ее прислал клиент
+type BatchRequestOperation struct {
+	Op   string          `json:"op"`
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// BatchOperation описывает одну разобранную операцию пакетного запроса
+type BatchOperation struct {
+	Op     string
+	ID     uuid.UUID
+	Create CreateUserInput
+	Update UpdateUserInput
+}
+
+// BatchOperationResult содержит результат выполнения одной операции пакета
+type BatchOperationResult struct {
+	User    *User
+	Err     error
+	Skipped bool // операция не выполнялась или была отменена откатом транзакции
+}
+
+// UserSearchResult представляет найденного пользователя с оценкой релевантности
+// и подсветкой совпадений (HTML, совпадения обернуты в <mark>)
+type UserSearchResult struct {
+	User       *User             `json:"user"`
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchTerms разбивает поисковый запрос на слова в нижнем регистре, отбрасывая служебные символы
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchRank - упрощенный поиск для хранилищ без полнотекстовых индексов.
+// Каждое слово запроса должно совпасть с началом слова (ранг +1) или входить подстрокой (ранг +0.5)
+// в email, имя или фамилию пользователя; иначе ok равен false. Опечатки не учитываются.
+func (u *User) SearchRank(terms []string) (rank float64, ok bool) {
+	document := strings.ToLower(u.Email + " " + u.FirstName + " " + u.LastName)
+	words := SearchTerms(document)
+
+	for _, term := range terms {
+		switch {
+		case hasWordPrefix(words, term):
+			rank += 1
+		case strings.Contains(document, term):
+			rank += 0.5
+		default:
+			return 0, false
+		}
+	}
+	return rank, len(terms) > 0
+}
+
+func hasWordPrefix(words []string, prefix string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
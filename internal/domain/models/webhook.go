@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Состояния доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEventTypes перечисляет события, на которые можно подписаться
+var WebhookEventTypes = []string{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+// WebhookSubscription представляет подписку внешней системы на события пользователей
+type WebhookSubscription struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	URL        string    `gorm:"type:varchar(2048)" json:"url"`
+	EventTypes []string  `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	Secret     string    `gorm:"type:varchar(255)" json:"-"` // Не отправляем секрет в JSON (кроме ответа на создание)
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Subscribed сообщает, подписана ли подписка на событие
+func (s *WebhookSubscription) Subscribed(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// WebhookDelivery представляет доставку одного события одной подписке.
+// Запись служит и журналом, и очередью повторов: пока доставка в состоянии pending,
+// она отправляется снова после NextAttemptAt.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	SubscriptionID uuid.UUID       `gorm:"type:uuid;index" json:"subscription_id"`
+	EventID        uuid.UUID       `gorm:"type:uuid;index" json:"event_id"`
+	EventType      string          `gorm:"type:varchar(50)" json:"event_type"`
+	Payload        json.RawMessage `gorm:"type:jsonb" json:"payload"`
+	Status         string          `gorm:"type:varchar(20);index" json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"index" json:"next_attempt_at"`
+	ResponseCode   int             `json:"response_code"`
+	LastError      string          `gorm:"type:text" json:"last_error,omitempty"`
+	ReplayOf       *uuid.UUID      `gorm:"type:uuid" json:"replay_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// BeforeCreate - хук GORM, который выполняется перед созданием записи
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return
+}
+
+// CreateWebhookInput определяет структуру для создания подписки
+type CreateWebhookInput struct {
+	URL        string   `json:"url" binding:"required,url,startswith=http"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+}
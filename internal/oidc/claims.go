@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Claims - утверждения ID-токена, которые нужны для входа
+type Claims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce,omitempty"`
+
+	Email         string `json:"email,omitempty"`
+	EmailVerified Bool   `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+}
+
+// Audience - получатели токена; в JSON это строка или массив строк (RFC 7519, раздел 4.1.3)
+type Audience []string
+
+// Contains сообщает, входит ли clientID в число получателей
+func (a Audience) Contains(clientID string) bool {
+	return contains(a, clientID)
+}
+
+// MarshalJSON записывает единственного получателя строкой
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON принимает строку или массив строк
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Bool - логическое утверждение; некоторые поставщики передают email_verified строкой "true"
+type Bool bool
+
+// UnmarshalJSON принимает true/false и их строковую запись
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = Bool(value)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		return err
+	}
+	*b = Bool(value)
+	return nil
+}
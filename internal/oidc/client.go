@@ -0,0 +1,261 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Est1ege/go-user-api/pkg/jose"
+)
+
+const (
+	// clockSkew - допустимое расхождение часов с поставщиком при проверке exp и iat
+	clockSkew = time.Minute
+	// keysRefreshInterval ограничивает повторную загрузку JWKS при неизвестном kid, чтобы токены с чужими
+	// ключами не превращались в запросы к поставщику
+	keysRefreshInterval = time.Minute
+	// maxResponseSize ограничивает размер ответов поставщика
+	maxResponseSize = 1 << 20
+)
+
+// ErrInvalidIDToken возвращается, если ID-токен не прошел проверку
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Metadata - настройки поставщика из документа discovery (OpenID Connect Discovery 1.0)
+type Metadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// Provider - клиент поставщика удостоверений; настройки discovery и ключи загружаются при первом обращении и кешируются
+type Provider struct {
+	cfg    ProviderConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	metadata    *Metadata
+	keys        jose.JWKS
+	keysFetched time.Time
+}
+
+// NewProvider создает клиент поставщика; client - HTTP-клиент для запросов к поставщику (nil - с таймаутом 10 секунд)
+func NewProvider(cfg ProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client, now: time.Now}
+}
+
+// Config возвращает настройки поставщика
+func (p *Provider) Config() ProviderConfig {
+	return p.cfg
+}
+
+// AuthCodeURL возвращает адрес страницы входа поставщика для потока authorization code с PKCE (метод S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, verifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// tokenResponse - ответ конечной точки токенов
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange обменивает код авторизации на токены и возвращает утверждения проверенного ID-токена;
+// nonce должен совпадать с переданным в AuthCodeURL
+func (p *Provider) Exchange(ctx context.Context, code, verifier, redirectURI, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// client_secret_basic: идентификатор и секрет кодируются как в форме (RFC 6749, раздел 2.3.1)
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("token response: %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if token.Error != "" {
+			return nil, fmt.Errorf("token request: %s: %s", token.Error, token.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token request: %s", resp.Status)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+// Verify проверяет подпись ID-токена ключами поставщика, издателя, получателя, срок действия и nonce
+func (p *Provider) Verify(ctx context.Context, idToken, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := jose.Verify(idToken, func(header jose.Header) (crypto.PublicKey, error) {
+		return p.key(ctx, header.Kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	now := p.now()
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: empty subject", ErrInvalidIDToken)
+	case !claims.Audience.Contains(p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: token is not issued for this client", ErrInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID:
+		return nil, fmt.Errorf("%w: unexpected authorized party %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	case claims.AuthorizedParty != "" && claims.AuthorizedParty != p.cfg.ClientID:
+		return nil, fmt.Errorf("%w: unexpected authorized party %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return nil, fmt.Errorf("%w: token issued in the future", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return &claims, nil
+}
+
+// discover загружает документ discovery поставщика; успешный результат кешируется
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("OIDC discovery for %s: %w", p.cfg.Name, err)
+	}
+	// Издатель в документе должен совпадать с настроенным (OpenID Connect Discovery, раздел 4.3)
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery for %s: issuer %q does not match %q", p.cfg.Name, metadata.Issuer, p.cfg.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery for %s: incomplete provider metadata", p.cfg.Name)
+	}
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// key возвращает открытый ключ поставщика с идентификатором kid; при неизвестном kid набор ключей
+// загружается заново, но не чаще keysRefreshInterval - так подхватывается ротация ключей
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	jwk, ok := p.findKey(kid)
+	if !ok && (p.keysFetched.IsZero() || p.now().Sub(p.keysFetched) >= keysRefreshInterval) {
+		var keys jose.JWKS
+		if err := p.getJSON(ctx, p.metadata.JWKSURI, &keys); err != nil {
+			return nil, fmt.Errorf("fetch JWKS: %w", err)
+		}
+		p.keys, p.keysFetched = keys, p.now()
+		jwk, ok = p.findKey(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return jwk.PublicKey()
+}
+
+// findKey ищет ключ подписи; токен без kid допустим, если у поставщика единственный ключ
+func (p *Provider) findKey(kid string) (jose.JWK, bool) {
+	if kid == "" && len(p.keys.Keys) == 1 {
+		return p.keys.Keys[0], true
+	}
+	jwk, ok := p.keys.Key(kid)
+	if ok && jwk.Use != "" && jwk.Use != "sig" {
+		return jose.JWK{}, false
+	}
+	return jwk, ok
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
@@ -0,0 +1,124 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/oidc"
+	"github.com/Est1ege/go-user-api/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURI = "http://localhost:8080/web/login/oidc/corp/callback"
+
+func newProvider(t *testing.T, idp *oidctest.Server) *oidc.Provider {
+	cfg, err := oidc.ParseProvider("name=corp;issuer=" + idp.URL + ";client_id=" + idp.ClientID + ";client_secret=" + idp.ClientSecret)
+	require.NoError(t, err)
+	return oidc.NewProvider(cfg, idp.Client())
+}
+
+// login проходит авторизацию у поставщика и возвращает код из адреса возврата
+func login(t *testing.T, idp *oidctest.Server, provider *oidc.Provider, verifier string) string {
+	authURL, err := provider.AuthCodeURL(context.Background(), redirectURI, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	callback, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+
+	u, err := url.Parse(callback)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", u.Query().Get("state"))
+	return u.Query().Get("code")
+}
+
+func TestParseProvider(t *testing.T) {
+	cfg, err := oidc.ParseProvider("name=corp; issuer=https://sso.example.com/; client_id=user-api; provision=true; domains=Example.com corp.example.com; title=Corporate SSO")
+	require.NoError(t, err)
+	assert.Equal(t, "https://sso.example.com", cfg.Issuer)
+	assert.Equal(t, oidc.DefaultScopes, cfg.Scopes)
+	assert.True(t, cfg.Provision)
+	assert.Equal(t, "Corporate SSO", cfg.Title)
+	assert.True(t, cfg.AllowsEmail("john@EXAMPLE.com"))
+	assert.False(t, cfg.AllowsEmail("john@example.org"))
+
+	for spec, want := range map[string]string{
+		"issuer=https://sso.example.com;client_id=x":                           "invalid OIDC provider name",
+		"name=corp;issuer=http://sso.example.com;client_id=x":                  "must use https",
+		"name=corp;issuer=https://sso.example.com":                             "client_id must not be empty",
+		"name=corp;issuer=https://sso.example.com;client_id=x;scope=openid":    "unknown OIDC provider setting",
+		"name=corp;issuer=https://sso.example.com;client_id=x;provision=maybe": "provision",
+	} {
+		_, err := oidc.ParseProvider(spec)
+		assert.ErrorContains(t, err, want, spec)
+	}
+
+	cfg, err = oidc.ParseProvider("name=dev;issuer=http://127.0.0.1:9000;client_id=x;scopes=email")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openid", "email"}, cfg.Scopes, "openid is always requested")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	idp := oidctest.NewServer("user-api", "secret")
+	defer idp.Close()
+	provider := newProvider(t, idp)
+
+	verifier, err := oidc.NewVerifier()
+	require.NoError(t, err)
+	code := login(t, idp, provider, verifier)
+
+	claims, err := provider.Exchange(context.Background(), code, verifier, redirectURI, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.True(t, bool(claims.EmailVerified))
+
+	// Код одноразовый
+	_, err = provider.Exchange(context.Background(), code, verifier, redirectURI, "nonce-1")
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestProvider_ExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     oidctest.User
+		verifier string
+		nonce    string
+		want     string
+	}{
+		{"Wrong PKCE verifier", oidctest.User{Subject: "s"}, "another-verifier-another-verifier-another-ver", "nonce-1", "invalid_grant"},
+		{"Wrong nonce", oidctest.User{Subject: "s"}, "", "nonce-2", "nonce mismatch"},
+		{"Foreign audience", oidctest.User{Subject: "s", Claims: map[string]any{"aud": "other-app"}}, "", "nonce-1", "not issued for this client"},
+		{"Expired token", oidctest.User{Subject: "s", Claims: map[string]any{"exp": 1}}, "", "nonce-1", "token expired"},
+		{"Foreign issuer", oidctest.User{Subject: "s", Claims: map[string]any{"iss": "https://evil.example.com"}}, "", "nonce-1", "unexpected issuer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewServer("user-api", "")
+			defer idp.Close()
+			idp.SetUser(tt.user)
+			provider := newProvider(t, idp)
+
+			verifier, err := oidc.NewVerifier()
+			require.NoError(t, err)
+			code := login(t, idp, provider, verifier)
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+
+			_, err = provider.Exchange(context.Background(), code, verifier, redirectURI, tt.nonce)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("user-api", "")
+	defer idp.Close()
+
+	cfg, err := oidc.ParseProvider("name=corp;issuer=" + idp.URL + "/tenant;client_id=user-api")
+	require.NoError(t, err)
+	_, err = oidc.NewProvider(cfg, idp.Client()).AuthCodeURL(context.Background(), redirectURI, "s", "n", "v")
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString возвращает случайную строку из n байт в base64url; используется для state, nonce и верификатора PKCE
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// NewVerifier создает верификатор PKCE (RFC 7636): 32 случайных байта, 43 символа
+func NewVerifier() (string, error) {
+	return RandomString(32)
+}
+
+// Challenge возвращает код подтверждения PKCE для верификатора по методу S256
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+// Package oidc реализует вход через внешних поставщиков удостоверений OpenID Connect: поток authorization code
+// с PKCE, получение настроек поставщика (discovery) и проверку ID-токенов по его ключам (JWKS)
+package oidc
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultScopes - области доступа, которые запрашиваются, если у поставщика они не заданы
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// ProviderConfig - настройки поставщика удостоверений
+type ProviderConfig struct {
+	// Name - идентификатор поставщика в адресах (/web/login/oidc/<name>) и в привязанных учетных записях
+	Name string
+	// Title - название на кнопке входа; по умолчанию Name
+	Title    string
+	Issuer   string
+	ClientID string
+	// ClientSecret - секрет клиента; пустой для публичного клиента, который защищен только PKCE
+	ClientSecret string
+	Scopes       []string
+	// Provision разрешает создавать пользователя при первом входе, если учетной записи с его email нет
+	Provision bool
+	// Domains - домены email, которые принимаются от поставщика для привязки по email и создания пользователей;
+	// пусто - любые
+	Domains []string
+}
+
+// ParseProvider разбирает настройки поставщика вида
+// "name=corp;issuer=https://sso.example.com;client_id=user-api;client_secret=...;scopes=openid email;provision=true;domains=example.com".
+// Обязательны name, issuer и client_id; списки scopes и domains разделяются пробелами.
+func ParseProvider(spec string) (ProviderConfig, error) {
+	var cfg ProviderConfig
+	for _, pair := range strings.Split(spec, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid OIDC provider setting %q: use key=value", pair)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "name":
+			cfg.Name = value
+		case "title":
+			cfg.Title = value
+		case "issuer":
+			cfg.Issuer = strings.TrimSuffix(value, "/")
+		case "client_id":
+			cfg.ClientID = value
+		case "client_secret":
+			cfg.ClientSecret = value
+		case "scopes":
+			cfg.Scopes = strings.Fields(value)
+		case "provision":
+			provision, err := strconv.ParseBool(value)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid OIDC provider setting provision=%q", value)
+			}
+			cfg.Provision = provision
+		case "domains":
+			cfg.Domains = strings.Fields(strings.ToLower(value))
+		default:
+			return cfg, fmt.Errorf("unknown OIDC provider setting %q", key)
+		}
+	}
+
+	if !providerName.MatchString(cfg.Name) {
+		return cfg, fmt.Errorf("invalid OIDC provider name %q: use lowercase letters, digits, - and _", cfg.Name)
+	}
+	if err := validIssuer(cfg.Issuer); err != nil {
+		return cfg, fmt.Errorf("OIDC provider %s: %w", cfg.Name, err)
+	}
+	if cfg.ClientID == "" {
+		return cfg, fmt.Errorf("OIDC provider %s: client_id must not be empty", cfg.Name)
+	}
+	if cfg.Title == "" {
+		cfg.Title = cfg.Name
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	if !contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return cfg, nil
+}
+
+// AllowsEmail сообщает, принимается ли email от поставщика с учетом Domains
+func (c ProviderConfig) AllowsEmail(email string) bool {
+	if len(c.Domains) == 0 {
+		return true
+	}
+	_, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	return ok && contains(c.Domains, domain)
+}
+
+// validIssuer требует https; http допускается только для локального поставщика (например, в тестах)
+func validIssuer(issuer string) error {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid issuer %q", issuer)
+	}
+	switch {
+	case u.Scheme == "https":
+		return nil
+	case u.Scheme == "http" && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1" || u.Hostname() == "::1"):
+		return nil
+	default:
+		return fmt.Errorf("issuer %q must use https", issuer)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
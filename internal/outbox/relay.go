@@ -0,0 +1,205 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPollInterval - интервал опроса outbox по умолчанию
+	DefaultPollInterval = time.Second
+	// DefaultBatchSize - количество событий, публикуемых за один проход
+	DefaultBatchSize = 100
+	// DefaultLease - на сколько захватываются события порции; за это время они должны быть опубликованы,
+	// иначе их снова выберет этот или другой экземпляр сервиса
+	DefaultLease = time.Minute
+	// DefaultMaxAttempts - количество попыток публикации, после которого событие переводится в dead letter
+	DefaultMaxAttempts = 10
+	// DefaultBaseBackoff и DefaultMaxBackoff - задержка перед повтором после первой неудачи и ее предел
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = 10 * time.Minute
+)
+
+// Publisher доставляет доменные события получателям.
+// Доставка выполняется "как минимум один раз": получатель должен быть идемпотентен по EventID.
+type Publisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// PublisherFunc позволяет использовать функцию в качестве Publisher
+type PublisherFunc func(ctx context.Context, event *models.OutboxEvent) error
+
+// Publish вызывает f(ctx, event)
+func (f PublisherFunc) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+// LogPublisher пишет события в лог; используется, если другой Publisher не настроен
+type LogPublisher struct{}
+
+// Publish записывает событие в лог
+func (LogPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	log.Printf("Domain event %s %s aggregate=%s payload=%s", event.Type, event.EventID, event.AggregateID, event.Payload)
+	return nil
+}
+
+// Relay периодически публикует события из outbox
+type Relay struct {
+	repo       repository.OutboxRepository
+	transactor repository.Transactor
+	publisher  Publisher
+
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+
+	now func() time.Time
+}
+
+// NewRelay создает новый экземпляр Relay
+func NewRelay(repo repository.OutboxRepository, transactor repository.Transactor, publisher Publisher) *Relay {
+	return &Relay{
+		repo:         repo,
+		transactor:   transactor,
+		publisher:    publisher,
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		Lease:        DefaultLease,
+		MaxAttempts:  DefaultMaxAttempts,
+		BaseBackoff:  DefaultBaseBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		now:          time.Now,
+	}
+}
+
+// Run публикует события до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ProcessBatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Outbox relay error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch публикует очередную порцию событий и возвращает количество опубликованных.
+//
+// Порция захватывается в короткой транзакции (время попытки сдвигается на Lease), а публикация
+// и отметка о ее результате выполняются уже вне транзакции. Если событие пользователя не удалось
+// опубликовать, оно повторяется с экспоненциальной задержкой, а последующие события этого пользователя
+// ждут его, чтобы сохранить порядок. После MaxAttempts неудач событие переводится в dead letter.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	events, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	blocked := make(map[uuid.UUID]bool)
+	var skipped []uint64
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			// Оставшиеся события будут выбраны снова, когда истечет захват
+			return published, err
+		}
+		if blocked[event.AggregateID] {
+			skipped = append(skipped, event.ID)
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			blocked[event.AggregateID] = true
+			log.Printf("Failed to publish event %s (%s): %v", event.EventID, event.Type, err)
+			if err := r.fail(ctx, event, err); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := r.repo.MarkPublished(ctx, event.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	// Пропущенные события освобождаются сразу: пока ждет повтора более раннее событие пользователя,
+	// FetchPending их не вернет, а после перевода его в dead letter они публикуются со следующим проходом
+	if len(skipped) > 0 {
+		if err := r.repo.Schedule(ctx, skipped, r.now()); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+// claim выбирает порцию событий и захватывает ее на время Lease.
+// Блокировка TryLock не дает нескольким экземплярам сервиса захватить одни и те же события.
+func (r *Relay) claim(ctx context.Context) ([]*models.OutboxEvent, error) {
+	var events []*models.OutboxEvent
+	err := r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := r.repo.TryLock(ctx)
+		if err != nil || !locked {
+			// Порцию выбирает другой экземпляр сервиса
+			return err
+		}
+
+		now := r.now()
+		pending, err := r.repo.FetchPending(ctx, now, r.BatchSize)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, len(pending))
+		for i, event := range pending {
+			ids[i] = event.ID
+		}
+		if err := r.repo.Schedule(ctx, ids, now.Add(r.Lease)); err != nil {
+			return err
+		}
+		events = pending
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// fail сохраняет неудачную попытку публикации: назначает повтор или переводит событие в dead letter
+func (r *Relay) fail(ctx context.Context, event *models.OutboxEvent, cause error) error {
+	attempts := event.Attempts + 1
+	if attempts >= r.MaxAttempts {
+		log.Printf("Event %s (%s) moved to dead letter after %d attempts", event.EventID, event.Type, attempts)
+		return r.repo.MarkDead(ctx, event.ID, cause.Error())
+	}
+	return r.repo.MarkFailed(ctx, event.ID, cause.Error(), r.now().Add(Backoff(r.BaseBackoff, r.MaxBackoff, attempts)))
+}
+
+// Backoff возвращает задержку перед повтором после attempts неудачных попыток:
+// base * 2^(attempts-1), но не более max
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
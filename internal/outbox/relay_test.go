@@ -0,0 +1,225 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOutboxRepository хранит события в памяти
+type fakeOutboxRepository struct {
+	events []*models.OutboxEvent
+	locked bool
+}
+
+func (f *fakeOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	event.ID = uint64(len(f.events) + 1)
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeOutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	var pending []*models.OutboxEvent
+	waiting := make(map[uuid.UUID]bool)
+	for _, event := range f.events {
+		if event.PublishedAt != nil || event.DeadAt != nil || waiting[event.AggregateID] || len(pending) == limit {
+			continue
+		}
+		if event.NextAttemptAt.After(now) {
+			waiting[event.AggregateID] = true
+			continue
+		}
+		pending = append(pending, event)
+	}
+	return pending, nil
+}
+
+func (f *fakeOutboxRepository) Schedule(ctx context.Context, ids []uint64, at time.Time) error {
+	for _, id := range ids {
+		f.events[id-1].NextAttemptAt = at
+	}
+	return nil
+}
+
+func (f *fakeOutboxRepository) MarkPublished(ctx context.Context, id uint64) error {
+	now := f.events[id-1].CreatedAt
+	f.events[id-1].PublishedAt = &now
+	f.events[id-1].Attempts++
+	return nil
+}
+
+func (f *fakeOutboxRepository) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	f.events[id-1].Attempts++
+	f.events[id-1].LastError = reason
+	f.events[id-1].NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (f *fakeOutboxRepository) MarkDead(ctx context.Context, id uint64, reason string) error {
+	now := f.events[id-1].CreatedAt
+	f.events[id-1].DeadAt = &now
+	f.events[id-1].Attempts++
+	f.events[id-1].LastError = reason
+	return nil
+}
+
+func (f *fakeOutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	return !f.locked, nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestRelay_ProcessBatch_PreservesOrderPerUser(t *testing.T) {
+	// Arrange
+	repo := &fakeOutboxRepository{}
+	alice, bob := uuid.New(), uuid.New()
+	for _, e := range []struct {
+		aggregate uuid.UUID
+		typ       string
+	}{
+		{alice, models.EventUserCreated},
+		{bob, models.EventUserCreated},
+		{alice, models.EventUserUpdated},
+		{bob, models.EventUserDeleted},
+	} {
+		_ = repo.Create(context.Background(), &models.OutboxEvent{EventID: uuid.New(), AggregateID: e.aggregate, Type: e.typ})
+	}
+
+	var published []*models.OutboxEvent
+	failAlice := true
+	relay := NewRelay(repo, fakeTransactor{}, PublisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		if event.AggregateID == alice && failAlice {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, event)
+		return nil
+	}))
+
+	// Act: первое событие Alice не публикуется, ее второе событие должно подождать
+	count, err := relay.ProcessBatch(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []uint64{2, 4}, eventIDs(published))
+	assert.Equal(t, "broker unavailable", repo.events[0].LastError)
+	assert.Nil(t, repo.events[2].PublishedAt)
+
+	// Act: до истечения задержки событие Alice не повторяется
+	count, err = relay.ProcessBatch(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	// Act: после восстановления и истечения задержки события Alice публикуются по порядку
+	failAlice = false
+	relay.now = func() time.Time { return time.Now().Add(DefaultBaseBackoff) }
+	count, err = relay.ProcessBatch(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []uint64{2, 4, 1, 3}, eventIDs(published))
+	assert.Equal(t, 2, repo.events[0].Attempts)
+}
+
+func TestRelay_ProcessBatch_SkipsWhenLocked(t *testing.T) {
+	// Arrange
+	repo := &fakeOutboxRepository{locked: true}
+	_ = repo.Create(context.Background(), &models.OutboxEvent{EventID: uuid.New(), AggregateID: uuid.New()})
+	relay := NewRelay(repo, fakeTransactor{}, PublisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		t.Fatal("event must not be published while another instance holds the lock")
+		return nil
+	}))
+
+	// Act
+	count, err := relay.ProcessBatch(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestRelay_ProcessBatch_DeadLetter(t *testing.T) {
+	// Arrange
+	repo := &fakeOutboxRepository{}
+	alice := uuid.New()
+	_ = repo.Create(context.Background(), &models.OutboxEvent{EventID: uuid.New(), AggregateID: alice, Type: models.EventUserCreated})
+	_ = repo.Create(context.Background(), &models.OutboxEvent{EventID: uuid.New(), AggregateID: alice, Type: models.EventUserUpdated})
+
+	var published []*models.OutboxEvent
+	relay := NewRelay(repo, fakeTransactor{}, PublisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		if event.Type == models.EventUserCreated {
+			return errors.New("payload rejected")
+		}
+		published = append(published, event)
+		return nil
+	}))
+	relay.MaxAttempts = 3
+	clock := time.Now()
+	relay.now = func() time.Time { return clock }
+
+	// Act: каждая неудача откладывает повтор вдвое дольше
+	for attempt := 1; attempt < relay.MaxAttempts; attempt++ {
+		_, err := relay.ProcessBatch(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, clock.Add(Backoff(relay.BaseBackoff, relay.MaxBackoff, attempt)), repo.events[0].NextAttemptAt)
+		clock = repo.events[0].NextAttemptAt
+	}
+	_, err := relay.ProcessBatch(context.Background())
+	assert.Nil(t, err)
+
+	// Assert: событие в dead letter, следующее событие Alice больше его не ждет
+	assert.NotNil(t, repo.events[0].DeadAt)
+	assert.Equal(t, 3, repo.events[0].Attempts)
+	count, err := relay.ProcessBatch(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []uint64{2}, eventIDs(published))
+}
+
+func TestRelay_ProcessBatch_ClaimsEvents(t *testing.T) {
+	// Arrange
+	repo := &fakeOutboxRepository{}
+	_ = repo.Create(context.Background(), &models.OutboxEvent{EventID: uuid.New(), AggregateID: uuid.New()})
+
+	var relay *Relay
+	relay = NewRelay(repo, fakeTransactor{}, PublisherFunc(func(ctx context.Context, event *models.OutboxEvent) error {
+		// Пока событие публикуется, оно захвачено и не выбирается повторно
+		pending, err := repo.FetchPending(ctx, relay.now(), relay.BatchSize)
+		assert.Nil(t, err)
+		assert.Empty(t, pending)
+		return nil
+	}))
+
+	// Act
+	count, err := relay.ProcessBatch(context.Background())
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 8*time.Second, Backoff(time.Second, time.Minute, 4))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 20))
+}
+
+func eventIDs(events []*models.OutboxEvent) []uint64 {
+	ids := make([]uint64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
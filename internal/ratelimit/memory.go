@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Убедимся что MemoryStore реализует интерфейс Store
+var _ Store = (*MemoryStore)(nil)
+
+// sweepInterval - как часто MemoryStore удаляет наполнившиеся корзины
+const sweepInterval = time.Minute
+
+// MemoryStore хранит корзины в памяти процесса. Подходит для одного экземпляра сервиса:
+// у каждого экземпляра свои корзины.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	// full - когда корзина наполнится; после этого ее можно удалить, ничего не потеряв
+	full time.Time
+}
+
+// NewMemoryStore создает новый экземпляр MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket), now: time.Now}
+}
+
+// Take забирает токен из корзины key; новая корзина создается полной
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.Burst), updated: now}}
+		s.buckets[key] = b
+	}
+	result := b.take(limit, now)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// Len возвращает количество корзин
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep удаляет наполнившиеся корзины: новая корзина будет такой же
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,171 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket: у каждого ключа (IP-адреса,
+// пользователя, API-ключа) есть корзина на Burst токенов, которая пополняется со скоростью Rate токенов
+// в секунду; запрос забирает токен, а при пустой корзине отклоняется. Корзины хранятся в Store:
+// MemoryStore - в памяти процесса, Redis - общее хранилище для нескольких экземпляров сервиса.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit - параметры корзины: пополнение Rate токенов в секунду, не больше Burst токенов
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result - результат попытки взять токен
+type Result struct {
+	Allowed bool
+	// Limit - емкость корзины, Remaining - оставшиеся токены
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько появится токен, если запрос отклонен
+	RetryAfter time.Duration
+	// Reset - через сколько корзина наполнится полностью
+	Reset time.Duration
+}
+
+// Store - хранилище корзин. Реализация для нескольких экземпляров сервиса должна выполнять Take атомарно
+// в общем хранилище, иначе каждый экземпляр будет пропускать свою долю запросов.
+type Store interface {
+	// Take забирает токен из корзины key с параметрами limit
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// KeyType - по чему считаются запросы
+type KeyType string
+
+// Типы ключей; если ключа у запроса нет (анонимный пользователь, запрос без API-ключа), запросы считаются по IP
+const (
+	KeyIP     KeyType = "ip"
+	KeyUser   KeyType = "user"
+	KeyAPIKey KeyType = "api_key"
+)
+
+// Policy - правило ограничения для группы маршрутов: не больше Requests запросов за Period на ключ,
+// подряд - не больше Burst
+type Policy struct {
+	Group    string
+	Key      KeyType
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParsePolicy разбирает правило вида "<группа>:<ключ>:<запросы>/<период>[:<всплеск>]",
+// например "users.write:ip:30/1m:10"; без всплеска корзина вмещает все запросы периода
+func ParsePolicy(s string) (Policy, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 3 || len(parts) > 4 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: use <group>:<key>:<requests>/<period>[:<burst>]", s)
+	}
+
+	policy := Policy{Group: parts[0], Key: KeyType(parts[1])}
+	if policy.Group == "" {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: empty group", s)
+	}
+	switch policy.Key {
+	case KeyIP, KeyUser, KeyAPIKey:
+	default:
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: key must be %s, %s or %s", s, KeyIP, KeyUser, KeyAPIKey)
+	}
+
+	requests, period, ok := strings.Cut(parts[2], "/")
+	var err error
+	if policy.Requests, err = strconv.Atoi(requests); !ok || err != nil || policy.Requests <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: requests must be a positive integer", s)
+	}
+	if policy.Period, err = time.ParseDuration(period); err != nil || policy.Period <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: period must be a positive duration", s)
+	}
+
+	policy.Burst = policy.Requests
+	if len(parts) == 4 {
+		if policy.Burst, err = strconv.Atoi(parts[3]); err != nil || policy.Burst <= 0 {
+			return Policy{}, fmt.Errorf("invalid rate limit policy %q: burst must be a positive integer", s)
+		}
+	}
+	return policy, nil
+}
+
+// Limit возвращает параметры корзины правила
+func (p Policy) Limit() Limit {
+	return Limit{Rate: float64(p.Requests) / p.Period.Seconds(), Burst: p.Burst}
+}
+
+// Header возвращает описание правила для заголовка RateLimit-Policy
+func (p Policy) Header() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", p.Requests, int(math.Ceil(p.Period.Seconds())), p.Burst)
+}
+
+// Limiter хранит корзины в Store и правила, сгруппированные по группам маршрутов
+type Limiter struct {
+	store    Store
+	policies map[string][]Policy
+}
+
+// New создает Limiter с правилами в формате ParsePolicy
+func New(store Store, policies []string) (*Limiter, error) {
+	limiter := &Limiter{store: store, policies: make(map[string][]Policy)}
+	for _, s := range policies {
+		policy, err := ParsePolicy(s)
+		if err != nil {
+			return nil, err
+		}
+		limiter.policies[policy.Group] = append(limiter.policies[policy.Group], policy)
+	}
+	return limiter, nil
+}
+
+// Policies возвращает правила группы маршрутов
+func (l *Limiter) Policies(group string) []Policy {
+	return l.policies[group]
+}
+
+// Take забирает токен из корзины ключа key по правилу policy
+func (l *Limiter) Take(ctx context.Context, policy Policy, key string) (Result, error) {
+	return l.store.Take(ctx, policy.Group+":"+string(policy.Key)+":"+key, policy.Limit())
+}
+
+// bucket - состояние корзины: tokens токенов на момент updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take пополняет корзину на момент now и забирает из нее токен, если он есть
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.updated = now
+	}
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(limit, b.tokens, allowed)
+}
+
+// newResult описывает корзину с tokens токенами после попытки взять токен
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
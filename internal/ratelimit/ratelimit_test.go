@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("users.write:ip:30/1m:10")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Group: "users.write", Key: KeyIP, Requests: 30, Period: time.Minute, Burst: 10}, policy)
+	assert.Equal(t, Limit{Rate: 0.5, Burst: 10}, policy.Limit())
+	assert.Equal(t, "30;w=60;burst=10", policy.Header())
+
+	policy, err = ParsePolicy("login:user:5/1s")
+	require.NoError(t, err)
+	assert.Equal(t, 5, policy.Burst)
+
+	for _, invalid := range []string{"", "api:ip", ":ip:1/1s", "api:session:1/1s", "api:ip:0/1s", "api:ip:1/0s", "api:ip:1", "api:ip:1/1s:0", "api:ip:1/1s:2:3"} {
+		_, err := ParsePolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// testClock - управляемые часы хранилища
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// runStoreTests проверяет алгоритм token bucket на реализации хранилища
+func runStoreTests(t *testing.T, store Store, clock *testClock) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 3}
+
+	// Полная корзина пропускает всплеск из Burst запросов
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// Корзина пополняется со скоростью Rate
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	result, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Корзины разных ключей независимы
+	result, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Корзина не переполняется
+	clock.now = clock.now.Add(time.Hour)
+	result, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryStore(t *testing.T) {
+	clock := &testClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+
+	runStoreTests(t, store, clock)
+
+	// Наполнившиеся корзины удаляются
+	clock.now = clock.now.Add(time.Hour)
+	_, err := store.Take(context.Background(), "new", Limit{Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	clock := &testClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewRedis(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	store.now = clock.Now
+
+	runStoreTests(t, store, clock)
+
+	// Ключ истекает, когда корзина наполнится
+	assert.True(t, server.Exists("test:client"))
+	server.FastForward(time.Hour)
+	assert.False(t, server.Exists("test:client"))
+}
+
+func TestLimiter(t *testing.T) {
+	_, err := New(NewMemoryStore(), []string{"api:ip:1/1s", "api:bad"})
+	assert.Error(t, err)
+
+	limiter, err := New(NewMemoryStore(), []string{"api:ip:1/1s", "api:user:5/1s", "login:ip:1/1m"})
+	require.NoError(t, err)
+	assert.Len(t, limiter.Policies("api"), 2)
+	assert.Empty(t, limiter.Policies("web"))
+
+	// Ключи разных правил не пересекаются
+	login := limiter.Policies("login")[0]
+	api := limiter.Policies("api")[0]
+	result, err := limiter.Take(context.Background(), login, "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Take(context.Background(), api, "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
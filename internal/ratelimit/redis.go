@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Убедимся что Redis реализует интерфейс Store
+var _ Store = (*Redis)(nil)
+
+// takeScript атомарно пополняет корзину и забирает из нее токен. Корзина - хеш с полями tokens и ts
+// (время обновления в миллисекундах); ключ удаляется, когда корзина наполнится.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) / 1000 * rate)
+	ts = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// Redis хранит корзины в Redis, общем для всех экземпляров сервиса. Время берется с часов экземпляра,
+// поэтому часы экземпляров должны быть синхронизированы.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+
+	now func() time.Time
+}
+
+// NewRedis создает новый экземпляр Redis; prefix добавляется ко всем ключам
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix, now: time.Now}
+}
+
+// Take забирает токен из корзины key
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := takeScript.Run(ctx, r.client, []string{r.prefix + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst, r.now().UnixMilli()).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := reply[0].(int64)
+	value, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit script reply %v: %w", reply, err)
+	}
+	return newResult(limit, tokens, allowed == 1), nil
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store - хранилище кеша с ограниченным временем жизни записей
+type Store interface {
+	// Get возвращает значение по ключу; ok равен false, если записи нет или ее время жизни истекло
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set сохраняет значение на время ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete удаляет записи по ключам
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что UserRepository реализует интерфейс repository.UserRepository
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// Параметры кеша по умолчанию
+const (
+	DefaultTTL         = time.Minute
+	DefaultNegativeTTL = 10 * time.Second
+)
+
+// notFound - значение записи, которая кеширует отсутствие пользователя
+var notFound = []byte("!")
+
+// recentlyWritten - значение записи, которая заменяет сброшенную на время отставания реплик (см. WithReplicaLag)
+var recentlyWritten = []byte("~")
+
+// Stats - счетчики обращений к кешу
+type Stats struct {
+	Hits         uint64 `json:"hits"`
+	NegativeHits uint64 `json:"negative_hits"`
+	Misses       uint64 `json:"misses"`
+	Errors       uint64 `json:"errors"`
+}
+
+// UserRepository - декоратор репозитория пользователей, кеширующий GetByID и GetByEmail.
+// Остальные методы передаются обернутому репозиторию без изменений.
+//
+// Хеш пароля в кеш не попадает: пользователь из кеша возвращается с пустым Password. Чтобы получить
+// пароль, читайте в контексте repository.WithPrimaryReads.
+//
+// Чтения, которые должны выполняться на основной базе (repository.ReadsFromPrimary): внутри транзакции,
+// после записи в области согласованности или в контексте WithPrimaryReads - идут мимо кеша, чтобы в кеш
+// не попали незафиксированные данные. Записи инвалидируются после фиксации транзакции. Запись, прочитанная
+// из БД одновременно с изменением, может остаться в кеше устаревшей, но не дольше ttl.
+type UserRepository struct {
+	repository.UserRepository
+
+	store       Store
+	ttl         time.Duration
+	negativeTTL time.Duration
+	replicaLag  time.Duration
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	errors       atomic.Uint64
+}
+
+// NewUserRepository создает новый экземпляр UserRepository поверх next.
+// При negativeTTL <= 0 отсутствие пользователя не кешируется.
+func NewUserRepository(next repository.UserRepository, store Store, ttl, negativeTTL time.Duration) *UserRepository {
+	return &UserRepository{
+		UserRepository: next,
+		store:          store,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+	}
+}
+
+// WithReplicaLag включает защиту от устаревших данных реплик: после изменения пользователя его записи
+// в течение lag заменены отметкой, и прочитанное за это время (возможно, с отстающей реплики) не кешируется
+func (r *UserRepository) WithReplicaLag(lag time.Duration) *UserRepository {
+	r.replicaLag = lag
+	return r
+}
+
+// cachedUser - представление пользователя в кеше. Хеш пароля не сохраняется: учетные данные
+// не должны попадать в общее хранилище вроде Redis.
+type cachedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Stats возвращает текущие значения счетчиков
+func (r *UserRepository) Stats() Stats {
+	return Stats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+		Errors:       r.errors.Load(),
+	}
+}
+
+// GetByID получает пользователя по ID из кеша или из обернутого репозитория
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if repository.ReadsFromPrimary(ctx) {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+
+	key := idKey(id)
+	user, cached, recent := r.lookup(ctx, key)
+	if cached {
+		if user == nil {
+			r.negativeHits.Add(1)
+			return nil, repository.ErrUserNotFound
+		}
+		r.hits.Add(1)
+		return user, nil
+	}
+
+	r.misses.Add(1)
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if recent {
+		return user, err
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		r.setNotFound(ctx, key)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.setUser(ctx, user)
+	return user, nil
+}
+
+// GetByEmail получает пользователя по email из кеша или из обернутого репозитория.
+// В кеше email указывает на ID пользователя, а сам пользователь хранится под ключом ID.
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if repository.ReadsFromPrimary(ctx) {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+
+	normalized := models.NormalizeEmail(email)
+	key := emailKey(normalized)
+	value, ok := r.get(ctx, key)
+	recent := ok && isRecentlyWritten(value)
+	if ok && isNotFound(value) {
+		r.negativeHits.Add(1)
+		return nil, repository.ErrUserNotFound
+	}
+	checkedID := false
+	if ok && !recent {
+		// Email мог смениться или перейти к другому пользователю после того, как ссылка попала в кеш
+		if id, err := uuid.ParseBytes(value); err == nil {
+			user, cached, idRecent := r.lookup(ctx, idKey(id))
+			if cached && user != nil && models.NormalizeEmail(user.Email) == normalized {
+				r.hits.Add(1)
+				return user, nil
+			}
+			recent, checkedID = idRecent, true
+		}
+	}
+
+	r.misses.Add(1)
+	user, err := r.UserRepository.GetByEmail(ctx, email)
+	if recent {
+		return user, err
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		r.setNotFound(ctx, key)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !checkedID && r.replicaLag > 0 {
+		// Ссылки по email не было, но сам пользователь мог недавно измениться
+		if _, _, recent := r.lookup(ctx, idKey(user.ID)); recent {
+			return user, nil
+		}
+	}
+
+	r.setUser(ctx, user)
+	r.set(ctx, key, []byte(user.ID.String()), r.ttl)
+	return user, nil
+}
+
+// Create создает пользователя и сбрасывает закешированное отсутствие его ID и email
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.invalidate(ctx, idKey(user.ID), emailKey(models.NormalizeEmail(user.Email)))
+	return nil
+}
+
+// Update обновляет пользователя и сбрасывает его записи в кеше.
+// Ссылка со старого email проверяется при чтении, поэтому ее удалять не нужно.
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	if err := r.UserRepository.Update(ctx, user); err != nil {
+		return err
+	}
+	r.invalidate(ctx, idKey(user.ID), emailKey(models.NormalizeEmail(user.Email)))
+	return nil
+}
+
+// Delete удаляет пользователя и сбрасывает его запись в кеше
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, idKey(id))
+	return nil
+}
+
+// lookup читает пользователя из кеша; cached равен true и для закешированного отсутствия (user == nil),
+// recent - если пользователь недавно изменился и прочитанное из БД кешировать нельзя
+func (r *UserRepository) lookup(ctx context.Context, key string) (user *models.User, cached, recent bool) {
+	value, ok := r.get(ctx, key)
+	if !ok {
+		return nil, false, false
+	}
+	if isRecentlyWritten(value) {
+		return nil, false, true
+	}
+	if isNotFound(value) {
+		return nil, true, false
+	}
+
+	var entry cachedUser
+	if err := json.Unmarshal(value, &entry); err != nil {
+		r.fail("decode", err)
+		return nil, false, false
+	}
+	return &models.User{
+		ID:        entry.ID,
+		Email:     entry.Email,
+		FirstName: entry.FirstName,
+		LastName:  entry.LastName,
+		Role:      entry.Role,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}, true, false
+}
+
+func (r *UserRepository) setUser(ctx context.Context, user *models.User) {
+	value, err := json.Marshal(cachedUser{
+		ID:        user.ID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	})
+	if err != nil {
+		r.fail("encode", err)
+		return
+	}
+	r.set(ctx, idKey(user.ID), value, r.ttl)
+}
+
+func (r *UserRepository) setNotFound(ctx context.Context, key string) {
+	if r.negativeTTL > 0 {
+		r.set(ctx, key, notFound, r.negativeTTL)
+	}
+}
+
+// invalidate удаляет ключи после фиксации текущей транзакции, а при заданном replicaLag заменяет их
+// отметкой recentlyWritten: иначе чтение с отстающей реплики сразу вернуло бы в кеш старые данные.
+// До фиксации в кеше остаются последние зафиксированные данные, которые и должны видеть другие запросы.
+func (r *UserRepository) invalidate(ctx context.Context, keys ...string) {
+	ctx = context.WithoutCancel(ctx)
+	repository.AfterCommit(ctx, func() {
+		if r.replicaLag <= 0 {
+			if err := r.store.Delete(ctx, keys...); err != nil {
+				r.fail("delete", err)
+			}
+			return
+		}
+		for _, key := range keys {
+			r.set(ctx, key, recentlyWritten, r.replicaLag)
+		}
+	})
+}
+
+// get и set не возвращают ошибки хранилища: недоступный кеш не должен ломать запросы
+func (r *UserRepository) get(ctx context.Context, key string) ([]byte, bool) {
+	value, ok, err := r.store.Get(ctx, key)
+	if err != nil {
+		r.fail("get", err)
+		return nil, false
+	}
+	return value, ok
+}
+
+func (r *UserRepository) set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := r.store.Set(ctx, key, value, ttl); err != nil {
+		r.fail("set", err)
+	}
+}
+
+func (r *UserRepository) fail(op string, err error) {
+	r.errors.Add(1)
+	log.Printf("User cache %s error: %v", op, err)
+}
+
+func isNotFound(value []byte) bool {
+	return string(value) == string(notFound)
+}
+
+func isRecentlyWritten(value []byte) bool {
+	return string(value) == string(recentlyWritten)
+}
+
+func idKey(id uuid.UUID) string {
+	return "user:id:" + id.String()
+}
+
+func emailKey(email string) string {
+	return "user:email:" + email
+}
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/repository/repotest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository хранит пользователей в памяти и считает обращения к GetByID и GetByEmail
+type countingRepository struct {
+	repository.UserRepository
+	users  map[uuid.UUID]*models.User
+	byID   int
+	byMail int
+}
+
+func newCountingRepository() *countingRepository {
+	return &countingRepository{users: make(map[uuid.UUID]*models.User)}
+}
+
+func (f *countingRepository) Create(ctx context.Context, user *models.User) error {
+	user.ID = uuid.New()
+	copied := *user
+	f.users[user.ID] = &copied
+	return nil
+}
+
+func (f *countingRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	f.byID++
+	user, ok := f.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *countingRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	f.byMail++
+	for _, user := range f.users {
+		if user.Email == models.NormalizeEmail(email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (f *countingRepository) Update(ctx context.Context, user *models.User) error {
+	copied := *user
+	f.users[user.ID] = &copied
+	return nil
+}
+
+func (f *countingRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f.users, id)
+	return nil
+}
+
+// stores возвращает хранилища, на которых проверяется декоратор
+func stores(t *testing.T) map[string]Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return map[string]Store{
+		"lru":   NewLRU(100),
+		"redis": NewRedis(client, "test:"),
+	}
+}
+
+func TestUserRepository_Conformance(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepository {
+		return NewUserRepository(memory.NewUserRepository(memory.NewDB()), NewLRU(100), time.Minute, time.Minute)
+	})
+}
+
+func TestUserRepository_GetByID(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			next := newCountingRepository()
+			repo := NewUserRepository(next, store, time.Minute, time.Minute)
+			user := &models.User{Email: "alice@example.com", FirstName: "Alice", Password: "hash"}
+			require.NoError(t, repo.Create(ctx, user))
+
+			first, err := repo.GetByID(ctx, user.ID)
+			require.NoError(t, err)
+			second, err := repo.GetByID(ctx, user.ID)
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, next.byID)
+			assert.Equal(t, "hash", first.Password)
+			first.Password = ""
+			assert.Equal(t, first, second)
+			assert.Equal(t, Stats{Hits: 1, Misses: 1}, repo.Stats())
+
+			// Хеш пароля в хранилище не попадает
+			value, ok, err := store.Get(ctx, idKey(user.ID))
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.NotContains(t, string(value), "hash")
+
+			// Изменение сбрасывает запись
+			second.FirstName = "Alicia"
+			require.NoError(t, repo.Update(ctx, second))
+			updated, err := repo.GetByID(ctx, user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "Alicia", updated.FirstName)
+			assert.Equal(t, 2, next.byID)
+
+			// Удаление сбрасывает запись
+			require.NoError(t, repo.Delete(ctx, user.ID))
+			_, err = repo.GetByID(ctx, user.ID)
+			assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		})
+	}
+}
+
+func TestUserRepository_NegativeCaching(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			next := newCountingRepository()
+			repo := NewUserRepository(next, store, time.Minute, time.Minute)
+
+			for i := 0; i < 3; i++ {
+				_, err := repo.GetByEmail(ctx, "bob@example.com")
+				assert.ErrorIs(t, err, repository.ErrUserNotFound)
+			}
+			assert.Equal(t, 1, next.byMail)
+			assert.Equal(t, Stats{NegativeHits: 2, Misses: 1}, repo.Stats())
+
+			// Создание пользователя с этим email сбрасывает закешированное отсутствие
+			require.NoError(t, repo.Create(ctx, &models.User{Email: "bob@example.com"}))
+			user, err := repo.GetByEmail(ctx, "Bob@Example.com")
+			require.NoError(t, err)
+			assert.Equal(t, "bob@example.com", user.Email)
+		})
+	}
+}
+
+func TestUserRepository_GetByEmail_FollowsEmailChange(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			next := newCountingRepository()
+			repo := NewUserRepository(next, store, time.Minute, time.Minute)
+			user := &models.User{Email: "carol@example.com"}
+			require.NoError(t, repo.Create(ctx, user))
+
+			cached, err := repo.GetByEmail(ctx, "carol@example.com")
+			require.NoError(t, err)
+			_, err = repo.GetByEmail(ctx, "carol@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, 1, next.byMail)
+
+			cached.Email = "carol@example.org"
+			require.NoError(t, repo.Update(ctx, cached))
+
+			_, err = repo.GetByEmail(ctx, "carol@example.com")
+			assert.ErrorIs(t, err, repository.ErrUserNotFound, "stale email reference must not resolve")
+			moved, err := repo.GetByEmail(ctx, "carol@example.org")
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, moved.ID)
+		})
+	}
+}
+
+func TestUserRepository_BypassesCacheInTransaction(t *testing.T) {
+	ctx, commit := repository.WithCommitHooks(context.Background())
+	next := newCountingRepository()
+	store := NewLRU(100)
+	repo := NewUserRepository(next, store, time.Minute, time.Minute)
+	user := &models.User{Email: "dave@example.com"}
+	require.NoError(t, next.Create(ctx, user))
+
+	// Прочитанное в транзакции не кешируется
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, store.Len())
+
+	// Закешированная вне транзакции запись сбрасывается только после фиксации
+	_, err = repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	assert.Equal(t, 1, store.Len())
+
+	commit()
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestUserRepository_BypassesCacheForPrimaryReads(t *testing.T) {
+	next := newCountingRepository()
+	store := NewLRU(100)
+	repo := NewUserRepository(next, store, time.Minute, time.Minute)
+	user := &models.User{Email: "frank@example.com", Password: "hash"}
+	require.NoError(t, next.Create(context.Background(), user))
+
+	_, err := repo.GetByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
+
+	// Чтение с основной базы возвращает хеш пароля, даже если пользователь есть в кеше
+	found, err := repo.GetByEmail(repository.WithPrimaryReads(context.Background()), user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", found.Password)
+	assert.Equal(t, 2, next.byMail)
+}
+
+func TestUserRepository_ReplicaLag(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			next := newCountingRepository()
+			repo := NewUserRepository(next, store, time.Minute, time.Minute).WithReplicaLag(time.Minute)
+			user := &models.User{Email: "grace@example.com", FirstName: "Grace"}
+			require.NoError(t, repo.Create(ctx, user))
+
+			// После записи прочитанное не кешируется: оно могло прийти с отстающей реплики
+			for i := 0; i < 2; i++ {
+				_, err := repo.GetByID(ctx, user.ID)
+				require.NoError(t, err)
+				_, err = repo.GetByEmail(ctx, user.Email)
+				require.NoError(t, err)
+			}
+			assert.Equal(t, 2, next.byID)
+			assert.Equal(t, 2, next.byMail)
+
+			// Отсутствие пользователя в этот период тоже не кешируется
+			require.NoError(t, repo.Delete(ctx, user.ID))
+			_, err := repo.GetByID(ctx, user.ID)
+			assert.ErrorIs(t, err, repository.ErrUserNotFound)
+			value, ok, err := store.Get(ctx, idKey(user.ID))
+			require.NoError(t, err)
+			assert.True(t, ok && isRecentlyWritten(value))
+		})
+	}
+}
+
+func TestUserRepository_SurvivesStoreFailure(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	defer client.Close()
+
+	ctx := context.Background()
+	next := newCountingRepository()
+	repo := NewUserRepository(next, NewRedis(client, "test:"), time.Minute, time.Minute)
+	user := &models.User{Email: "erin@example.com"}
+	require.NoError(t, next.Create(ctx, user))
+
+	server.Close()
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, uint64(2), repo.Stats().Errors)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// commitHooksKey - ключ контекста, под которым хранятся отложенные до фиксации транзакции функции
+type commitHooksKey struct{}
+
+type commitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// WithCommitHooks возвращает контекст транзакции и функцию, которую реализация Transactor
+// вызывает после успешной фиксации внешней транзакции. Если в ctx уже есть транзакция,
+// функции регистрируются в ней, а возвращаемая функция ничего не делает.
+func WithCommitHooks(ctx context.Context) (context.Context, func()) {
+	if InTransaction(ctx) {
+		return ctx, func() {}
+	}
+
+	hooks := &commitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), func() {
+		hooks.mu.Lock()
+		fns := hooks.fns
+		hooks.fns = nil
+		hooks.mu.Unlock()
+
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// InTransaction сообщает, выполняется ли ctx внутри транзакции
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(commitHooksKey{}).(*commitHooks)
+	return ok
+}
+
+// AfterCommit выполняет fn после фиксации текущей транзакции или сразу, если транзакции нет.
+// При откате транзакции fn не выполняется.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
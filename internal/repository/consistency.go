@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// readYourWritesKey - ключ контекста, под которым хранится признак записи в области согласованности
+type readYourWritesKey struct{}
+
+// primaryReadsKey - ключ контекста, под которым хранится требование читать с основной базы
+type primaryReadsKey struct{}
+
+// WithReadYourWrites открывает область согласованности (обычно - один HTTP-запрос): после первой записи
+// в этой области все чтения выполняются на основной базе, а не на репликах, которые могут отставать
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, readYourWritesKey{}, new(atomic.Bool))
+}
+
+// MarkWritten отмечает, что в области согласованности ctx была запись; без области ничего не делает
+func MarkWritten(ctx context.Context) {
+	if written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// WithPrimaryReads направляет все чтения в ctx на основную базу мимо кеша. Используется там, где нужны
+// данные, которых нет в кеше (хеш пароля), или где устаревшие данные недопустимы.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// ReadsFromPrimary сообщает, должны ли чтения в ctx выполняться на основной базе:
+// внутри транзакции, в контексте WithPrimaryReads или после записи в той же области согласованности
+func ReadsFromPrimary(ctx context.Context) bool {
+	if InTransaction(ctx) {
+		return true
+	}
+	if primary, _ := ctx.Value(primaryReadsKey{}).(bool); primary {
+		return true
+	}
+	written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool)
+	return ok && written.Load()
+}
@@ -0,0 +1,27 @@
+package repository
+
+import "errors"
+
+// Ошибки хранилища, не зависящие от конкретной реализации
+var (
+	// ErrUserNotFound возвращается, когда пользователь не найден
+	ErrUserNotFound = errors.New("user not found")
+	// ErrWebhookNotFound возвращается, когда подписка или доставка вебхука не найдена
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrSessionNotFound возвращается, когда сессия не найдена
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrAPIKeyNotFound возвращается, когда API-ключ не найден
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrIdentityNotFound возвращается, когда учетная запись внешнего поставщика не привязана
+	ErrIdentityNotFound = errors.New("identity not found")
+	// ErrIdentityAlreadyLinked возвращается, когда учетная запись поставщика уже привязана к пользователю
+	ErrIdentityAlreadyLinked = errors.New("identity already linked")
+	// ErrOAuthClientNotFound возвращается, когда клиент сервера авторизации не найден
+	ErrOAuthClientNotFound = errors.New("oauth client not found")
+	// ErrOAuthCodeNotFound возвращается, когда код авторизации не найден или уже использован
+	ErrOAuthCodeNotFound = errors.New("oauth authorization code not found")
+	// ErrOAuthTokenNotFound возвращается, когда токен доступа не найден
+	ErrOAuthTokenNotFound = errors.New("oauth token not found")
+	// ErrEmailAlreadyExists возвращается, когда запись нарушает уникальность email
+	ErrEmailAlreadyExists = errors.New("email already exists")
+)
@@ -1,16 +1,137 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/Est1ege/go-user-api/internal/domain/models"
 )
 
 // UserRepository определяет интерфейс для работы с хранилищем пользователей
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id uuid.UUID) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	Update(user *models.User) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetByIDForUpdate получает пользователя в текущей транзакции на основной базе и блокирует его запись
+	// до конца транзакции, чтобы параллельное изменение не было потеряно при перезаписи (Update)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetAll(ctx context.Context) ([]*models.User, error)
+	List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error)
+	// FindInBatches последовательно передает в fn пользователей, подходящих под фильтр,
+	// порциями не более batchSize записей, не загружая всю таблицу в память
+	FindInBatches(ctx context.Context, filter models.UserFilter, batchSize int, fn func(users []*models.User) error) error
+	// Search ищет пользователей по email, имени и фамилии (по префиксу, без учета регистра
+	// и с допуском опечаток) и возвращает не более limit результатов по убыванию релевантности
+	Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error)
+}
+
+// Transactor выполняет функцию в рамках одной транзакции хранилища.
+// Транзакция передается через ctx: все вызовы репозиториев с этим ctx выполняются в ней.
+// Вложенный вызов создает точку сохранения, откат которой не отменяет внешнюю транзакцию.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AuditRepository определяет интерфейс журнала аудита. Записи только добавляются.
+type AuditRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	// ListByUserID возвращает события пользователя от новых к старым и их общее количество
+	ListByUserID(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error)
+}
+
+// OutboxRepository определяет интерфейс хранилища исходящих доменных событий
+type OutboxRepository interface {
+	Create(ctx context.Context, event *models.OutboxEvent) error
+	// FetchPending возвращает неопубликованные события, время попытки которых подошло, в порядке их записи.
+	// События пользователя, у которого есть более раннее неопубликованное событие с еще не подошедшим
+	// временем попытки, не возвращаются: они ждут его публикации.
+	FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error)
+	// Schedule назначает событиям время следующей попытки публикации
+	Schedule(ctx context.Context, ids []uint64, at time.Time) error
+	MarkPublished(ctx context.Context, id uint64) error
+	// MarkFailed сохраняет причину неудачной публикации и время следующей попытки
+	MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error
+	// MarkDead переводит событие в dead letter: оно больше не публикуется
+	MarkDead(ctx context.Context, id uint64, reason string) error
+	// TryLock захватывает блокировку выборки событий до конца текущей транзакции,
+	// чтобы события не захватили одновременно несколько экземпляров сервиса. Возвращает false, если блокировка занята.
+	TryLock(ctx context.Context) (bool, error)
+}
+
+// WebhookRepository определяет интерфейс хранилища подписок и доставок вебхуков
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+	// ListDeliveries возвращает доставки подписки от новых к старым и их общее количество
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error)
+	// ClaimDueDeliveries блокирует до конца транзакции и возвращает доставки, которые пора отправить;
+	// доставки, заблокированные другими экземплярами сервиса, пропускаются
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// SessionRepository определяет интерфейс хранилища сессий веб-интерфейса
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	Update(ctx context.Context, session *models.Session) error
+	Delete(ctx context.Context, id string) error
+	// ListByUserID возвращает сессии пользователя, действующие в момент now, начиная с последней активной
+	ListByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Session, error)
+	// DeleteByUserID удаляет все сессии пользователя и возвращает их количество
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	// DeleteExpired удаляет сессии, истекшие к моменту now, и возвращает их количество
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// APIKeyRepository определяет интерфейс хранилища API-ключей
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	// List возвращает ключи от новых к старым; если userID не nil - только ключи этого пользователя
+	List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error)
+	// Revoke отмечает ключ отозванным в момент at; повторный отзыв не меняет время
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+	// TouchLastUsed сохраняет время последнего использования ключа
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// IdentityRepository определяет интерфейс хранилища учетных записей внешних поставщиков удостоверений
+type IdentityRepository interface {
+	// Create привязывает учетную запись; если пара провайдер и subject уже привязана - ErrIdentityAlreadyLinked
+	Create(ctx context.Context, identity *models.Identity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.Identity, error)
+	// ListByUserID возвращает учетные записи пользователя в порядке привязки
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// OAuthRepository определяет интерфейс хранилища сервера авторизации: клиентов, кодов авторизации и токенов доступа
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	GetClient(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error)
+	// ListClients возвращает клиентов в порядке регистрации
+	ListClients(ctx context.Context) ([]*models.OAuthClient, error)
+	// DeleteClient удаляет клиента вместе с его кодами авторизации и токенами
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+
+	CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error
+	// ConsumeCode удаляет код и возвращает его; код можно получить только один раз, затем - ErrOAuthCodeNotFound
+	ConsumeCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error)
+
+	CreateToken(ctx context.Context, token *models.OAuthToken) error
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.OAuthToken, error)
+	// RevokeToken отмечает токен отозванным в момент at; повторный отзыв не меняет время
+	RevokeToken(ctx context.Context, id uuid.UUID, at time.Time) error
+	// DeleteExpired удаляет коды и токены, истекшие к моменту now, и возвращает их количество
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
 }
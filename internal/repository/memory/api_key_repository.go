@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что APIKeyRepository реализует интерфейс repository.APIKeyRepository
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// APIKeyRepository представляет хранилище API-ключей в памяти
+type APIKeyRepository struct {
+	db *DB
+}
+
+// NewAPIKeyRepository создает новый экземпляр APIKeyRepository
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create создает ключ
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	defer r.db.lock(ctx)()
+
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	put(r.db, r.db.data.apiKeys, key.ID, copyAPIKey(key))
+	return nil
+}
+
+// GetByID получает ключ по ID
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	defer r.db.lock(ctx)()
+
+	key, ok := r.db.data.apiKeys[id]
+	if !ok {
+		return nil, repository.ErrAPIKeyNotFound
+	}
+	copied := copyAPIKey(&key)
+	return &copied, nil
+}
+
+// GetByPrefix получает ключ по видимому префиксу
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	defer r.db.lock(ctx)()
+
+	for _, key := range r.db.data.apiKeys {
+		if key.Prefix == prefix {
+			copied := copyAPIKey(&key)
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+// List получает ключи от новых к старым, при userID не nil - только ключи пользователя
+func (r *APIKeyRepository) List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error) {
+	defer r.db.lock(ctx)()
+
+	var keys []*models.APIKey
+	for _, key := range r.db.data.apiKeys {
+		if userID != nil && (key.UserID == nil || *key.UserID != *userID) {
+			continue
+		}
+		copied := copyAPIKey(&key)
+		keys = append(keys, &copied)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID.String() < keys[j].ID.String()
+	})
+	return keys, nil
+}
+
+// Revoke отзывает ключ
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer r.db.lock(ctx)()
+
+	key, ok := r.db.data.apiKeys[id]
+	if !ok {
+		return repository.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		put(r.db, r.db.data.apiKeys, id, key)
+	}
+	return nil
+}
+
+// TouchLastUsed сохраняет время последнего использования ключа
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer r.db.lock(ctx)()
+
+	key, ok := r.db.data.apiKeys[id]
+	if !ok {
+		return repository.ErrAPIKeyNotFound
+	}
+	key.LastUsedAt = &at
+	put(r.db, r.db.data.apiKeys, id, key)
+	return nil
+}
+
+// copyAPIKey копирует ключ вместе с областями действия и сроками, чтобы вызывающий не изменял хранилище
+func copyAPIKey(key *models.APIKey) models.APIKey {
+	copied := *key
+	copied.Scopes = append([]string(nil), key.Scopes...)
+	copied.UserID = copyPtr(key.UserID)
+	copied.ExpiresAt = copyPtr(key.ExpiresAt)
+	copied.LastUsedAt = copyPtr(key.LastUsedAt)
+	copied.RevokedAt = copyPtr(key.RevokedAt)
+	return copied
+}
+
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что AuditRepository реализует интерфейс repository.AuditRepository
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+// AuditRepository представляет журнал аудита в памяти
+type AuditRepository struct {
+	db *DB
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create добавляет событие в журнал
+func (r *AuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	defer r.db.lock(ctx)()
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	replace(r.db, &r.db.data.auditEvents, append(r.db.data.auditEvents, *event))
+	return nil
+}
+
+// ListByUserID получает страницу событий пользователя от новых к старым
+func (r *AuditRepository) ListByUserID(ctx context.Context, userID uuid.UUID, p models.Page) ([]*models.AuditEvent, int64, error) {
+	defer r.db.lock(ctx)()
+
+	var events []*models.AuditEvent
+	for _, event := range r.db.data.auditEvents {
+		if event.UserID == userID {
+			copied := event
+			events = append(events, &copied)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.After(events[j].CreatedAt)
+		}
+		return events[i].ID.String() < events[j].ID.String()
+	})
+
+	start, end := page(len(events), p)
+	return events[start:end], int64(len(events)), nil
+}
@@ -0,0 +1,134 @@
+// Package memory содержит потокобезопасные реализации репозиториев в памяти процесса
+// для тестов и локального запуска без PostgreSQL. Данные теряются при остановке сервиса.
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// DB - общее хранилище репозиториев в памяти.
+// Транзакции выполняются последовательно: транзакция удерживает хранилище до фиксации или отката,
+// а операции вне транзакции ждут ее завершения, поэтому незафиксированные данные никому не видны.
+//
+// Для отката транзакция ведет журнал: каждое изменение через put, putAt, remove и replace запоминает
+// прежнее значение, поэтому стоимость транзакции зависит от числа изменений, а не от размера хранилища.
+type DB struct {
+	mu   sync.Mutex
+	data *state
+
+	// inTx и undo относятся к выполняющейся транзакции; undo - функции, восстанавливающие прежние значения
+	inTx bool
+	undo []func()
+}
+
+// state - содержимое хранилища. Записи хранятся по значению и изменяются только через put, putAt,
+// remove и replace, чтобы изменение попало в журнал отката.
+type state struct {
+	users         map[uuid.UUID]models.User
+	auditEvents   []models.AuditEvent
+	outboxEvents  []models.OutboxEvent
+	subscriptions map[uuid.UUID]models.WebhookSubscription
+	deliveries    map[uuid.UUID]models.WebhookDelivery
+	sessions      map[string]models.Session
+	apiKeys       map[uuid.UUID]models.APIKey
+	identities    map[uuid.UUID]models.Identity
+	oauthClients  map[uuid.UUID]models.OAuthClient
+	oauthCodes    map[string]models.OAuthAuthorizationCode
+	oauthTokens   map[uuid.UUID]models.OAuthToken
+}
+
+// NewDB создает новое пустое хранилище
+func NewDB() *DB {
+	return &DB{data: &state{
+		users:         make(map[uuid.UUID]models.User),
+		subscriptions: make(map[uuid.UUID]models.WebhookSubscription),
+		deliveries:    make(map[uuid.UUID]models.WebhookDelivery),
+		sessions:      make(map[string]models.Session),
+		apiKeys:       make(map[uuid.UUID]models.APIKey),
+		identities:    make(map[uuid.UUID]models.Identity),
+		oauthClients:  make(map[uuid.UUID]models.OAuthClient),
+		oauthCodes:    make(map[string]models.OAuthAuthorizationCode),
+		oauthTokens:   make(map[uuid.UUID]models.OAuthToken),
+	}}
+}
+
+// txKey - ключ контекста, под которым хранится хранилище текущей транзакции
+type txKey struct{}
+
+// lock захватывает хранилище на время операции; внутри транзакции хранилище уже захвачено ею
+func (db *DB) lock(ctx context.Context) func() {
+	if ctx.Value(txKey{}) == any(db) {
+		return func() {}
+	}
+	db.mu.Lock()
+	return db.mu.Unlock
+}
+
+// rollbackTo отменяет изменения, записанные в журнал после отметки mark, в обратном порядке
+func (db *DB) rollbackTo(mark int) {
+	for i := len(db.undo) - 1; i >= mark; i-- {
+		db.undo[i]()
+	}
+	db.undo = db.undo[:mark]
+}
+
+// remember добавляет в журнал отката функцию undo, если выполняется транзакция
+func (db *DB) remember(undo func()) {
+	if db.inTx {
+		db.undo = append(db.undo, undo)
+	}
+}
+
+// put записывает value в таблицу m по ключу key
+func put[K comparable, V any](db *DB, m map[K]V, key K, value V) {
+	old, existed := m[key]
+	db.remember(func() {
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+	m[key] = value
+}
+
+// remove удаляет из таблицы m запись по ключу key
+func remove[K comparable, V any](db *DB, m map[K]V, key K) {
+	old, existed := m[key]
+	if !existed {
+		return
+	}
+	db.remember(func() { m[key] = old })
+	delete(m, key)
+}
+
+// putAt заменяет элемент i списка items
+func putAt[T any](db *DB, items []T, i int, value T) {
+	old := items[i]
+	db.remember(func() { items[i] = old })
+	items[i] = value
+}
+
+// replace заменяет список *items на value, например после добавления элемента
+func replace[T any](db *DB, items *[]T, value []T) {
+	old := *items
+	db.remember(func() { *items = old })
+	*items = value
+}
+
+// page применяет смещение и лимит к количеству записей n и возвращает границы среза
+func page(n int, p models.Page) (int, int) {
+	start := p.Offset
+	if start > n {
+		start = n
+	}
+	end := n
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+	}
+	return start, end
+}
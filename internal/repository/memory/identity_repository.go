@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что IdentityRepository реализует интерфейс repository.IdentityRepository
+var _ repository.IdentityRepository = (*IdentityRepository)(nil)
+
+// IdentityRepository представляет хранилище учетных записей внешних поставщиков в памяти
+type IdentityRepository struct {
+	db *DB
+}
+
+// NewIdentityRepository создает новый экземпляр IdentityRepository
+func NewIdentityRepository(db *DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// Create привязывает учетную запись поставщика к пользователю
+func (r *IdentityRepository) Create(ctx context.Context, identity *models.Identity) error {
+	defer r.db.lock(ctx)()
+
+	for _, existing := range r.db.data.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return repository.ErrIdentityAlreadyLinked
+		}
+	}
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+	put(r.db, r.db.data.identities, identity.ID, *identity)
+	return nil
+}
+
+// GetByProviderSubject получает учетную запись по поставщику и идентификатору пользователя у него
+func (r *IdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	defer r.db.lock(ctx)()
+
+	for _, identity := range r.db.data.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, repository.ErrIdentityNotFound
+}
+
+// ListByUserID получает учетные записи пользователя в порядке привязки
+func (r *IdentityRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error) {
+	defer r.db.lock(ctx)()
+
+	var identities []*models.Identity
+	for _, identity := range r.db.data.identities {
+		if identity.UserID == userID {
+			identity := identity
+			identities = append(identities, &identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if !identities[i].CreatedAt.Equal(identities[j].CreatedAt) {
+			return identities[i].CreatedAt.Before(identities[j].CreatedAt)
+		}
+		return identities[i].ID.String() < identities[j].ID.String()
+	})
+	return identities, nil
+}
+
+// Delete отвязывает учетную запись
+func (r *IdentityRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.data.identities[id]; !ok {
+		return repository.ErrIdentityNotFound
+	}
+	remove(r.db, r.db.data.identities, id)
+	return nil
+}
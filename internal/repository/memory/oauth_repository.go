@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что OAuthRepository реализует интерфейс repository.OAuthRepository
+var _ repository.OAuthRepository = (*OAuthRepository)(nil)
+
+// OAuthRepository представляет хранилище сервера авторизации в памяти
+type OAuthRepository struct {
+	db *DB
+}
+
+// NewOAuthRepository создает новый экземпляр OAuthRepository
+func NewOAuthRepository(db *DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient регистрирует клиента
+func (r *OAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	defer r.db.lock(ctx)()
+
+	if client.ID == uuid.Nil {
+		client.ID = uuid.New()
+	}
+	if client.CreatedAt.IsZero() {
+		client.CreatedAt = time.Now()
+	}
+	put(r.db, r.db.data.oauthClients, client.ID, copyOAuthClient(client))
+	return nil
+}
+
+// GetClient получает клиента по ID
+func (r *OAuthRepository) GetClient(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error) {
+	defer r.db.lock(ctx)()
+
+	client, ok := r.db.data.oauthClients[id]
+	if !ok {
+		return nil, repository.ErrOAuthClientNotFound
+	}
+	copied := copyOAuthClient(&client)
+	return &copied, nil
+}
+
+// ListClients получает клиентов в порядке регистрации
+func (r *OAuthRepository) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	defer r.db.lock(ctx)()
+
+	clients := make([]*models.OAuthClient, 0, len(r.db.data.oauthClients))
+	for _, client := range r.db.data.oauthClients {
+		copied := copyOAuthClient(&client)
+		clients = append(clients, &copied)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].CreatedAt.Equal(clients[j].CreatedAt) {
+			return clients[i].CreatedAt.Before(clients[j].CreatedAt)
+		}
+		return clients[i].ID.String() < clients[j].ID.String()
+	})
+	return clients, nil
+}
+
+// DeleteClient удаляет клиента вместе с его кодами и токенами
+func (r *OAuthRepository) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.data.oauthClients[id]; !ok {
+		return repository.ErrOAuthClientNotFound
+	}
+	remove(r.db, r.db.data.oauthClients, id)
+	for hash, code := range r.db.data.oauthCodes {
+		if code.ClientID == id {
+			remove(r.db, r.db.data.oauthCodes, hash)
+		}
+	}
+	for tokenID, token := range r.db.data.oauthTokens {
+		if token.ClientID == id {
+			remove(r.db, r.db.data.oauthTokens, tokenID)
+		}
+	}
+	return nil
+}
+
+// CreateCode сохраняет код авторизации
+func (r *OAuthRepository) CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	defer r.db.lock(ctx)()
+
+	if code.CreatedAt.IsZero() {
+		code.CreatedAt = time.Now()
+	}
+	copied := *code
+	copied.Scopes = append([]string(nil), code.Scopes...)
+	put(r.db, r.db.data.oauthCodes, code.CodeHash, copied)
+	return nil
+}
+
+// ConsumeCode удаляет код авторизации и возвращает его
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	defer r.db.lock(ctx)()
+
+	code, ok := r.db.data.oauthCodes[codeHash]
+	if !ok {
+		return nil, repository.ErrOAuthCodeNotFound
+	}
+	remove(r.db, r.db.data.oauthCodes, codeHash)
+	return &code, nil
+}
+
+// CreateToken сохраняет токен доступа
+func (r *OAuthRepository) CreateToken(ctx context.Context, token *models.OAuthToken) error {
+	defer r.db.lock(ctx)()
+
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	put(r.db, r.db.data.oauthTokens, token.ID, copyOAuthToken(token))
+	return nil
+}
+
+// GetTokenByHash получает токен доступа по хешу
+func (r *OAuthRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.OAuthToken, error) {
+	defer r.db.lock(ctx)()
+
+	for _, token := range r.db.data.oauthTokens {
+		if token.TokenHash == tokenHash {
+			copied := copyOAuthToken(&token)
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrOAuthTokenNotFound
+}
+
+// RevokeToken отзывает токен доступа
+func (r *OAuthRepository) RevokeToken(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer r.db.lock(ctx)()
+
+	token, ok := r.db.data.oauthTokens[id]
+	if !ok {
+		return repository.ErrOAuthTokenNotFound
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &at
+		put(r.db, r.db.data.oauthTokens, id, token)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет истекшие коды авторизации и токены
+func (r *OAuthRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	defer r.db.lock(ctx)()
+
+	var deleted int64
+	for hash, code := range r.db.data.oauthCodes {
+		if !code.ExpiresAt.After(now) {
+			remove(r.db, r.db.data.oauthCodes, hash)
+			deleted++
+		}
+	}
+	for id, token := range r.db.data.oauthTokens {
+		if !token.ExpiresAt.After(now) {
+			remove(r.db, r.db.data.oauthTokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copyOAuthClient копирует клиента вместе со списками, чтобы вызывающий не изменял хранилище
+func copyOAuthClient(client *models.OAuthClient) models.OAuthClient {
+	copied := *client
+	copied.RedirectURIs = append([]string(nil), client.RedirectURIs...)
+	copied.GrantTypes = append([]string(nil), client.GrantTypes...)
+	copied.Scopes = append([]string(nil), client.Scopes...)
+	return copied
+}
+
+// copyOAuthToken копирует токен вместе с областями действия и временем отзыва
+func copyOAuthToken(token *models.OAuthToken) models.OAuthToken {
+	copied := *token
+	copied.Scopes = append([]string(nil), token.Scopes...)
+	copied.UserID = copyPtr(token.UserID)
+	copied.RevokedAt = copyPtr(token.RevokedAt)
+	return copied
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что OutboxRepository реализует интерфейс repository.OutboxRepository
+var _ repository.OutboxRepository = (*OutboxRepository)(nil)
+
+// OutboxRepository представляет хранилище исходящих событий в памяти
+type OutboxRepository struct {
+	db *DB
+}
+
+// NewOutboxRepository создает новый экземпляр OutboxRepository
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create записывает событие; ID событий возрастают в порядке записи
+func (r *OutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	defer r.db.lock(ctx)()
+
+	event.ID = uint64(len(r.db.data.outboxEvents) + 1)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = event.CreatedAt
+	}
+	replace(r.db, &r.db.data.outboxEvents, append(r.db.data.outboxEvents, *event))
+	return nil
+}
+
+// FetchPending получает неопубликованные события, время попытки которых подошло,
+// пропуская пользователей, чье более раннее событие ждет повтора
+func (r *OutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	defer r.db.lock(ctx)()
+
+	var events []*models.OutboxEvent
+	waiting := make(map[uuid.UUID]bool)
+	for _, event := range r.db.data.outboxEvents {
+		if len(events) == limit {
+			break
+		}
+		if event.PublishedAt != nil || event.DeadAt != nil || waiting[event.AggregateID] {
+			continue
+		}
+		if event.NextAttemptAt.After(now) {
+			waiting[event.AggregateID] = true
+			continue
+		}
+		copied := event
+		events = append(events, &copied)
+	}
+	return events, nil
+}
+
+// Schedule назначает событиям время следующей попытки публикации
+func (r *OutboxRepository) Schedule(ctx context.Context, ids []uint64, at time.Time) error {
+	for _, id := range ids {
+		if err := r.update(ctx, id, func(event *models.OutboxEvent) {
+			event.NextAttemptAt = at
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkPublished отмечает событие как опубликованное
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uint64) error {
+	now := time.Now()
+	return r.update(ctx, id, func(event *models.OutboxEvent) {
+		event.PublishedAt = &now
+		event.Attempts++
+		event.LastError = ""
+	})
+}
+
+// MarkFailed сохраняет причину неудачной публикации и время следующей попытки
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	return r.update(ctx, id, func(event *models.OutboxEvent) {
+		event.Attempts++
+		event.LastError = reason
+		event.NextAttemptAt = nextAttemptAt
+	})
+}
+
+// MarkDead переводит событие в dead letter
+func (r *OutboxRepository) MarkDead(ctx context.Context, id uint64, reason string) error {
+	now := time.Now()
+	return r.update(ctx, id, func(event *models.OutboxEvent) {
+		event.DeadAt = &now
+		event.Attempts++
+		event.LastError = reason
+	})
+}
+
+// TryLock всегда успешен: хранилище в памяти доступно только одному процессу,
+// а транзакции в нем выполняются последовательно
+func (r *OutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// update изменяет копию события и записывает ее на место прежней, чтобы транзакцию можно было откатить
+func (r *OutboxRepository) update(ctx context.Context, id uint64, fn func(event *models.OutboxEvent)) error {
+	defer r.db.lock(ctx)()
+
+	if id == 0 || id > uint64(len(r.db.data.outboxEvents)) {
+		return nil
+	}
+	event := r.db.data.outboxEvents[id-1]
+	fn(&event)
+	putAt(r.db, r.db.data.outboxEvents, int(id-1), event)
+	return nil
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что SessionRepository реализует интерфейс repository.SessionRepository
+var _ repository.SessionRepository = (*SessionRepository)(nil)
+
+// SessionRepository представляет хранилище сессий веб-интерфейса в памяти
+type SessionRepository struct {
+	db *DB
+}
+
+// NewSessionRepository создает новый экземпляр SessionRepository
+func NewSessionRepository(db *DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create создает сессию
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	defer r.db.lock(ctx)()
+
+	put(r.db, r.db.data.sessions, session.ID, copySession(session))
+	return nil
+}
+
+// GetByID получает сессию по ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	defer r.db.lock(ctx)()
+
+	session, ok := r.db.data.sessions[id]
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	copied := copySession(&session)
+	return &copied, nil
+}
+
+// Update сохраняет данные, владельца и сроки сессии
+func (r *SessionRepository) Update(ctx context.Context, session *models.Session) error {
+	defer r.db.lock(ctx)()
+
+	stored, ok := r.db.data.sessions[session.ID]
+	if !ok {
+		return repository.ErrSessionNotFound
+	}
+	updated := copySession(session)
+	updated.UserAgent, updated.SourceIP, updated.CreatedAt = stored.UserAgent, stored.SourceIP, stored.CreatedAt
+	put(r.db, r.db.data.sessions, session.ID, updated)
+	return nil
+}
+
+// Delete удаляет сессию
+func (r *SessionRepository) Delete(ctx context.Context, id string) error {
+	defer r.db.lock(ctx)()
+
+	remove(r.db, r.db.data.sessions, id)
+	return nil
+}
+
+// ListByUserID получает действующие сессии пользователя, начиная с последней активной
+func (r *SessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Session, error) {
+	defer r.db.lock(ctx)()
+
+	var sessions []*models.Session
+	for _, session := range r.db.data.sessions {
+		if session.UserID != nil && *session.UserID == userID && session.ExpiresAt.After(now) {
+			copied := copySession(&session)
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// DeleteByUserID удаляет все сессии пользователя
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	defer r.db.lock(ctx)()
+
+	var deleted int64
+	for id, session := range r.db.data.sessions {
+		if session.UserID != nil && *session.UserID == userID {
+			remove(r.db, r.db.data.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteExpired удаляет истекшие сессии
+func (r *SessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	defer r.db.lock(ctx)()
+
+	var deleted int64
+	for id, session := range r.db.data.sessions {
+		if !session.ExpiresAt.After(now) {
+			remove(r.db, r.db.data.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copySession копирует сессию вместе с данными и ID пользователя, чтобы вызывающий не изменял хранилище
+func copySession(session *models.Session) models.Session {
+	copied := *session
+	copied.Data = append([]byte(nil), session.Data...)
+	if session.UserID != nil {
+		userID := *session.UserID
+		copied.UserID = &userID
+	}
+	return copied
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Est1ege/go-user-api/internal/repository"
+)
+
+// Убедимся что Transactor реализует интерфейс repository.Transactor
+var _ repository.Transactor = (*Transactor)(nil)
+
+// Transactor управляет транзакциями хранилища в памяти
+type Transactor struct {
+	db *DB
+}
+
+// NewTransactor создает новый экземпляр Transactor
+func NewTransactor(db *DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction выполняет fn в транзакции; при ошибке или панике изменения откатываются.
+// Вложенный вызов работает как точка сохранения. Функции, зарегистрированные через
+// repository.AfterCommit, выполняются после фиксации внешней транзакции.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) == any(t.db) {
+		return t.savepoint(ctx, fn)
+	}
+
+	ctx, runHooks := repository.WithCommitHooks(ctx)
+	if err := t.run(context.WithValue(ctx, txKey{}, t.db), fn); err != nil {
+		return err
+	}
+	runHooks()
+	return nil
+}
+
+func (t *Transactor) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+
+	t.db.inTx = true
+	defer func() {
+		t.db.inTx = false
+		t.db.undo = nil
+	}()
+	return t.savepoint(ctx, fn)
+}
+
+// savepoint выполняет fn и отменяет ее изменения по журналу отката, если fn завершилась ошибкой или паникой
+func (t *Transactor) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	mark := len(t.db.undo)
+	committed := false
+	defer func() {
+		if !committed {
+			t.db.rollbackTo(mark)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
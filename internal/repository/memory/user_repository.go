@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что UserRepository реализует интерфейс repository.UserRepository
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// UserRepository представляет хранилище пользователей в памяти
+type UserRepository struct {
+	db *DB
+}
+
+// NewUserRepository создает новый экземпляр UserRepository
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create создает нового пользователя; как и в PostgreSQL, ID всегда генерируется заново
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	defer r.db.lock(ctx)()
+
+	if r.emailTaken(user.Email, uuid.Nil) {
+		return repository.ErrEmailAlreadyExists
+	}
+
+	now := time.Now()
+	user.ID = uuid.New()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	put(r.db, r.db.data.users, user.ID, *user)
+	return nil
+}
+
+// GetByID получает пользователя по ID
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	defer r.db.lock(ctx)()
+
+	user, ok := r.db.data.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// GetByIDForUpdate получает пользователя по ID. Отдельная блокировка не нужна: транзакции
+// хранилища в памяти выполняются под общей блокировкой и не пересекаются.
+func (r *UserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+// GetByEmail получает пользователя по email без учета регистра
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	defer r.db.lock(ctx)()
+
+	for _, user := range r.db.data.users {
+		if strings.ToLower(user.Email) == strings.ToLower(email) {
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// Update сохраняет все поля пользователя и обновляет UpdatedAt
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	defer r.db.lock(ctx)()
+
+	if r.emailTaken(user.Email, user.ID) {
+		return repository.ErrEmailAlreadyExists
+	}
+
+	user.UpdatedAt = time.Now()
+	put(r.db, r.db.data.users, user.ID, *user)
+	return nil
+}
+
+// Delete удаляет пользователя
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer r.db.lock(ctx)()
+
+	remove(r.db, r.db.data.users, id)
+	return nil
+}
+
+// GetAll получает список всех пользователей
+func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
+	return r.find(ctx, models.UserFilter{}, byCreatedAt), nil
+}
+
+// List получает страницу пользователей, подходящих под фильтр, и их общее количество
+func (r *UserRepository) List(ctx context.Context, filter models.UserFilter, p models.Page) ([]*models.User, int64, error) {
+	users := r.find(ctx, filter, byCreatedAt)
+	start, end := page(len(users), p)
+	return users[start:end], int64(len(users)), nil
+}
+
+// FindInBatches передает пользователей порциями в порядке первичного ключа.
+// Порции отбираются сразу, а fn вызывается уже без блокировки хранилища.
+func (r *UserRepository) FindInBatches(ctx context.Context, filter models.UserFilter, batchSize int, fn func(users []*models.User) error) error {
+	users := r.find(ctx, filter, byID)
+	for start := 0; start < len(users); start += batchSize {
+		end := start + batchSize
+		if end > len(users) {
+			end = len(users)
+		}
+		if err := fn(users[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search ищет пользователей упрощенным поиском (см. models.User.SearchRank)
+func (r *UserRepository) Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error) {
+	terms := models.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var results []*models.UserSearchResult
+	for _, user := range r.find(ctx, models.UserFilter{}, byCreatedAt) {
+		if rank, ok := user.SearchRank(terms); ok {
+			results = append(results, &models.UserSearchResult{User: user, Rank: rank})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// emailTaken сообщает, занят ли email (без учета регистра) другим пользователем
+func (r *UserRepository) emailTaken(email string, except uuid.UUID) bool {
+	for id, user := range r.db.data.users {
+		if id != except && strings.ToLower(user.Email) == strings.ToLower(email) {
+			return true
+		}
+	}
+	return false
+}
+
+// find возвращает копии пользователей, подходящих под фильтр, в указанном порядке
+func (r *UserRepository) find(ctx context.Context, filter models.UserFilter, less func(a, b *models.User) bool) []*models.User {
+	defer r.db.lock(ctx)()
+
+	users := make([]*models.User, 0, len(r.db.data.users))
+	for _, user := range r.db.data.users {
+		if matches(&user, filter) {
+			copied := user
+			users = append(users, &copied)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return less(users[i], users[j]) })
+	return users
+}
+
+// matches проверяет пользователя на соответствие фильтру так же, как applyUserFilter в PostgreSQL
+func matches(user *models.User, filter models.UserFilter) bool {
+	if filter.Email != "" && !containsFold(user.Email, filter.Email) {
+		return false
+	}
+	if filter.FirstName != "" && !containsFold(user.FirstName, filter.FirstName) {
+		return false
+	}
+	if filter.LastName != "" && !containsFold(user.LastName, filter.LastName) {
+		return false
+	}
+	if filter.CreatedAfter != nil && user.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !user.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func byCreatedAt(a, b *models.User) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+func byID(a, b *models.User) bool {
+	return a.ID.String() < b.ID.String()
+}
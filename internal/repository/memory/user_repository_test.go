@@ -0,0 +1,131 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/internal/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_Conformance(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepository {
+		return memory.NewUserRepository(memory.NewDB())
+	})
+}
+
+func TestTransactor_Conformance(t *testing.T) {
+	repotest.RunTransactorTests(t, func(t *testing.T) (repository.UserRepository, repository.Transactor) {
+		db := memory.NewDB()
+		return memory.NewUserRepository(db), memory.NewTransactor(db)
+	})
+}
+
+func TestTransactor_RollbackRestoresAllTables(t *testing.T) {
+	db := memory.NewDB()
+	users := memory.NewUserRepository(db)
+	sessions := memory.NewSessionRepository(db)
+	outbox := memory.NewOutboxRepository(db)
+	transactor := memory.NewTransactor(db)
+	ctx := context.Background()
+
+	user := &models.User{Email: "rollback@example.com", FirstName: "Before"}
+	require.NoError(t, users.Create(ctx, user))
+	require.NoError(t, sessions.Create(ctx, &models.Session{ID: "session", ExpiresAt: time.Now().Add(time.Hour)}))
+	event := &models.OutboxEvent{Type: models.EventUserCreated, AggregateID: user.ID}
+	require.NoError(t, outbox.Create(ctx, event))
+
+	failure := errors.New("rollback")
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		changed := *user
+		changed.FirstName = "After"
+		require.NoError(t, users.Update(ctx, &changed))
+		require.NoError(t, users.Create(ctx, &models.User{Email: "created@example.com"}))
+		require.NoError(t, sessions.Delete(ctx, "session"))
+		require.NoError(t, outbox.MarkPublished(ctx, event.ID))
+		require.NoError(t, outbox.Create(ctx, &models.OutboxEvent{Type: models.EventUserCreated, AggregateID: user.ID}))
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	found, err := users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Before", found.FirstName)
+	_, err = users.GetByEmail(ctx, "created@example.com")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	_, err = sessions.GetByID(ctx, "session")
+	assert.NoError(t, err)
+	pending, err := outbox.FetchPending(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, event.ID, pending[0].ID)
+}
+
+func TestUserRepository_ConcurrentTransactions(t *testing.T) {
+	db := memory.NewDB()
+	repo := memory.NewUserRepository(db)
+	transactor := memory.NewTransactor(db)
+
+	const workers = 16
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+				if err := repo.Create(ctx, &models.User{Email: fmt.Sprintf("user%d@example.com", i)}); err != nil {
+					return err
+				}
+				// Нечетные транзакции откатываются
+				if i%2 == 1 {
+					return fmt.Errorf("rollback %d", i)
+				}
+				return nil
+			})
+			_, _ = repo.GetAll(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	users, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, users, workers/2)
+}
+
+func TestSessionRepository_Conformance(t *testing.T) {
+	repotest.RunSessionRepositoryTests(t, func(t *testing.T) repository.SessionRepository {
+		return memory.NewSessionRepository(memory.NewDB())
+	})
+}
+
+func TestAPIKeyRepository_Conformance(t *testing.T) {
+	repotest.RunAPIKeyRepositoryTests(t, func(t *testing.T) repository.APIKeyRepository {
+		return memory.NewAPIKeyRepository(memory.NewDB())
+	})
+}
+
+func TestIdentityRepository_Conformance(t *testing.T) {
+	repotest.RunIdentityRepositoryTests(t, func(t *testing.T) repository.IdentityRepository {
+		return memory.NewIdentityRepository(memory.NewDB())
+	})
+}
+
+func TestOAuthRepository_Conformance(t *testing.T) {
+	repotest.RunOAuthRepositoryTests(t, func(t *testing.T) repository.OAuthRepository {
+		return memory.NewOAuthRepository(memory.NewDB())
+	})
+}
+
+func TestOutboxRepository_Conformance(t *testing.T) {
+	repotest.RunOutboxRepositoryTests(t, func(t *testing.T) repository.OutboxRepository {
+		return memory.NewOutboxRepository(memory.NewDB())
+	})
+}
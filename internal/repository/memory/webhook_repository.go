@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Убедимся что WebhookRepository реализует интерфейс repository.WebhookRepository
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// WebhookRepository представляет хранилище подписок и доставок вебхуков в памяти
+type WebhookRepository struct {
+	db *DB
+}
+
+// NewWebhookRepository создает новый экземпляр WebhookRepository
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription создает подписку
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	defer r.db.lock(ctx)()
+
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+	now := time.Now()
+	subscription.CreatedAt, subscription.UpdatedAt = now, now
+	put(r.db, r.db.data.subscriptions, subscription.ID, *subscription)
+	return nil
+}
+
+// GetSubscription получает подписку по ID
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	defer r.db.lock(ctx)()
+
+	subscription, ok := r.db.data.subscriptions[id]
+	if !ok {
+		return nil, repository.ErrWebhookNotFound
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions получает все подписки в порядке создания
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	defer r.db.lock(ctx)()
+
+	subscriptions := make([]*models.WebhookSubscription, 0, len(r.db.data.subscriptions))
+	for _, subscription := range r.db.data.subscriptions {
+		copied := subscription
+		subscriptions = append(subscriptions, &copied)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	defer r.db.lock(ctx)()
+
+	remove(r.db, r.db.data.subscriptions, id)
+	return nil
+}
+
+// CreateDelivery создает доставку
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	defer r.db.lock(ctx)()
+
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+	now := time.Now()
+	delivery.CreatedAt, delivery.UpdatedAt = now, now
+	put(r.db, r.db.data.deliveries, delivery.ID, *delivery)
+	return nil
+}
+
+// GetDelivery получает доставку по ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	defer r.db.lock(ctx)()
+
+	delivery, ok := r.db.data.deliveries[id]
+	if !ok {
+		return nil, repository.ErrWebhookNotFound
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries получает страницу доставок подписки от новых к старым
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, p models.Page) ([]*models.WebhookDelivery, int64, error) {
+	defer r.db.lock(ctx)()
+
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.db.data.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			copied := delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID.String() < deliveries[j].ID.String()
+	})
+
+	start, end := page(len(deliveries), p)
+	return deliveries[start:end], int64(len(deliveries)), nil
+}
+
+// ClaimDueDeliveries получает доставки, которые пора отправить.
+// Блокировать записи не нужно: транзакция и так удерживает хранилище целиком.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	defer r.db.lock(ctx)()
+
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range r.db.data.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			copied := delivery
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	defer r.db.lock(ctx)()
+
+	delivery.UpdatedAt = time.Now()
+	put(r.db, r.db.data.deliveries, delivery.ID, *delivery)
+	return nil
+}
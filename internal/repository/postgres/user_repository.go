@@ -1,70 +0,0 @@
-package postgres
-
-import (
-	"errors"
-	"github.com/google/uuid"
-	"github.com/Est1ege/go-user-api/internal/domain/models"
-	"github.com/Est1ege/go-user-api/internal/repository"
-	"gorm.io/gorm"
-)
-
-// Убедимся что UserRepository реализует интерфейс repository.UserRepository
-var _ repository.UserRepository = (*UserRepository)(nil)
-
-// UserRepository представляет интерфейс для работы с пользователями в БД
-type UserRepository struct {
-	db *gorm.DB
-}
-
-// NewUserRepository создает новый экземпляр UserRepository
-func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
-}
-
-// Create создает нового пользователя
-func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
-}
-
-// GetByID получает пользователя по ID
-func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
-	var user models.User
-	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-// GetByEmail получает пользователя по email
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
-	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-// Update обновляет данные пользователя
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
-}
-
-// Delete удаляет пользователя
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.User{}, "id = ?", id).Error
-}
-
-// GetAll получает список всех пользователей
-func (r *UserRepository) GetAll() ([]*models.User, error) {
-	var users []*models.User
-	if err := r.db.Find(&users).Error; err != nil {
-		return nil, err
-	}
-	return users, nil
-}
\ No newline at end of file
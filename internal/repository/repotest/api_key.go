@@ -0,0 +1,139 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunAPIKeyRepositoryTests проверяет реализацию repository.APIKeyRepository.
+// Ключи создаются для случайных пользователей и со случайными префиксами, поэтому тесты не мешают друг другу.
+func RunAPIKeyRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.APIKeyRepository) {
+	tests := map[string]func(t *testing.T, repo repository.APIKeyRepository){
+		"CreateAndGet":  testAPIKeyCreateAndGet,
+		"ListByUser":    testAPIKeyListByUser,
+		"Revoke":        testAPIKeyRevoke,
+		"TouchLastUsed": testAPIKeyTouchLastUsed,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// newAPIKey создает в репозитории ключ пользователя userID (nil - сервисного аккаунта)
+func newAPIKey(t *testing.T, repo repository.APIKeyRepository, userID *uuid.UUID) *models.APIKey {
+	key := &models.APIKey{
+		Name:   "repotest",
+		Prefix: "uak_" + randomLetters(12),
+		Hash:   randomLetters(64),
+		UserID: userID,
+		Scopes: []string{models.ScopeUsersRead},
+	}
+	if userID == nil {
+		key.ServiceAccount = "provisioning"
+	}
+	require.NoError(t, repo.Create(context.Background(), key))
+	return key
+}
+
+func testAPIKeyCreateAndGet(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(timePrecision)
+	key := &models.APIKey{
+		Name:           "provisioning job",
+		Prefix:         "uak_" + randomLetters(12),
+		Hash:           randomLetters(64),
+		ServiceAccount: "provisioning",
+		Scopes:         []string{models.ScopeUsersRead, models.ScopeUsersWrite},
+		ExpiresAt:      &expiresAt,
+	}
+	require.NoError(t, repo.Create(ctx, key))
+	require.NotEqual(t, uuid.Nil, key.ID)
+
+	got, err := repo.GetByID(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, key.Prefix, got.Prefix)
+	assert.Equal(t, key.Hash, got.Hash)
+	assert.Equal(t, "provisioning", got.ServiceAccount)
+	assert.Nil(t, got.UserID)
+	assert.Equal(t, []string{models.ScopeUsersRead, models.ScopeUsersWrite}, got.Scopes)
+	require.NotNil(t, got.ExpiresAt)
+	assert.WithinDuration(t, expiresAt, *got.ExpiresAt, timePrecision)
+	assert.Nil(t, got.RevokedAt)
+	assert.False(t, got.CreatedAt.IsZero())
+
+	byPrefix, err := repo.GetByPrefix(ctx, key.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, byPrefix.ID)
+
+	_, err = repo.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+	_, err = repo.GetByPrefix(ctx, "uak_missing")
+	assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+}
+
+func testAPIKeyListByUser(t *testing.T, repo repository.APIKeyRepository) {
+	userID, otherID := uuid.New(), uuid.New()
+
+	older := newAPIKey(t, repo, &userID)
+	time.Sleep(2 * timePrecision)
+	newer := newAPIKey(t, repo, &userID)
+	other := newAPIKey(t, repo, &otherID)
+	service := newAPIKey(t, repo, nil)
+
+	keys, err := repo.List(context.Background(), &userID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{newer.ID, older.ID}, apiKeyIDs(keys))
+
+	all, err := repo.List(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Subset(t, apiKeyIDs(all), []uuid.UUID{newer.ID, older.ID, other.ID, service.ID})
+}
+
+func testAPIKeyRevoke(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := newAPIKey(t, repo, nil)
+	revokedAt := time.Now().Truncate(timePrecision)
+
+	require.NoError(t, repo.Revoke(ctx, key.ID, revokedAt))
+	require.NoError(t, repo.Revoke(ctx, key.ID, revokedAt.Add(time.Hour)))
+
+	got, err := repo.GetByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	assert.WithinDuration(t, revokedAt, *got.RevokedAt, timePrecision)
+
+	assert.ErrorIs(t, repo.Revoke(ctx, uuid.New(), revokedAt), repository.ErrAPIKeyNotFound)
+}
+
+func testAPIKeyTouchLastUsed(t *testing.T, repo repository.APIKeyRepository) {
+	ctx := context.Background()
+	key := newAPIKey(t, repo, nil)
+	usedAt := time.Now().Truncate(timePrecision)
+
+	require.NoError(t, repo.TouchLastUsed(ctx, key.ID, usedAt))
+
+	got, err := repo.GetByID(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+	assert.WithinDuration(t, usedAt, *got.LastUsedAt, timePrecision)
+
+	assert.ErrorIs(t, repo.TouchLastUsed(ctx, uuid.New(), usedAt), repository.ErrAPIKeyNotFound)
+}
+
+func apiKeyIDs(keys []*models.APIKey) []uuid.UUID {
+	ids := make([]uuid.UUID, len(keys))
+	for i, key := range keys {
+		ids[i] = key.ID
+	}
+	return ids
+}
@@ -0,0 +1,89 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunIdentityRepositoryTests проверяет реализацию repository.IdentityRepository.
+// Учетные записи создаются со случайными subject и для случайных пользователей, поэтому тесты не мешают друг другу.
+func RunIdentityRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.IdentityRepository) {
+	tests := map[string]func(t *testing.T, repo repository.IdentityRepository){
+		"CreateAndGet":     testIdentityCreateAndGet,
+		"DuplicateSubject": testIdentityDuplicateSubject,
+		"ListByUser":       testIdentityListByUser,
+		"Delete":           testIdentityDelete,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// newIdentity привязывает к пользователю userID учетную запись поставщика provider со случайным subject
+func newIdentity(t *testing.T, repo repository.IdentityRepository, userID uuid.UUID, provider string) *models.Identity {
+	identity := &models.Identity{UserID: userID, Provider: provider, Subject: randomLetters(16)}
+	require.NoError(t, repo.Create(context.Background(), identity))
+	return identity
+}
+
+func testIdentityCreateAndGet(t *testing.T, repo repository.IdentityRepository) {
+	ctx := context.Background()
+	identity := &models.Identity{UserID: uuid.New(), Provider: "corp", Subject: randomLetters(16), Email: "john@example.com"}
+	require.NoError(t, repo.Create(ctx, identity))
+	require.NotEqual(t, uuid.Nil, identity.ID)
+
+	got, err := repo.GetByProviderSubject(ctx, "corp", identity.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, identity.ID, got.ID)
+	assert.Equal(t, identity.UserID, got.UserID)
+	assert.Equal(t, "john@example.com", got.Email)
+	assert.False(t, got.CreatedAt.IsZero())
+
+	// Subject уникален только в пределах поставщика
+	_, err = repo.GetByProviderSubject(ctx, "other", identity.Subject)
+	assert.ErrorIs(t, err, repository.ErrIdentityNotFound)
+}
+
+func testIdentityDuplicateSubject(t *testing.T, repo repository.IdentityRepository) {
+	identity := newIdentity(t, repo, uuid.New(), "corp")
+
+	err := repo.Create(context.Background(), &models.Identity{UserID: uuid.New(), Provider: "corp", Subject: identity.Subject})
+	assert.ErrorIs(t, err, repository.ErrIdentityAlreadyLinked)
+
+	require.NoError(t, repo.Create(context.Background(), &models.Identity{UserID: uuid.New(), Provider: "other", Subject: identity.Subject}))
+}
+
+func testIdentityListByUser(t *testing.T, repo repository.IdentityRepository) {
+	userID := uuid.New()
+
+	first := newIdentity(t, repo, userID, "corp")
+	time.Sleep(2 * timePrecision)
+	second := newIdentity(t, repo, userID, "github")
+	newIdentity(t, repo, uuid.New(), "corp")
+
+	identities, err := repo.ListByUserID(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, identities, 2)
+	assert.Equal(t, first.ID, identities[0].ID)
+	assert.Equal(t, second.ID, identities[1].ID)
+}
+
+func testIdentityDelete(t *testing.T, repo repository.IdentityRepository) {
+	ctx := context.Background()
+	identity := newIdentity(t, repo, uuid.New(), "corp")
+
+	require.NoError(t, repo.Delete(ctx, identity.ID))
+	_, err := repo.GetByProviderSubject(ctx, "corp", identity.Subject)
+	assert.ErrorIs(t, err, repository.ErrIdentityNotFound)
+	assert.ErrorIs(t, repo.Delete(ctx, identity.ID), repository.ErrIdentityNotFound)
+}
@@ -0,0 +1,170 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunOAuthRepositoryTests проверяет реализацию repository.OAuthRepository.
+// Коды и токены создаются со случайными хешами, поэтому тесты не мешают друг другу.
+func RunOAuthRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.OAuthRepository) {
+	tests := map[string]func(t *testing.T, repo repository.OAuthRepository){
+		"ClientLifecycle": testOAuthClientLifecycle,
+		"ConsumeCode":     testOAuthConsumeCode,
+		"Tokens":          testOAuthTokens,
+		"DeleteExpired":   testOAuthDeleteExpired,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+func newOAuthClient(t *testing.T, repo repository.OAuthRepository) *models.OAuthClient {
+	client := &models.OAuthClient{
+		Name:         "repotest",
+		SecretHash:   randomLetters(64),
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		GrantTypes:   []string{models.GrantAuthorizationCode, models.GrantClientCredentials},
+		Scopes:       []string{models.OAuthScopeOpenID, models.OAuthScopeEmail},
+	}
+	require.NoError(t, repo.CreateClient(context.Background(), client))
+	return client
+}
+
+func newOAuthToken(t *testing.T, repo repository.OAuthRepository, clientID uuid.UUID, expiresAt time.Time) *models.OAuthToken {
+	userID := uuid.New()
+	token := &models.OAuthToken{
+		TokenHash: randomLetters(64),
+		ClientID:  clientID,
+		UserID:    &userID,
+		Scopes:    []string{models.OAuthScopeOpenID},
+		ExpiresAt: expiresAt,
+	}
+	require.NoError(t, repo.CreateToken(context.Background(), token))
+	return token
+}
+
+func testOAuthClientLifecycle(t *testing.T, repo repository.OAuthRepository) {
+	ctx := context.Background()
+	first := newOAuthClient(t, repo)
+	time.Sleep(2 * timePrecision)
+	second := newOAuthClient(t, repo)
+	require.NotEqual(t, uuid.Nil, first.ID)
+
+	got, err := repo.GetClient(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.SecretHash, got.SecretHash)
+	assert.Equal(t, first.RedirectURIs, got.RedirectURIs)
+	assert.Equal(t, first.GrantTypes, got.GrantTypes)
+	assert.Equal(t, first.Scopes, got.Scopes)
+	assert.False(t, got.CreatedAt.IsZero())
+
+	// В общей базе могут быть клиенты других тестов, поэтому проверяется только порядок своих
+	clients, err := repo.ListClients(ctx)
+	require.NoError(t, err)
+	var ids []uuid.UUID
+	for _, client := range clients {
+		if client.ID == first.ID || client.ID == second.ID {
+			ids = append(ids, client.ID)
+		}
+	}
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID}, ids)
+
+	// Вместе с клиентом удаляются его токены
+	token := newOAuthToken(t, repo, first.ID, time.Now().Add(time.Hour))
+	require.NoError(t, repo.DeleteClient(ctx, first.ID))
+	_, err = repo.GetClient(ctx, first.ID)
+	assert.ErrorIs(t, err, repository.ErrOAuthClientNotFound)
+	_, err = repo.GetTokenByHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, repository.ErrOAuthTokenNotFound)
+	assert.ErrorIs(t, repo.DeleteClient(ctx, first.ID), repository.ErrOAuthClientNotFound)
+}
+
+func testOAuthConsumeCode(t *testing.T, repo repository.OAuthRepository) {
+	ctx := context.Background()
+	code := &models.OAuthAuthorizationCode{
+		CodeHash:      randomLetters(64),
+		ClientID:      uuid.New(),
+		UserID:        uuid.New(),
+		RedirectURI:   "https://app.example.com/callback",
+		Scopes:        []string{models.OAuthScopeOpenID, models.OAuthScopeProfile},
+		Nonce:         "nonce",
+		CodeChallenge: randomLetters(43),
+		ExpiresAt:     time.Now().Add(time.Minute).Truncate(timePrecision),
+	}
+	require.NoError(t, repo.CreateCode(ctx, code))
+
+	got, err := repo.ConsumeCode(ctx, code.CodeHash)
+	require.NoError(t, err)
+	assert.Equal(t, code.UserID, got.UserID)
+	assert.Equal(t, code.RedirectURI, got.RedirectURI)
+	assert.Equal(t, code.Scopes, got.Scopes)
+	assert.Equal(t, code.CodeChallenge, got.CodeChallenge)
+	assert.WithinDuration(t, code.ExpiresAt, got.ExpiresAt, timePrecision)
+
+	// Код одноразовый
+	_, err = repo.ConsumeCode(ctx, code.CodeHash)
+	assert.ErrorIs(t, err, repository.ErrOAuthCodeNotFound)
+}
+
+func testOAuthTokens(t *testing.T, repo repository.OAuthRepository) {
+	ctx := context.Background()
+	client := newOAuthClient(t, repo)
+	token := newOAuthToken(t, repo, client.ID, time.Now().Add(time.Hour).Truncate(timePrecision))
+	require.NotEqual(t, uuid.Nil, token.ID)
+
+	got, err := repo.GetTokenByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, got.ID)
+	assert.Equal(t, client.ID, got.ClientID)
+	require.NotNil(t, got.UserID)
+	assert.Equal(t, *token.UserID, *got.UserID)
+	assert.WithinDuration(t, token.ExpiresAt, got.ExpiresAt, timePrecision)
+	assert.Nil(t, got.RevokedAt)
+
+	revokedAt := time.Now().Truncate(timePrecision)
+	require.NoError(t, repo.RevokeToken(ctx, token.ID, revokedAt))
+	require.NoError(t, repo.RevokeToken(ctx, token.ID, revokedAt.Add(time.Hour)))
+	got, err = repo.GetTokenByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	assert.WithinDuration(t, revokedAt, *got.RevokedAt, timePrecision)
+
+	_, err = repo.GetTokenByHash(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrOAuthTokenNotFound)
+	assert.ErrorIs(t, repo.RevokeToken(ctx, uuid.New(), revokedAt), repository.ErrOAuthTokenNotFound)
+}
+
+func testOAuthDeleteExpired(t *testing.T, repo repository.OAuthRepository) {
+	ctx := context.Background()
+	now := time.Now()
+	client := newOAuthClient(t, repo)
+	expired := newOAuthToken(t, repo, client.ID, now.Add(-time.Minute))
+	active := newOAuthToken(t, repo, client.ID, now.Add(time.Hour))
+	code := &models.OAuthAuthorizationCode{
+		CodeHash: randomLetters(64), ClientID: client.ID, UserID: uuid.New(), RedirectURI: "https://app.example.com/callback",
+		CodeChallenge: randomLetters(43), ExpiresAt: now.Add(-time.Second),
+	}
+	require.NoError(t, repo.CreateCode(ctx, code))
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(2))
+
+	_, err = repo.GetTokenByHash(ctx, expired.TokenHash)
+	assert.ErrorIs(t, err, repository.ErrOAuthTokenNotFound)
+	_, err = repo.GetTokenByHash(ctx, active.TokenHash)
+	assert.NoError(t, err)
+	_, err = repo.ConsumeCode(ctx, code.CodeHash)
+	assert.ErrorIs(t, err, repository.ErrOAuthCodeNotFound)
+}
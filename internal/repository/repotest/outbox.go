@@ -0,0 +1,106 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunOutboxRepositoryTests проверяет реализацию repository.OutboxRepository.
+// События создаются для случайных пользователей, а проверки учитывают только их, поэтому
+// события других тестов в общей базе не мешают.
+func RunOutboxRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.OutboxRepository) {
+	tests := map[string]func(t *testing.T, repo repository.OutboxRepository){
+		"FetchPendingOrder":      testOutboxFetchPendingOrder,
+		"FetchPendingWaiting":    testOutboxFetchPendingWaiting,
+		"PublishedAndDeadLetter": testOutboxPublishedAndDeadLetter,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+func testOutboxFetchPendingOrder(t *testing.T, repo repository.OutboxRepository) {
+	now := time.Now()
+	alice, bob := uuid.New(), uuid.New()
+	first := newOutboxEvent(t, repo, alice, now)
+	second := newOutboxEvent(t, repo, bob, now)
+	third := newOutboxEvent(t, repo, alice, now)
+	newOutboxEvent(t, repo, bob, now.Add(time.Hour))
+
+	assert.Equal(t, []uint64{first.ID, second.ID, third.ID}, fetchPending(t, repo, now, alice, bob))
+}
+
+func testOutboxFetchPendingWaiting(t *testing.T, repo repository.OutboxRepository) {
+	ctx := context.Background()
+	now := time.Now()
+	alice, bob := uuid.New(), uuid.New()
+	failed := newOutboxEvent(t, repo, alice, now)
+	newOutboxEvent(t, repo, alice, now)
+	claimed := newOutboxEvent(t, repo, bob, now)
+	newOutboxEvent(t, repo, bob, now)
+
+	// Неудачное событие ждет повтора, захваченное - публикации: следующие события тех же пользователей не выбираются
+	require.NoError(t, repo.MarkFailed(ctx, failed.ID, "broker unavailable", now.Add(time.Minute)))
+	require.NoError(t, repo.Schedule(ctx, []uint64{claimed.ID}, now.Add(time.Minute)))
+	assert.Empty(t, fetchPending(t, repo, now, alice, bob))
+
+	// Когда время повтора подошло, события снова выбираются по порядку
+	later := now.Add(2 * time.Minute)
+	ids := fetchPending(t, repo, later, alice)
+	require.Len(t, ids, 2)
+	assert.Equal(t, failed.ID, ids[0])
+}
+
+func testOutboxPublishedAndDeadLetter(t *testing.T, repo repository.OutboxRepository) {
+	ctx := context.Background()
+	now := time.Now()
+	alice := uuid.New()
+	published := newOutboxEvent(t, repo, alice, now)
+	dead := newOutboxEvent(t, repo, alice, now)
+	next := newOutboxEvent(t, repo, alice, now)
+
+	require.NoError(t, repo.MarkPublished(ctx, published.ID))
+	require.NoError(t, repo.MarkDead(ctx, dead.ID, "payload rejected"))
+
+	// Событие в dead letter не выбирается и не задерживает следующие события пользователя
+	assert.Equal(t, []uint64{next.ID}, fetchPending(t, repo, now, alice))
+}
+
+// newOutboxEvent создает в репозитории событие пользователя aggregateID с временем попытки at
+func newOutboxEvent(t *testing.T, repo repository.OutboxRepository, aggregateID uuid.UUID, at time.Time) *models.OutboxEvent {
+	event := &models.OutboxEvent{
+		EventID:       uuid.New(),
+		AggregateID:   aggregateID,
+		Type:          models.EventUserUpdated,
+		Payload:       []byte(`{}`),
+		NextAttemptAt: at,
+	}
+	require.NoError(t, repo.Create(context.Background(), event))
+	return event
+}
+
+// fetchPending возвращает ID выбранных событий указанных пользователей
+func fetchPending(t *testing.T, repo repository.OutboxRepository, now time.Time, aggregates ...uuid.UUID) []uint64 {
+	events, err := repo.FetchPending(context.Background(), now, 1000)
+	require.NoError(t, err)
+
+	var ids []uint64
+	for _, event := range events {
+		for _, aggregate := range aggregates {
+			if event.AggregateID == aggregate {
+				ids = append(ids, event.ID)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,426 @@
+// Package repotest содержит общие тесты, которые должна проходить каждая реализация репозиториев.
+// Тесты не рассчитывают на пустое хранилище: каждый подтест работает со своим доменом email
+// вида <случайная строка>.conformance.test, который реализация может удалять после тестов.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Domain - общий суффикс доменов email, которые создают тесты
+const Domain = ".conformance.test"
+
+// timePrecision - точность хранения времени, которую допускают тесты (PostgreSQL хранит микросекунды)
+const timePrecision = time.Millisecond
+
+// RunUserRepositoryTests проверяет реализацию repository.UserRepository
+func RunUserRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.UserRepository) {
+	tests := map[string]func(t *testing.T, repo repository.UserRepository, domain string){
+		"CreateAndGet":            testCreateAndGet,
+		"NotFound":                testNotFound,
+		"GetByEmailIgnoresCase":   testGetByEmailIgnoresCase,
+		"DuplicateEmail":          testDuplicateEmail,
+		"Update":                  testUpdate,
+		"Delete":                  testDelete,
+		"GetAll":                  testGetAll,
+		"ListFilterAndPagination": testList,
+		"ListEscapesPatterns":     testListEscapesPatterns,
+		"FindInBatches":           testFindInBatches,
+		"Search":                  testSearch,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t), newDomain())
+		})
+	}
+}
+
+// RunTransactorTests проверяет, что изменения репозитория фиксируются и откатываются вместе с транзакцией
+func RunTransactorTests(t *testing.T, newRepo func(t *testing.T) (repository.UserRepository, repository.Transactor)) {
+	t.Run("CommitAndRollback", func(t *testing.T) {
+		repo, transactor := newRepo(t)
+		testCommitAndRollback(t, repo, transactor, newDomain())
+	})
+	t.Run("NestedRollback", func(t *testing.T) {
+		repo, transactor := newRepo(t)
+		testNestedRollback(t, repo, transactor, newDomain())
+	})
+	t.Run("AfterCommit", func(t *testing.T) {
+		repo, transactor := newRepo(t)
+		testAfterCommit(t, repo, transactor, newDomain())
+	})
+	t.Run("GetByIDForUpdate", func(t *testing.T) {
+		repo, transactor := newRepo(t)
+		testGetByIDForUpdate(t, repo, transactor, newDomain())
+	})
+}
+
+func newDomain() string {
+	return uuid.NewString()[:8] + Domain
+}
+
+// newUser создает пользователя со случайными именем и фамилией из букв, чтобы поиск находил только его
+func newUser(local, domain string) *models.User {
+	return &models.User{
+		Email:     local + "@" + domain,
+		FirstName: "First" + randomLetters(8),
+		LastName:  "Last" + randomLetters(8),
+		Password:  "hash",
+	}
+}
+
+func randomLetters(n int) string {
+	letters := make([]byte, n)
+	for i := range letters {
+		letters[i] = byte('a' + rand.Intn(26))
+	}
+	return string(letters)
+}
+
+func mustCreate(t *testing.T, repo repository.UserRepository, user *models.User) *models.User {
+	t.Helper()
+	require.NoError(t, repo.Create(context.Background(), user))
+	return user
+}
+
+func emails(users []*models.User) []string {
+	result := make([]string, len(users))
+	for i, user := range users {
+		result[i] = user.Email
+	}
+	return result
+}
+
+func testCreateAndGet(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	before := time.Now().Add(-time.Second)
+	user := mustCreate(t, repo, newUser("alice", domain))
+
+	assert.NotEqual(t, uuid.Nil, user.ID)
+	assert.True(t, user.CreatedAt.After(before), "CreatedAt must be set")
+	assert.True(t, user.UpdatedAt.After(before), "UpdatedAt must be set")
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, user.Email, found.Email)
+	assert.Equal(t, user.FirstName, found.FirstName)
+	assert.Equal(t, user.LastName, found.LastName)
+	assert.Equal(t, user.Password, found.Password)
+	assert.WithinDuration(t, user.CreatedAt, found.CreatedAt, timePrecision)
+	assert.WithinDuration(t, user.UpdatedAt, found.UpdatedAt, timePrecision)
+
+	// Возвращается копия: ее изменение не затрагивает хранилище
+	found.FirstName = "Changed"
+	again, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.FirstName, again.FirstName)
+}
+
+func testNotFound(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, uuid.New())
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "GetByID: got %v", err)
+
+	_, err = repo.GetByEmail(ctx, "nobody@"+domain)
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "GetByEmail: got %v", err)
+}
+
+func testGetByEmailIgnoresCase(t *testing.T, repo repository.UserRepository, domain string) {
+	user := mustCreate(t, repo, newUser("bob", domain))
+
+	found, err := repo.GetByEmail(context.Background(), strings.ToUpper(user.Email))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+}
+
+func testDuplicateEmail(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	first := mustCreate(t, repo, newUser("carol", domain))
+
+	err := repo.Create(ctx, newUser("CAROL", domain))
+	assert.True(t, errors.Is(err, repository.ErrEmailAlreadyExists), "Create: got %v", err)
+
+	second := mustCreate(t, repo, newUser("carl", domain))
+	second.Email = strings.ToUpper(first.Email)
+	err = repo.Update(ctx, second)
+	assert.True(t, errors.Is(err, repository.ErrEmailAlreadyExists), "Update: got %v", err)
+}
+
+func testUpdate(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	user := mustCreate(t, repo, newUser("dave", domain))
+	createdAt, updatedAt := user.CreatedAt, user.UpdatedAt
+
+	time.Sleep(2 * timePrecision)
+	user.FirstName = "David"
+	user.Email = "david@" + domain
+	require.NoError(t, repo.Update(ctx, user))
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "David", found.FirstName)
+	assert.Equal(t, "david@"+domain, found.Email)
+	assert.WithinDuration(t, createdAt, found.CreatedAt, timePrecision)
+	assert.True(t, found.UpdatedAt.After(updatedAt), "UpdatedAt must advance")
+
+	_, err = repo.GetByEmail(ctx, "dave@"+domain)
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "old email: got %v", err)
+}
+
+func testDelete(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	user := mustCreate(t, repo, newUser("erin", domain))
+
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	_, err := repo.GetByID(ctx, user.ID)
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "got %v", err)
+
+	// Повторное удаление не считается ошибкой, а email снова свободен
+	assert.NoError(t, repo.Delete(ctx, user.ID))
+	mustCreate(t, repo, newUser("erin", domain))
+}
+
+func testGetAll(t *testing.T, repo repository.UserRepository, domain string) {
+	first := mustCreate(t, repo, newUser("frank", domain))
+	second := mustCreate(t, repo, newUser("grace", domain))
+
+	users, err := repo.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Subset(t, emails(users), []string{first.Email, second.Email})
+}
+
+func testList(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	var created []*models.User
+	for i := 0; i < 5; i++ {
+		user := newUser(fmt.Sprintf("user%d", i), domain)
+		user.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		created = append(created, mustCreate(t, repo, user))
+	}
+	created[2].LastName = "Needle"
+	require.NoError(t, repo.Update(ctx, created[2]))
+
+	// Фильтр по подстроке email без учета регистра, порядок по времени создания
+	users, total, err := repo.List(ctx, models.UserFilter{Email: strings.ToUpper(domain)}, models.Page{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, emails(created[1:3]), emails(users))
+
+	// Смещение за пределами результата
+	users, total, err = repo.List(ctx, models.UserFilter{Email: domain}, models.Page{Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Empty(t, users)
+
+	// Полуинтервал [CreatedAfter, CreatedBefore)
+	after, before := created[1].CreatedAt, created[3].CreatedAt
+	users, total, err = repo.List(ctx, models.UserFilter{Email: domain, CreatedAfter: &after, CreatedBefore: &before}, models.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, emails(created[1:3]), emails(users))
+
+	// Несколько условий одновременно
+	users, total, err = repo.List(ctx, models.UserFilter{Email: domain, LastName: "needle"}, models.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{created[2].Email}, emails(users))
+}
+
+func testListEscapesPatterns(t *testing.T, repo repository.UserRepository, domain string) {
+	literal := mustCreate(t, repo, newUser("a_b", domain))
+	mustCreate(t, repo, newUser("axb", domain))
+	percent := mustCreate(t, repo, newUser("c%d", domain))
+	mustCreate(t, repo, newUser("cxxd", domain))
+
+	users, _, err := repo.List(context.Background(), models.UserFilter{Email: "a_b@" + domain}, models.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{literal.Email}, emails(users))
+
+	users, _, err = repo.List(context.Background(), models.UserFilter{Email: "c%d@" + domain}, models.Page{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{percent.Email}, emails(users))
+}
+
+func testFindInBatches(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	var want []string
+	for i := 0; i < 5; i++ {
+		want = append(want, mustCreate(t, repo, newUser(fmt.Sprintf("batch%d", i), domain)).Email)
+	}
+
+	var got []string
+	var sizes []int
+	err := repo.FindInBatches(ctx, models.UserFilter{Email: domain}, 2, func(users []*models.User) error {
+		sizes = append(sizes, len(users))
+		got = append(got, emails(users)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+	assert.ElementsMatch(t, want, got)
+
+	// Ошибка fn прерывает чтение и возвращается вызывающему
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.FindInBatches(ctx, models.UserFilter{Email: domain}, 2, func(users []*models.User) error {
+		calls++
+		return stop
+	})
+	assert.True(t, errors.Is(err, stop), "got %v", err)
+	assert.Equal(t, 1, calls)
+}
+
+func testSearch(t *testing.T, repo repository.UserRepository, domain string) {
+	ctx := context.Background()
+	target := mustCreate(t, repo, newUser("search", domain))
+	other := newUser("other", domain)
+	other.FirstName = "Other" + randomLetters(8)
+	mustCreate(t, repo, other)
+
+	// Префикс имени без учета регистра
+	results, err := repo.Search(ctx, strings.ToUpper(target.FirstName[:9]), 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, target.ID, results[0].User.ID)
+	assert.NotContains(t, resultIDs(results), other.ID)
+
+	// Все слова запроса должны совпасть
+	results, err = repo.Search(ctx, target.FirstName+" "+target.LastName, 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, target.ID, results[0].User.ID)
+
+	// Пустой запрос ничего не находит
+	results, err = repo.Search(ctx, "  ", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func resultIDs(results []*models.UserSearchResult) []uuid.UUID {
+	ids := make([]uuid.UUID, len(results))
+	for i, result := range results {
+		ids[i] = result.User.ID
+	}
+	return ids
+}
+
+func testCommitAndRollback(t *testing.T, repo repository.UserRepository, transactor repository.Transactor, domain string) {
+	ctx := context.Background()
+
+	var committed *models.User
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		committed = newUser("commit", domain)
+		return repo.Create(ctx, committed)
+	})
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, committed.ID)
+	assert.NoError(t, err)
+
+	failure := errors.New("rollback")
+	var rolledBack *models.User
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		rolledBack = newUser("rollback", domain)
+		if err := repo.Create(ctx, rolledBack); err != nil {
+			return err
+		}
+		// Внутри транзакции изменения видны
+		if _, err := repo.GetByID(ctx, rolledBack.ID); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.True(t, errors.Is(err, failure), "got %v", err)
+	_, err = repo.GetByID(ctx, rolledBack.ID)
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "got %v", err)
+}
+
+func testGetByIDForUpdate(t *testing.T, repo repository.UserRepository, transactor repository.Transactor, domain string) {
+	ctx := context.Background()
+	user := mustCreate(t, repo, newUser("locked", domain))
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		found, err := repo.GetByIDForUpdate(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, user.Email, found.Email)
+		assert.Equal(t, user.Password, found.Password)
+
+		found.FirstName = "Locked"
+		if err := repo.Update(ctx, found); err != nil {
+			return err
+		}
+
+		_, err = repo.GetByIDForUpdate(ctx, uuid.New())
+		assert.True(t, errors.Is(err, repository.ErrUserNotFound), "got %v", err)
+		return nil
+	})
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Locked", found.FirstName)
+}
+
+func testNestedRollback(t *testing.T, repo repository.UserRepository, transactor repository.Transactor, domain string) {
+	ctx := context.Background()
+	outer, inner := newUser("outer", domain), newUser("inner", domain)
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, outer); err != nil {
+			return err
+		}
+		nestedErr := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, inner); err != nil {
+				return err
+			}
+			return errors.New("inner failure")
+		})
+		assert.Error(t, nestedErr)
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(ctx, outer.ID)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(ctx, inner.ID)
+	assert.True(t, errors.Is(err, repository.ErrUserNotFound), "got %v", err)
+}
+
+func testAfterCommit(t *testing.T, repo repository.UserRepository, transactor repository.Transactor, domain string) {
+	ctx := context.Background()
+
+	ran := false
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		assert.True(t, repository.InTransaction(ctx))
+		repository.AfterCommit(ctx, func() { ran = true })
+		assert.False(t, ran, "hook must wait for commit")
+		return repo.Create(ctx, newUser("hook", domain))
+	})
+	require.NoError(t, err)
+	assert.True(t, ran)
+
+	ran = false
+	_ = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		repository.AfterCommit(ctx, func() { ran = true })
+		return errors.New("rollback")
+	})
+	assert.False(t, ran, "hook must not run after rollback")
+}
@@ -0,0 +1,147 @@
+package repotest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunSessionRepositoryTests проверяет реализацию repository.SessionRepository.
+// Сессии создаются для случайных пользователей, поэтому тесты не мешают друг другу.
+func RunSessionRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.SessionRepository) {
+	tests := map[string]func(t *testing.T, repo repository.SessionRepository){
+		"CreateGetUpdateDelete": testSessionLifecycle,
+		"ListByUserID":          testSessionListByUserID,
+		"DeleteByUserID":        testSessionDeleteByUserID,
+		"DeleteExpired":         testSessionDeleteExpired,
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// newSession создает в репозитории сессию пользователя userID, созданную в момент at и истекающую через ttl
+func newSession(t *testing.T, repo repository.SessionRepository, userID *uuid.UUID, at time.Time, ttl time.Duration) *models.Session {
+	id := make([]byte, 32)
+	_, err := rand.Read(id)
+	require.NoError(t, err)
+
+	session := &models.Session{
+		ID:         hex.EncodeToString(id),
+		UserID:     userID,
+		Data:       []byte("payload"),
+		UserAgent:  "repotest",
+		SourceIP:   "192.0.2.1",
+		CreatedAt:  at,
+		LastSeenAt: at,
+		ExpiresAt:  at.Add(ttl),
+	}
+	require.NoError(t, repo.Create(context.Background(), session))
+	return session
+}
+
+func sessionIDs(sessions []*models.Session) []string {
+	ids := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	return ids
+}
+
+func testSessionLifecycle(t *testing.T, repo repository.SessionRepository) {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Now().Truncate(timePrecision)
+	session := newSession(t, repo, nil, now, time.Hour)
+
+	got, err := repo.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.UserID)
+	assert.Equal(t, []byte("payload"), got.Data)
+	assert.Equal(t, "repotest", got.UserAgent)
+	assert.WithinDuration(t, session.ExpiresAt, got.ExpiresAt, timePrecision)
+
+	got.UserID = &userID
+	got.Data = []byte("updated")
+	got.LastSeenAt = now.Add(time.Minute)
+	got.ExpiresAt = now.Add(2 * time.Hour)
+	require.NoError(t, repo.Update(ctx, got))
+
+	updated, err := repo.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, updated.UserID)
+	assert.Equal(t, userID, *updated.UserID)
+	assert.Equal(t, []byte("updated"), updated.Data)
+	assert.WithinDuration(t, now.Add(2*time.Hour), updated.ExpiresAt, timePrecision)
+	assert.WithinDuration(t, now, updated.CreatedAt, timePrecision)
+
+	require.NoError(t, repo.Delete(ctx, session.ID))
+	_, err = repo.GetByID(ctx, session.ID)
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+	assert.ErrorIs(t, repo.Update(ctx, updated), repository.ErrSessionNotFound)
+}
+
+func testSessionListByUserID(t *testing.T, repo repository.SessionRepository) {
+	userID, otherID := uuid.New(), uuid.New()
+	now := time.Now().Truncate(timePrecision)
+
+	older := newSession(t, repo, &userID, now.Add(-time.Hour), 2*time.Hour)
+	newer := newSession(t, repo, &userID, now.Add(-time.Minute), 2*time.Hour)
+	newSession(t, repo, &userID, now.Add(-3*time.Hour), time.Hour) // истекла
+	newSession(t, repo, &otherID, now, time.Hour)
+	newSession(t, repo, nil, now, time.Hour)
+
+	sessions, err := repo.ListByUserID(context.Background(), userID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{newer.ID, older.ID}, sessionIDs(sessions))
+}
+
+func testSessionDeleteByUserID(t *testing.T, repo repository.SessionRepository) {
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	newSession(t, repo, &userID, now, time.Hour)
+	newSession(t, repo, &userID, now, time.Hour)
+	other := newSession(t, repo, &otherID, now, time.Hour)
+
+	deleted, err := repo.DeleteByUserID(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	sessions, err := repo.ListByUserID(ctx, userID, now)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = repo.GetByID(ctx, other.ID)
+	assert.NoError(t, err)
+}
+
+func testSessionDeleteExpired(t *testing.T, repo repository.SessionRepository) {
+	ctx := context.Background()
+	// Момент далеко в будущем: все ранее созданные сессии к нему истекут, а эти - еще нет
+	now := time.Now().Add(100 * 24 * time.Hour).Truncate(timePrecision)
+
+	expired := newSession(t, repo, nil, now.Add(-2*time.Hour), time.Hour)
+	active := newSession(t, repo, nil, now.Add(-2*time.Hour), 3*time.Hour)
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = repo.GetByID(ctx, expired.ID)
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+	_, err = repo.GetByID(ctx, active.ID)
+	assert.NoError(t, err)
+}
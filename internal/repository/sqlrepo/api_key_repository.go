@@ -0,0 +1,90 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Убедимся что APIKeyRepository реализует интерфейс repository.APIKeyRepository
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// APIKeyRepository представляет хранилище API-ключей в БД
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository создает новый экземпляр APIKeyRepository
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create создает ключ
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if key.ExpiresAt != nil {
+		expiresAt := utc(*key.ExpiresAt)
+		key.ExpiresAt = &expiresAt
+	}
+	return conn(ctx, r.db).Create(key).Error
+}
+
+// GetByID получает ключ по ID
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+// GetByPrefix получает ключ по видимому префиксу
+func (r *APIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return r.get(ctx, "prefix = ?", prefix)
+}
+
+func (r *APIKeyRepository) get(ctx context.Context, query string, arg interface{}) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := conn(ctx, r.db).Where(query, arg).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List получает ключи от новых к старым, при userID не nil - только ключи пользователя
+func (r *APIKeyRepository) List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error) {
+	db := conn(ctx, r.db)
+	if userID != nil {
+		db = db.Where("user_id = ?", *userID)
+	}
+	var keys []*models.APIKey
+	if err := db.Order("created_at DESC, id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return conn(ctx, r.db).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", utc(at)).Error
+}
+
+// TouchLastUsed сохраняет время последнего использования ключа
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := conn(ctx, r.db).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", utc(at))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrAPIKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package sqlrepo
+
+import (
+	"context"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Убедимся что AuditRepository реализует интерфейс repository.AuditRepository
+var _ repository.AuditRepository = (*AuditRepository)(nil)
+
+// AuditRepository представляет журнал аудита в БД
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create добавляет событие в журнал
+func (r *AuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return conn(ctx, r.db).Create(event).Error
+}
+
+// ListByUserID получает страницу событий пользователя
+func (r *AuditRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error) {
+	db := conn(ctx, r.db)
+
+	var total int64
+	if err := db.Model(&models.AuditEvent{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []*models.AuditEvent
+	query := db.Where("user_id = ?", userID).Order("created_at DESC, id").Offset(page.Offset)
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
@@ -0,0 +1,52 @@
+// Package sqlrepo содержит реализации репозиториев поверх GORM для PostgreSQL и SQLite.
+// Запросы, которые различаются между СУБД, выбираются по диалекту подключения.
+package sqlrepo
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// isPostgres сообщает, подключен ли db к PostgreSQL
+func isPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == "postgres"
+}
+
+// utc приводит время к UTC перед записью или сравнением.
+// SQLite хранит время строкой и сравнивает его как строку, поэтому все значения должны быть в одной зоне;
+// для PostgreSQL (timestamptz) преобразование ничего не меняет.
+func utc(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC()
+}
+
+// uniqueViolation сообщает, нарушено ли ограничение уникальности, и возвращает имя нарушенного ограничения.
+// PostgreSQL сообщает имя ограничения (индекса) отдельным полем. SQLite указывает его в тексте ошибки:
+// "index 'имя'" для индекса по выражению или "таблица.столбец, ..." для индекса по столбцам.
+func uniqueViolation(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName, pgErr.Code == "23505"
+	}
+	_, constraint, ok := strings.Cut(err.Error(), "UNIQUE constraint failed: ")
+	if !ok {
+		return "", false
+	}
+	// Код ошибки SQLite в конце сообщения: "... (2067)"
+	if i := strings.LastIndex(constraint, " ("); i >= 0 {
+		constraint = constraint[:i]
+	}
+	if name, ok := strings.CutPrefix(constraint, "index '"); ok {
+		constraint = strings.TrimSuffix(name, "'")
+	}
+	return constraint, true
+}
@@ -0,0 +1,67 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Убедимся что IdentityRepository реализует интерфейс repository.IdentityRepository
+var _ repository.IdentityRepository = (*IdentityRepository)(nil)
+
+// IdentityRepository представляет хранилище учетных записей внешних поставщиков в БД
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository создает новый экземпляр IdentityRepository
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// Create привязывает учетную запись поставщика к пользователю.
+// Единственный уникальный индекс таблицы помимо первичного ключа - по паре provider и subject.
+func (r *IdentityRepository) Create(ctx context.Context, identity *models.Identity) error {
+	err := conn(ctx, r.db).Create(identity).Error
+	if _, ok := uniqueViolation(err); ok {
+		return repository.ErrIdentityAlreadyLinked
+	}
+	return err
+}
+
+// GetByProviderSubject получает учетную запись по поставщику и идентификатору пользователя у него
+func (r *IdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	if err := conn(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListByUserID получает учетные записи пользователя в порядке привязки
+func (r *IdentityRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error) {
+	var identities []*models.Identity
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at, id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Delete отвязывает учетную запись
+func (r *IdentityRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result := conn(ctx, r.db).Delete(&models.Identity{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrIdentityNotFound
+	}
+	return nil
+}
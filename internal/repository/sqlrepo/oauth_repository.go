@@ -0,0 +1,138 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Убедимся что OAuthRepository реализует интерфейс repository.OAuthRepository
+var _ repository.OAuthRepository = (*OAuthRepository)(nil)
+
+// OAuthRepository представляет хранилище сервера авторизации в БД
+type OAuthRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthRepository создает новый экземпляр OAuthRepository
+func NewOAuthRepository(db *gorm.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// CreateClient регистрирует клиента
+func (r *OAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	return conn(ctx, r.db).Create(client).Error
+}
+
+// GetClient получает клиента по ID
+func (r *OAuthRepository) GetClient(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&client).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrOAuthClientNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// ListClients получает клиентов в порядке регистрации
+func (r *OAuthRepository) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	var clients []*models.OAuthClient
+	if err := conn(ctx, r.db).Order("created_at, id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// DeleteClient удаляет клиента вместе с его кодами и токенами
+func (r *OAuthRepository) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.OAuthClient{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrOAuthClientNotFound
+		}
+		if err := tx.Delete(&models.OAuthAuthorizationCode{}, "client_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.OAuthToken{}, "client_id = ?", id).Error
+	})
+}
+
+// CreateCode сохраняет код авторизации
+func (r *OAuthRepository) CreateCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	code.ExpiresAt = utc(code.ExpiresAt)
+	return conn(ctx, r.db).Create(code).Error
+}
+
+// ConsumeCode удаляет код авторизации и возвращает его. Из параллельных запросов с одним кодом
+// код получает только тот, чье удаление затронуло запись.
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	db := conn(ctx, r.db)
+	var code models.OAuthAuthorizationCode
+	if err := db.Where("code_hash = ?", codeHash).First(&code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrOAuthCodeNotFound
+		}
+		return nil, err
+	}
+
+	result := db.Delete(&models.OAuthAuthorizationCode{}, "code_hash = ?", codeHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, repository.ErrOAuthCodeNotFound
+	}
+	return &code, nil
+}
+
+// CreateToken сохраняет токен доступа
+func (r *OAuthRepository) CreateToken(ctx context.Context, token *models.OAuthToken) error {
+	token.ExpiresAt = utc(token.ExpiresAt)
+	return conn(ctx, r.db).Create(token).Error
+}
+
+// GetTokenByHash получает токен доступа по хешу
+func (r *OAuthRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.OAuthToken, error) {
+	var token models.OAuthToken
+	if err := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrOAuthTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeToken отзывает токен доступа
+func (r *OAuthRepository) RevokeToken(ctx context.Context, id uuid.UUID, at time.Time) error {
+	var count int64
+	if err := conn(ctx, r.db).Model(&models.OAuthToken{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return repository.ErrOAuthTokenNotFound
+	}
+	return conn(ctx, r.db).Model(&models.OAuthToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", utc(at)).Error
+}
+
+// DeleteExpired удаляет истекшие коды авторизации и токены
+func (r *OAuthRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	codes := conn(ctx, r.db).Delete(&models.OAuthAuthorizationCode{}, "expires_at <= ?", utc(now))
+	if codes.Error != nil {
+		return 0, codes.Error
+	}
+	tokens := conn(ctx, r.db).Delete(&models.OAuthToken{}, "expires_at <= ?", utc(now))
+	return codes.RowsAffected + tokens.RowsAffected, tokens.Error
+}
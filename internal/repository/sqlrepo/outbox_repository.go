@@ -0,0 +1,99 @@
+package sqlrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Убедимся что OutboxRepository реализует интерфейс repository.OutboxRepository
+var _ repository.OutboxRepository = (*OutboxRepository)(nil)
+
+// outboxLockKey - ключ advisory-блокировки публикации событий
+const outboxLockKey = 7100321
+
+// OutboxRepository представляет хранилище исходящих событий в БД
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository создает новый экземпляр OutboxRepository
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Create записывает событие; если время попытки не задано, событие можно публиковать сразу
+// (см. OutboxEvent.BeforeCreate)
+func (r *OutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	event.NextAttemptAt = utc(event.NextAttemptAt)
+	return conn(ctx, r.db).Create(event).Error
+}
+
+// FetchPending получает неопубликованные события, время попытки которых подошло.
+// События пользователей, ожидающих повтора более раннего события, отсекаются в самом запросе,
+// поэтому они не занимают места в порции и не задерживают события остальных пользователей.
+func (r *OutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	now = utc(now)
+	var events []*models.OutboxEvent
+	err := conn(ctx, r.db).
+		Where("published_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier
+			WHERE earlier.aggregate_id = outbox_events.aggregate_id AND earlier.id < outbox_events.id
+			AND earlier.published_at IS NULL AND earlier.dead_at IS NULL AND earlier.next_attempt_at > ?)`, now).
+		Order("id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Schedule назначает событиям время следующей попытки публикации
+func (r *OutboxRepository) Schedule(ctx context.Context, ids []uint64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", utc(at)).Error
+}
+
+// MarkPublished отмечает событие как опубликованное
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uint64) error {
+	return conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published_at": utc(time.Now()),
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	}).Error
+}
+
+// MarkFailed сохраняет причину неудачной публикации и время следующей попытки
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	return conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      reason,
+		"next_attempt_at": utc(nextAttemptAt),
+	}).Error
+}
+
+// MarkDead переводит событие в dead letter
+func (r *OutboxRepository) MarkDead(ctx context.Context, id uint64, reason string) error {
+	return conn(ctx, r.db).Model(&models.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"dead_at":    utc(time.Now()),
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+	}).Error
+}
+
+// TryLock захватывает транзакционную advisory-блокировку PostgreSQL.
+// В SQLite транзакции открываются с блокировкой записи (см. database.NewSQLiteDB) и выполняются
+// последовательно, поэтому отдельная блокировка не нужна.
+func (r *OutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	if !isPostgres(r.db) {
+		return true, nil
+	}
+
+	var locked bool
+	err := conn(ctx, r.db).Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error
+	return locked, err
+}
@@ -0,0 +1,41 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ReadReplicas выбирает подключение к реплике для очередного чтения (реализуется database.ReplicaSet).
+// Если доступных реплик нет, реализация возвращает подключение к основной базе.
+type ReadReplicas interface {
+	Reader() *gorm.DB
+}
+
+// reader возвращает подключение для чтения пользователей: транзакцию из ctx или основную базу,
+// если этого требует repository.ReadsFromPrimary, иначе реплику; replica сообщает, что выбрана реплика
+func (r *UserRepository) reader(ctx context.Context) (db *gorm.DB, replica bool) {
+	if r.replicas == nil || repository.ReadsFromPrimary(ctx) {
+		return conn(ctx, r.db), false
+	}
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return conn(ctx, r.db), false
+	}
+	return r.replicas.Reader().WithContext(ctx), true
+}
+
+// read выполняет запрос fn на подключении из reader. Реплика может стать недоступной между проверками
+// ее состояния, поэтому неудачный запрос к реплике повторяется на основной базе; отсутствие записи
+// и отмена ctx ошибками реплики не считаются.
+func (r *UserRepository) read(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db, replica := r.reader(ctx)
+	err := fn(db)
+	if !replica || err == nil || errors.Is(err, gorm.ErrRecordNotFound) || ctx.Err() != nil {
+		return err
+	}
+	log.Printf("Read from replica failed, retrying on primary: %v", err)
+	return fn(conn(ctx, r.db))
+}
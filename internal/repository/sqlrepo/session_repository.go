@@ -0,0 +1,97 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Убедимся что SessionRepository реализует интерфейс repository.SessionRepository
+var _ repository.SessionRepository = (*SessionRepository)(nil)
+
+// SessionRepository представляет хранилище сессий веб-интерфейса в БД
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository создает новый экземпляр SessionRepository
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create создает сессию
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	normalizeSessionTimes(session)
+	return conn(ctx, r.db).Create(session).Error
+}
+
+// GetByID получает сессию по ID
+func (r *SessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update сохраняет данные, владельца и сроки сессии; если сессия уже удалена, возвращается repository.ErrSessionNotFound
+func (r *SessionRepository) Update(ctx context.Context, session *models.Session) error {
+	normalizeSessionTimes(session)
+	result := conn(ctx, r.db).Model(&models.Session{}).Where("id = ?", session.ID).Updates(map[string]interface{}{
+		"user_id":      session.UserID,
+		"data":         session.Data,
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrSessionNotFound
+	}
+	return nil
+}
+
+// Delete удаляет сессию
+func (r *SessionRepository) Delete(ctx context.Context, id string) error {
+	return conn(ctx, r.db).Delete(&models.Session{}, "id = ?", id).Error
+}
+
+// ListByUserID получает действующие сессии пользователя, начиная с последней активной
+func (r *SessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND expires_at > ?", userID, utc(now)).
+		Order("last_seen_at DESC, id").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteByUserID удаляет все сессии пользователя
+func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := conn(ctx, r.db).Delete(&models.Session{}, "user_id = ?", userID)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteExpired удаляет истекшие сессии
+func (r *SessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Delete(&models.Session{}, "expires_at <= ?", utc(now))
+	return result.RowsAffected, result.Error
+}
+
+func normalizeSessionTimes(session *models.Session) {
+	session.CreatedAt = utc(session.CreatedAt)
+	session.LastSeenAt = utc(session.LastSeenAt)
+	session.ExpiresAt = utc(session.ExpiresAt)
+}
@@ -0,0 +1,46 @@
+package sqlrepo
+
+import (
+	"context"
+
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Убедимся что Transactor реализует интерфейс repository.Transactor
+var _ repository.Transactor = (*Transactor)(nil)
+
+// txKey - ключ контекста, под которым хранится текущая транзакция
+type txKey struct{}
+
+// Transactor управляет транзакциями БД
+type Transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor создает новый экземпляр Transactor
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTransaction выполняет fn в транзакции; если в ctx уже есть транзакция, создается точка сохранения.
+// Функции, зарегистрированные через repository.AfterCommit, выполняются после фиксации внешней транзакции.
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, runHooks := repository.WithCommitHooks(ctx)
+	err := conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		return err
+	}
+	runHooks()
+	return nil
+}
+
+// conn возвращает транзакцию из ctx, если она есть, иначе основное подключение
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,188 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Убедимся что UserRepository реализует интерфейс repository.UserRepository
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// UserRepository представляет интерфейс для работы с пользователями в БД
+type UserRepository struct {
+	db       *gorm.DB
+	replicas ReadReplicas
+}
+
+// NewUserRepository создает новый экземпляр UserRepository
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// WithReplicas направляет чтения пользователей вне транзакций на реплики; после записи в той же
+// области согласованности (repository.WithReadYourWrites) чтения снова выполняются на основной базе
+func (r *UserRepository) WithReplicas(replicas ReadReplicas) *UserRepository {
+	r.replicas = replicas
+	return r
+}
+
+// Create создает нового пользователя
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	user.CreatedAt, user.UpdatedAt = utc(user.CreatedAt), utc(user.UpdatedAt)
+	repository.MarkWritten(ctx)
+	return translateError(conn(ctx, r.db).Create(user).Error)
+}
+
+// GetByID получает пользователя по ID
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.read(ctx, func(db *gorm.DB) error {
+		return db.Where("id = ?", id).First(&user).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByEmail получает пользователя по email без учета регистра
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.read(ctx, func(db *gorm.DB) error {
+		return db.Where("lower(email) = lower(?)", email).First(&user).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIDForUpdate получает пользователя с блокировкой SELECT ... FOR UPDATE.
+// SQLite не поддерживает блокировку строк и пропускает ее: там пишущие транзакции и так выполняются по очереди.
+func (r *UserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Update обновляет данные пользователя
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	repository.MarkWritten(ctx)
+	return translateError(conn(ctx, r.db).Save(user).Error)
+}
+
+// Delete удаляет пользователя
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	repository.MarkWritten(ctx)
+	return conn(ctx, r.db).Delete(&models.User{}, "id = ?", id).Error
+}
+
+// GetAll получает список всех пользователей
+func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
+	var users []*models.User
+	err := r.read(ctx, func(db *gorm.DB) error {
+		return db.Find(&users).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// List получает страницу пользователей, подходящих под фильтр, и их общее количество
+func (r *UserRepository) List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+	err := r.read(ctx, func(db *gorm.DB) error {
+		if err := applyUserFilter(db.Model(&models.User{}), filter).Count(&total).Error; err != nil {
+			return err
+		}
+		query := applyUserFilter(db, filter).Order("created_at, id").Offset(page.Offset)
+		if page.Limit > 0 {
+			query = query.Limit(page.Limit)
+		}
+		return query.Find(&users).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// FindInBatches читает пользователей порциями по первичному ключу.
+// На основную базу чтение переносится, только если реплика отказала до первой порции: иначе fn получила бы
+// уже переданных пользователей повторно.
+func (r *UserRepository) FindInBatches(ctx context.Context, filter models.UserFilter, batchSize int, fn func(users []*models.User) error) error {
+	var batch []*models.User
+	started := false
+	var fnErr error
+	err := r.read(ctx, func(db *gorm.DB) error {
+		if started {
+			return fnErr
+		}
+		return applyUserFilter(db, filter).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			started = true
+			fnErr = fn(batch)
+			return fnErr
+		}).Error
+	})
+	return err
+}
+
+// emailUniqueIndex - уникальный индекс по lower(email), см. database.Migrate
+const emailUniqueIndex = "idx_users_email_lower"
+
+// translateError приводит ошибки БД к ошибкам пакета repository.
+// Занятым email считается только нарушение индекса emailUniqueIndex; остальные ошибки,
+// в том числе нарушение первичного ключа, возвращаются как есть.
+func translateError(err error) error {
+	if constraint, ok := uniqueViolation(err); ok && constraint == emailUniqueIndex {
+		return repository.ErrEmailAlreadyExists
+	}
+	return err
+}
+
+// applyUserFilter добавляет к запросу условия фильтра
+func applyUserFilter(db *gorm.DB, filter models.UserFilter) *gorm.DB {
+	if filter.Email != "" {
+		db = db.Where("LOWER(email) LIKE ? ESCAPE '\\'", containsPattern(filter.Email))
+	}
+	if filter.FirstName != "" {
+		db = db.Where("LOWER(first_name) LIKE ? ESCAPE '\\'", containsPattern(filter.FirstName))
+	}
+	if filter.LastName != "" {
+		db = db.Where("LOWER(last_name) LIKE ? ESCAPE '\\'", containsPattern(filter.LastName))
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", utc(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", utc(*filter.CreatedBefore))
+	}
+	return db
+}
+
+// containsPattern строит LIKE-шаблон поиска подстроки без учета регистра
+func containsPattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(value))
+	return "%" + escaped + "%"
+}
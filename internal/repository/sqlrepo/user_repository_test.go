@@ -0,0 +1,340 @@
+package sqlrepo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/repotest"
+	"github.com/Est1ege/go-user-api/internal/repository/sqlrepo"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/Est1ege/go-user-api/pkg/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabases возвращает функции подключения к тестовым базам: SQLite в памяти и в файле - всегда,
+// PostgreSQL - только если задана TEST_DATABASE_DSN
+func testDatabases() map[string]func(t *testing.T) *gorm.DB {
+	databases := map[string]func(t *testing.T) *gorm.DB{
+		"sqlite":      openSQLite,
+		"sqlite_file": openSQLiteFile,
+	}
+	if os.Getenv("TEST_DATABASE_DSN") != "" {
+		databases["postgres"] = openPostgres
+	}
+	return databases
+}
+
+// forEachDB запускает test на каждой тестовой базе
+func forEachDB(t *testing.T, test func(t *testing.T, open func(t *testing.T) *gorm.DB)) {
+	for name, open := range testDatabases() {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			test(t, open)
+		})
+	}
+}
+
+// openSQLite создает новую базу SQLite в памяти с примененными миграциями
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := database.NewSQLiteDB(&config.Config{DB: config.DBConfig{Path: database.SQLiteInMemory}})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db.Session(&gorm.Session{Logger: logger.Discard})
+}
+
+// openSQLiteFile создает базу SQLite во временном файле. В отличие от базы в памяти у нее пул
+// из нескольких соединений, поэтому конкурентные тесты действительно выполняются параллельно.
+func openSQLiteFile(t *testing.T) *gorm.DB {
+	db, err := database.NewSQLiteDB(&config.Config{DB: config.DBConfig{
+		Path:         filepath.Join(t.TempDir(), "test.db"),
+		MaxOpenConns: 8,
+		MaxIdleConns: 8,
+	}})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db.Session(&gorm.Session{Logger: logger.Discard})
+}
+
+// openPostgres подключается к тестовой базе из TEST_DATABASE_DSN и удаляет созданных тестами пользователей
+func openPostgres(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(pgdriver.Open(os.Getenv("TEST_DATABASE_DSN")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM users WHERE email LIKE ?", "%@concurrency.test")
+		db.Exec("DELETE FROM users WHERE email LIKE ?", "%"+repotest.Domain)
+	})
+	return db
+}
+
+func TestUserRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepository {
+			return sqlrepo.NewUserRepository(open(t))
+		})
+	})
+}
+
+func TestTransactor_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunTransactorTests(t, func(t *testing.T) (repository.UserRepository, repository.Transactor) {
+			db := open(t)
+			return sqlrepo.NewUserRepository(db), sqlrepo.NewTransactor(db)
+		})
+	})
+}
+
+func TestSessionRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunSessionRepositoryTests(t, func(t *testing.T) repository.SessionRepository {
+			return sqlrepo.NewSessionRepository(open(t))
+		})
+	})
+}
+
+func TestAPIKeyRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunAPIKeyRepositoryTests(t, func(t *testing.T) repository.APIKeyRepository {
+			return sqlrepo.NewAPIKeyRepository(open(t))
+		})
+	})
+}
+
+func TestIdentityRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunIdentityRepositoryTests(t, func(t *testing.T) repository.IdentityRepository {
+			return sqlrepo.NewIdentityRepository(open(t))
+		})
+	})
+}
+
+func TestOAuthRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunOAuthRepositoryTests(t, func(t *testing.T) repository.OAuthRepository {
+			return sqlrepo.NewOAuthRepository(open(t))
+		})
+	})
+}
+
+func TestOutboxRepository_Conformance(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		repotest.RunOutboxRepositoryTests(t, func(t *testing.T) repository.OutboxRepository {
+			return sqlrepo.NewOutboxRepository(open(t))
+		})
+	})
+}
+
+func TestAuditRepository_AppendOnly(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		db := open(t)
+		repo := sqlrepo.NewAuditRepository(db)
+		ctx := context.Background()
+
+		event := &models.AuditEvent{
+			UserID:  uuid.New(),
+			Action:  models.AuditActionUpdate,
+			Actor:   "tester",
+			Changes: map[string]models.FieldChange{"first_name": {Old: "A", New: "B"}},
+		}
+		require.NoError(t, repo.Create(ctx, event))
+
+		events, total, err := repo.ListByUserID(ctx, event.UserID, models.Page{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, events, 1)
+		assert.Equal(t, "B", events[0].Changes["first_name"].New)
+
+		assert.Error(t, db.Model(&models.AuditEvent{}).Where("id = ?", event.ID).Update("actor", "mallory").Error)
+		assert.Error(t, db.Where("id = ?", event.ID).Delete(&models.AuditEvent{}).Error)
+	})
+}
+
+func TestUserRepository_Create_DuplicateEmailIgnoresCase(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		db := open(t)
+		repo := sqlrepo.NewUserRepository(db)
+		ctx := context.Background()
+
+		err := repo.Create(ctx, &models.User{Email: "dup@concurrency.test", FirstName: "A", LastName: "A"})
+		require.NoError(t, err)
+
+		err = repo.Create(ctx, &models.User{Email: "DUP@concurrency.test", FirstName: "B", LastName: "B"})
+		assert.True(t, errors.Is(err, repository.ErrEmailAlreadyExists), "got %v", err)
+	})
+}
+
+func TestUserRepository_Create_OtherUniqueViolation(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		db := open(t)
+		// Дополнительный уникальный индекс только для тестовых записей
+		require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_test_users_last_name ON users (last_name) WHERE email LIKE '%@concurrency.test'").Error)
+		t.Cleanup(func() { db.Exec("DROP INDEX idx_test_users_last_name") })
+
+		repo := sqlrepo.NewUserRepository(db)
+		ctx := context.Background()
+		require.NoError(t, repo.Create(ctx, &models.User{Email: "unique1@concurrency.test", FirstName: "A", LastName: "Same"}))
+
+		// Нарушение другого ограничения - не занятый email
+		err := repo.Create(ctx, &models.User{Email: "unique2@concurrency.test", FirstName: "B", LastName: "Same"})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, repository.ErrEmailAlreadyExists), "got %v", err)
+	})
+}
+
+func TestUserService_Create_ConcurrentSameEmail(t *testing.T) {
+	forEachDB(t, func(t *testing.T, open func(t *testing.T) *gorm.DB) {
+		db := open(t)
+		userService := service.NewUserService(
+			sqlrepo.NewUserRepository(db),
+			sqlrepo.NewAuditRepository(db),
+			sqlrepo.NewOutboxRepository(db),
+			sqlrepo.NewTransactor(db),
+		)
+
+		// Адреса отличаются только регистром: после нормализации это один и тот же email
+		emails := []string{"race@concurrency.test", "RACE@concurrency.test", "Race@Concurrency.test"}
+
+		const attempts = 8
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, attempts)
+
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				_, errs[i] = userService.Create(context.Background(), models.CreateUserInput{
+					Email:     emails[i%len(emails)],
+					FirstName: "Race",
+					LastName:  fmt.Sprint(i),
+					Password:  "password123",
+				})
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			assert.True(t, errors.Is(err, service.ErrEmailAlreadyExists), "unexpected error: %v", err)
+		}
+		assert.Equal(t, 1, succeeded)
+	})
+}
+
+// staticReplica - реплика для тестов маршрутизации чтений: всегда возвращает одно подключение
+type staticReplica struct {
+	db *gorm.DB
+}
+
+func (r staticReplica) Reader() *gorm.DB {
+	return r.db
+}
+
+func TestUserRepository_ReadReplicaRouting(t *testing.T) {
+	// Реплика - отдельная пустая база, поэтому по результату чтения видно, куда оно было направлено
+	primary, replica := openSQLite(t), openSQLite(t)
+	repo := sqlrepo.NewUserRepository(primary).WithReplicas(staticReplica{db: replica})
+	transactor := sqlrepo.NewTransactor(primary)
+
+	user := &models.User{Email: "replica@concurrency.test", FirstName: "Read", LastName: "Replica"}
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	t.Run("Reads go to replica", func(t *testing.T) {
+		_, err := repo.GetByID(context.Background(), user.ID)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+		_, total, err := repo.List(context.Background(), models.UserFilter{}, models.Page{Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("Reads after write in the same scope go to primary", func(t *testing.T) {
+		ctx := repository.WithReadYourWrites(context.Background())
+
+		_, err := repo.GetByEmail(ctx, user.Email)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+		user.FirstName = "Updated"
+		require.NoError(t, repo.Update(ctx, user))
+
+		found, err := repo.GetByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", found.FirstName)
+	})
+
+	t.Run("Reads in transaction go to primary", func(t *testing.T) {
+		err := transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+			_, err := repo.GetByID(ctx, user.ID)
+			return err
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserRepository_ReadReplicaFallback(t *testing.T) {
+	// Реплика с закрытым подключением отвечает ошибкой на любой запрос, как отказавшая между проверками
+	primary, replica := openSQLite(t), openSQLite(t)
+	sqlDB, err := replica.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	repo := sqlrepo.NewUserRepository(primary).WithReplicas(staticReplica{db: replica})
+
+	ctx := context.Background()
+	user := &models.User{Email: "fallback@concurrency.test", FirstName: "Read", LastName: "Fallback"}
+	require.NoError(t, repo.Create(ctx, user))
+
+	found, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, found.Email)
+
+	_, err = repo.GetByEmail(ctx, user.Email)
+	assert.NoError(t, err)
+
+	_, total, err := repo.List(ctx, models.UserFilter{}, models.Page{Limit: 10})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	var batched int
+	err = repo.FindInBatches(ctx, models.UserFilter{}, 10, func(users []*models.User) error {
+		batched += len(users)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, batched)
+
+	results, err := repo.Search(ctx, "fallback", 10)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
@@ -0,0 +1,95 @@
+package sqlrepo
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"gorm.io/gorm"
+)
+
+// searchDocument - выражение, по которому построены поисковые индексы (см. миграцию 0001_users_search_indexes)
+const searchDocument = `(coalesce(email, '') || ' ' || coalesce(first_name, '') || ' ' || coalesce(last_name, ''))`
+
+// searchRow - строка результата поиска
+type searchRow struct {
+	models.User `gorm:"embedded"`
+	Rank        float64
+}
+
+// Search ищет пользователей. В PostgreSQL используются полнотекстовый индекс (совпадение по префиксу слов)
+// и триграммный индекс (подстроки и опечатки), в остальных СУБД - упрощенный поиск (см. models.User.SearchRank).
+func (r *UserRepository) Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error) {
+	terms := models.SearchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if !isPostgres(r.db) {
+		return r.searchWithoutIndexes(ctx, terms, limit)
+	}
+
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	tsQuery := strings.Join(prefixes, " & ")
+	term := strings.ToLower(strings.TrimSpace(query))
+
+	var rows []searchRow
+	err := r.read(ctx, func(db *gorm.DB) error {
+		return db.Raw(`
+			SELECT users.*,
+				ts_rank(to_tsvector('simple', `+searchDocument+`), to_tsquery('simple', @tsquery)) * 2
+					+ word_similarity(@term, lower(`+searchDocument+`)) AS rank
+			FROM users
+			WHERE to_tsvector('simple', `+searchDocument+`) @@ to_tsquery('simple', @tsquery)
+				OR lower(`+searchDocument+`) LIKE @pattern ESCAPE '\'
+				OR @term <% lower(`+searchDocument+`)
+			ORDER BY rank DESC, created_at
+			LIMIT @limit`,
+			map[string]interface{}{
+				"tsquery": tsQuery,
+				"term":    term,
+				"pattern": containsPattern(term),
+				"limit":   limit,
+			},
+		).Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.UserSearchResult, len(rows))
+	for i := range rows {
+		user := rows[i].User
+		results[i] = &models.UserSearchResult{User: &user, Rank: rows[i].Rank}
+	}
+	return results, nil
+}
+
+// searchWithoutIndexes отбирает в БД пользователей, содержащих все слова запроса, и ранжирует их в приложении
+func (r *UserRepository) searchWithoutIndexes(ctx context.Context, terms []string, limit int) ([]*models.UserSearchResult, error) {
+	var users []*models.User
+	err := r.read(ctx, func(db *gorm.DB) error {
+		for _, term := range terms {
+			db = db.Where("lower("+searchDocument+") LIKE ? ESCAPE '\\'", containsPattern(term))
+		}
+		return db.Order("created_at").Find(&users).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*models.UserSearchResult
+	for _, user := range users {
+		if rank, ok := user.SearchRank(terms); ok {
+			results = append(results, &models.UserSearchResult{User: user, Rank: rank})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
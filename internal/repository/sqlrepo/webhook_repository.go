@@ -0,0 +1,117 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Убедимся что WebhookRepository реализует интерфейс repository.WebhookRepository
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+// WebhookRepository представляет хранилище подписок и доставок вебхуков в БД
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository создает новый экземпляр WebhookRepository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription создает подписку
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(subscription).Error
+}
+
+// GetSubscription получает подписку по ID
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// ListSubscriptions получает все подписки
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	if err := conn(ctx, r.db).Order("created_at").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Delete(&models.WebhookSubscription{}, "id = ?", id).Error
+}
+
+// CreateDelivery создает доставку
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.NextAttemptAt = utc(delivery.NextAttemptAt)
+	return conn(ctx, r.db).Create(delivery).Error
+}
+
+// GetDelivery получает доставку по ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries получает страницу доставок подписки
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error) {
+	db := conn(ctx, r.db)
+
+	var total int64
+	if err := db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	query := db.Where("subscription_id = ?", subscriptionID).Order("created_at DESC, id").Offset(page.Offset)
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDueDeliveries получает доставки, которые пора отправить, с блокировкой FOR UPDATE SKIP LOCKED.
+// SQLite не поддерживает блокировку строк и пропускает ее: там транзакции и так выполняются последовательно.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, utc(now)).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.NextAttemptAt = utc(delivery.NextAttemptAt)
+	return conn(ctx, r.db).Save(delivery).Error
+}
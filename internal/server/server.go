@@ -0,0 +1,98 @@
+// Package server запускает HTTP-сервер приложения: по HTTP или по HTTPS с перечитыванием сертификатов,
+// перенаправлением с HTTP на HTTPS и проверкой клиентских сертификатов (mTLS).
+package server
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+)
+
+// ShutdownTimeout - сколько сервер ждет завершения начатых запросов при остановке
+const ShutdownTimeout = 15 * time.Second
+
+// ListenAndServe обслуживает handler на порту cfg.Port. Если в cfg.TLS задан сертификат, сервер работает
+// по HTTPS и, если задан cfg.TLS.RedirectPort, дополнительно слушает HTTP и перенаправляет запросы на HTTPS.
+// При отмене ctx серверы перестают принимать соединения, дожидаются начатых запросов (не дольше
+// ShutdownTimeout) и функция возвращает nil. Иначе возвращает первую ошибку любого из серверов.
+func ListenAndServe(ctx context.Context, cfg config.ServerConfig, handler http.Handler) error {
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: handler}
+	if !cfg.TLS.Enabled() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		return serve(ctx, map[*http.Server]func() error{srv: srv.ListenAndServe})
+	}
+
+	reloader, err := NewReloader(cfg.TLS)
+	if err != nil {
+		return err
+	}
+	go reloader.Run(ctx, cfg.TLS.ReloadInterval)
+
+	srv.TLSConfig = reloader.TLSConfig()
+	log.Printf("Server starting on port %s (HTTPS, TLS %s+, client certificates: %s)", cfg.Port, cfg.TLS.MinVersion, cfg.TLS.ClientAuth)
+	servers := map[*http.Server]func() error{
+		srv: func() error { return srv.ListenAndServeTLS("", "") },
+	}
+	if cfg.TLS.RedirectPort != "" {
+		redirect := &http.Server{Addr: ":" + cfg.TLS.RedirectPort, Handler: RedirectHandler(cfg.Port)}
+		log.Printf("Redirecting HTTP on port %s to HTTPS", cfg.TLS.RedirectPort)
+		servers[redirect] = redirect.ListenAndServe
+	}
+	return serve(ctx, servers)
+}
+
+// serve запускает серверы и ждет первой ошибки или отмены ctx, после которой останавливает их все
+func serve(ctx context.Context, servers map[*http.Server]func() error) error {
+	errs := make(chan error, len(servers))
+	for _, listen := range servers {
+		go func() {
+			errs <- listen()
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	var shutdownErr error
+	for srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+	return shutdownErr
+}
+
+// RedirectHandler перенаправляет запросы на тот же хост и путь по HTTPS на порт httpsPort.
+// GET и HEAD получают 301, остальные методы - 308, чтобы клиент повторил запрос с тем же методом и телом.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Host header is required", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if net.ParseIP(host) != nil && net.ParseIP(host).To4() == nil {
+			host = "[" + host + "]"
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert - сертификат с ключом, подписанный parent (nil - самоподписанный)
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write сохраняет сертификат и ключ в PEM-файлы и возвращает их пути
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// serveTLS запускает сервер с конфигурацией reloader, который отвечает CN клиентского сертификата
+func serveTLS(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", reloader.TLSConfig())
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+		}
+	})}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+	return "https://" + listener.Addr().String()
+}
+
+// servedCert возвращает CN сертификата, который сервер предъявил клиенту
+func servedCert(t *testing.T, url string) string {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+func TestReloader_ReloadsChangedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "first", false, nil).write(t, dir, "server")
+
+	cfg := config.Default().Server.TLS
+	cfg.CertFile, cfg.KeyFile = certFile, keyFile
+	reloader, err := NewReloader(cfg)
+	require.NoError(t, err)
+	url := serveTLS(t, reloader)
+	assert.Equal(t, "first", servedCert(t, url))
+
+	// Без изменений файлы не перечитываются
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// Поврежденный ключ не заменяет рабочий сертификат
+	newTestCert(t, "second", false, nil).write(t, dir, "server")
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0o600))
+	_, err = reloader.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "first", servedCert(t, url))
+
+	newTestCert(t, "second", false, nil).write(t, dir, "server")
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "second", servedCert(t, url))
+}
+
+func TestReloader_ClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "clients-ca", true, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "server", false, nil).write(t, dir, "server")
+
+	// Клиент предъявляет сертификат, даже если сервер не доверяет его издателю
+	get := func(url string, certs ...tls.Certificate) (string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if len(certs) == 0 {
+					return &tls.Certificate{}, nil
+				}
+				return &certs[0], nil
+			},
+		}}}
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		return string(body[:n]), nil
+	}
+
+	trusted := newTestCert(t, "billing-service", false, ca).tlsCertificate()
+	untrusted := newTestCert(t, "intruder", false, nil).tlsCertificate()
+
+	for _, tc := range []struct {
+		clientAuth    string
+		noCertAllowed bool
+	}{
+		{"require", false},
+		{"optional", true},
+	} {
+		t.Run(tc.clientAuth, func(t *testing.T) {
+			cfg := config.Default().Server.TLS
+			cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile, cfg.ClientAuth = certFile, keyFile, caFile, tc.clientAuth
+			reloader, err := NewReloader(cfg)
+			require.NoError(t, err)
+			url := serveTLS(t, reloader)
+
+			name, err := get(url, trusted)
+			require.NoError(t, err)
+			assert.Equal(t, "billing-service", name)
+
+			_, err = get(url, untrusted)
+			assert.Error(t, err)
+
+			_, err = get(url)
+			assert.Equal(t, tc.noCertAllowed, err == nil, err)
+		})
+	}
+}
+
+func TestReloader_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := newTestCert(t, "server", false, nil).write(t, dir, "server")
+
+	cfg := config.Default().Server.TLS
+	cfg.CertFile, cfg.KeyFile = certFile, filepath.Join(dir, "missing.key")
+	_, err := NewReloader(cfg)
+	assert.Error(t, err)
+
+	cfg.KeyFile, cfg.ClientAuth, cfg.ClientCAFile = keyFile, "require", keyFile
+	_, err = NewReloader(cfg)
+	assert.ErrorContains(t, err, "no PEM certificates")
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name, method, host, target, port string
+		wantStatus                       int
+		wantLocation                     string
+	}{
+		{"GET with port", http.MethodGet, "example.com:8080", "/web/users?page=2", "8443", http.StatusMovedPermanently, "https://example.com:8443/web/users?page=2"},
+		{"Default HTTPS port", http.MethodGet, "example.com", "/", "443", http.StatusMovedPermanently, "https://example.com/"},
+		{"POST keeps method", http.MethodPost, "example.com", "/api/v1/users", "443", http.StatusPermanentRedirect, "https://example.com/api/v1/users"},
+		{"IPv6 host", http.MethodGet, "[::1]:8080", "/", "443", http.StatusMovedPermanently, "https://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			RedirectHandler(tt.port).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantLocation, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestListenAndServe_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenAndServe(ctx, config.ServerConfig{Port: "0"}, http.NotFoundHandler())
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(ShutdownTimeout):
+		t.Fatal("server did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+)
+
+// Версии TLS по значению server.tls.min_version
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Режимы проверки клиентских сертификатов по значению server.tls.client_auth
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":     tls.NoClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+	"require":  tls.RequireAndVerifyClientCert,
+}
+
+// fileStamp - время изменения и размер файла, по которым обнаруживается его замена
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloader хранит TLS-конфигурацию сервера и перечитывает сертификат, ключ и сертификаты УЦ клиентов,
+// когда файлы меняются на диске. Новые соединения получают новую конфигурацию, установленные не разрываются.
+// Если новые файлы не загружаются (например, записан только сертификат без ключа), остается прежняя конфигурация.
+type Reloader struct {
+	cfg config.TLSConfig
+
+	mu      sync.RWMutex
+	current *tls.Config
+	stamps  map[string]fileStamp
+}
+
+// NewReloader загружает сертификаты по cfg и возвращает Reloader
+func NewReloader(cfg config.TLSConfig) (*Reloader, error) {
+	r := &Reloader{cfg: cfg}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig возвращает конфигурацию для http.Server, которая выдает каждому соединению текущие сертификаты
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.config().MinVersion,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.config().Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.config(), nil
+		},
+	}
+}
+
+// Reload перечитывает файлы, если они изменились с прошлой загрузки, и сообщает, обновилась ли конфигурация
+func (r *Reloader) Reload() (bool, error) {
+	stamps, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.current != nil && sameStamps(r.stamps, stamps)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	tlsConfig, err := r.load()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	r.current, r.stamps = tlsConfig, stamps
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Run проверяет файлы каждые interval до отмены ctx
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				log.Printf("TLS certificate reload failed, keeping the previous certificate: %v", err)
+			} else if reloaded {
+				log.Printf("TLS certificate reloaded from %s", r.cfg.CertFile)
+			}
+		}
+	}
+}
+
+func (r *Reloader) config() *tls.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// files возвращает файлы, за изменением которых следит Reloader
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) stat() (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, stamp := range a {
+		if other, ok := b[file]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+// load собирает TLS-конфигурацию из файлов
+func (r *Reloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+
+	minVersion, ok := tlsVersions[r.cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS version %q", r.cfg.MinVersion)
+	}
+	cipherSuites, err := cipherSuiteIDs(r.cfg.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, ok := clientAuthTypes[r.cfg.ClientAuth]
+	if !ok {
+		return nil, fmt.Errorf("unsupported client auth %q", r.cfg.ClientAuth)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		ClientAuth:   clientAuth,
+		// GetConfigForClient заменяет конфигурацию сервера целиком, поэтому HTTP/2 объявляется здесь
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA file contains no PEM certificates")
+		}
+		tlsConfig.ClientCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// cipherSuiteIDs переводит имена наборов шифров в идентификаторы; пустой список - наборы по умолчанию
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	byName := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		byName[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
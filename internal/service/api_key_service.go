@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Формат API-ключа: uak_<12 hex-символов префикса>_<64 hex-символа секрета>.
+// Префикс хранится открыто и служит для поиска ключа, весь ключ - только в виде хеша.
+const (
+	apiKeyMarker       = "uak_"
+	apiKeyPrefixLength = len(apiKeyMarker) + 12
+)
+
+// apiKeyLastUsedPrecision - точность времени последнего использования: чаще ключ не обновляется в хранилище,
+// чтобы каждый запрос не приводил к записи
+const apiKeyLastUsedPrecision = time.Minute
+
+// Ошибки API-ключей
+var (
+	// ErrInvalidAPIKey возвращается для неизвестного, отозванного или истекшего ключа
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyOwner возвращается, если у ключа не задан владелец или заданы оба
+	ErrAPIKeyOwner = errors.New("exactly one of user_id and service_account must be set")
+	// ErrAPIKeyExpired возвращается при создании ключа со сроком действия в прошлом
+	ErrAPIKeyExpired = errors.New("expires_at must be in the future")
+)
+
+// APIKeyServiceInterface определяет интерфейс сервиса API-ключей
+type APIKeyServiceInterface interface {
+	// Create создает ключ и возвращает его вместе с самим ключом, который больше нигде не сохраняется
+	Create(ctx context.Context, input models.CreateAPIKeyInput) (*models.APIKey, string, error)
+	List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// Authenticate проверяет ключ из заголовка запроса и отмечает его использование
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// APIKeyService представляет сервис API-ключей машинных клиентов
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+
+	now func() time.Time
+}
+
+// NewAPIKeyService создает новый экземпляр APIKeyService
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo, userRepo: userRepo, now: time.Now}
+}
+
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
+
+// Create создает ключ пользователя или сервисного аккаунта
+func (s *APIKeyService) Create(ctx context.Context, input models.CreateAPIKeyInput) (*models.APIKey, string, error) {
+	if (input.UserID == nil) == (input.ServiceAccount == "") {
+		return nil, "", ErrAPIKeyOwner
+	}
+	if input.UserID != nil {
+		if _, err := s.userRepo.GetByID(ctx, *input.UserID); err != nil {
+			return nil, "", err
+		}
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return nil, "", ErrAPIKeyExpired
+	}
+
+	prefix, err := randomHex(6)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix = apiKeyMarker + prefix
+	raw := prefix + "_" + secret
+
+	key := &models.APIKey{
+		Name:           input.Name,
+		Prefix:         prefix,
+		Hash:           hashAPIKey(raw),
+		UserID:         input.UserID,
+		ServiceAccount: input.ServiceAccount,
+		Scopes:         input.Scopes,
+		ExpiresAt:      input.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// List получает ключи от новых к старым; если userID не nil - только ключи пользователя
+func (s *APIKeyService) List(ctx context.Context, userID *uuid.UUID) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.List(ctx, userID)
+}
+
+// Get получает ключ по ID
+func (s *APIKeyService) Get(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	return s.apiKeyRepo.GetByID(ctx, id)
+}
+
+// Revoke отзывает ключ; запись остается, чтобы было видно, когда ключ использовался и был отозван
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	return s.apiKeyRepo.Revoke(ctx, id, s.now())
+}
+
+// Authenticate возвращает действующий ключ по его значению; при любом несовпадении возвращается ErrInvalidAPIKey.
+// Ключ удаленного пользователя недействителен.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*models.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyMarker) || len(raw) <= apiKeyPrefixLength || raw[apiKeyPrefixLength] != '_' {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.apiKeyRepo.GetByPrefix(ctx, raw[:apiKeyPrefixLength])
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(raw)), []byte(key.Hash)) != 1 || !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.UserID != nil {
+		if _, err := s.userRepo.GetByID(ctx, *key.UserID); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return nil, ErrInvalidAPIKey
+			}
+			return nil, err
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedPrecision {
+		// Ошибка записи времени использования не мешает запросу
+		if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Failed to record API key usage %s: %v", key.Prefix, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// hashAPIKey возвращает хеш ключа. Ключ содержит 256 случайных бит, поэтому медленный хеш паролей не нужен.
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
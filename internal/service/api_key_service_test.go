@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPIKeyService(userRepo repository.UserRepository) (*APIKeyService, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := NewAPIKeyService(memory.NewAPIKeyRepository(memory.NewDB()), userRepo)
+	service.now = func() time.Time { return now }
+	return service, &now
+}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service, now := newTestAPIKeyService(userRepo)
+	ctx := context.Background()
+
+	userID, missingID := uuid.New(), uuid.New()
+	userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil)
+	userRepo.On("GetByID", missingID).Return(nil, repository.ErrUserNotFound)
+
+	// Case: ключ сервисного аккаунта; хранится только хеш, префикс виден
+	key, raw, err := service.Create(ctx, models.CreateAPIKeyInput{
+		Name: "provisioning", ServiceAccount: "provisioning", Scopes: []string{models.ScopeUsersWrite},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, key.Prefix+"_"))
+	assert.Len(t, key.Prefix, apiKeyPrefixLength)
+	assert.NotContains(t, key.Hash, raw[apiKeyPrefixLength+1:])
+
+	// Case: ключ пользователя
+	_, _, err = service.Create(ctx, models.CreateAPIKeyInput{Name: "cli", UserID: &userID, Scopes: []string{models.ScopeUsersRead}})
+	assert.NoError(t, err)
+
+	// Case: ошибки владельца и срока действия
+	_, _, err = service.Create(ctx, models.CreateAPIKeyInput{Name: "none", Scopes: []string{models.ScopeUsersRead}})
+	assert.ErrorIs(t, err, ErrAPIKeyOwner)
+	_, _, err = service.Create(ctx, models.CreateAPIKeyInput{
+		Name: "both", UserID: &userID, ServiceAccount: "provisioning", Scopes: []string{models.ScopeUsersRead},
+	})
+	assert.ErrorIs(t, err, ErrAPIKeyOwner)
+	_, _, err = service.Create(ctx, models.CreateAPIKeyInput{Name: "ghost", UserID: &missingID, Scopes: []string{models.ScopeUsersRead}})
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	past := now.Add(-time.Minute)
+	_, _, err = service.Create(ctx, models.CreateAPIKeyInput{
+		Name: "expired", ServiceAccount: "provisioning", Scopes: []string{models.ScopeUsersRead}, ExpiresAt: &past,
+	})
+	assert.ErrorIs(t, err, ErrAPIKeyExpired)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service, now := newTestAPIKeyService(userRepo)
+	ctx := context.Background()
+
+	create := func(input models.CreateAPIKeyInput) (*models.APIKey, string) {
+		input.Name, input.Scopes = "test", []string{models.ScopeUsersRead}
+		key, raw, err := service.Create(ctx, input)
+		require.NoError(t, err)
+		return key, raw
+	}
+
+	// Case: верный ключ; время использования записывается не чаще раза в минуту
+	key, raw := create(models.CreateAPIKeyInput{ServiceAccount: "provisioning"})
+	got, err := service.Authenticate(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	require.NotNil(t, got.LastUsedAt)
+	assert.Equal(t, *now, *got.LastUsedAt)
+
+	*now = now.Add(30 * time.Second)
+	got, err = service.Authenticate(ctx, raw)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-30*time.Second), *got.LastUsedAt)
+
+	// Case: подделанный секрет, неизвестный префикс и мусор неразличимы
+	for _, invalid := range []string{raw[:len(raw)-1] + "0", "uak_000000000000_" + strings.Repeat("0", 64), "token", ""} {
+		if invalid == raw {
+			continue
+		}
+		_, err = service.Authenticate(ctx, invalid)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, invalid)
+	}
+
+	// Case: истекший ключ
+	expiresAt := now.Add(time.Hour)
+	_, expiring := create(models.CreateAPIKeyInput{ServiceAccount: "provisioning", ExpiresAt: &expiresAt})
+	*now = expiresAt
+	_, err = service.Authenticate(ctx, expiring)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	// Case: отозванный ключ
+	require.NoError(t, service.Revoke(ctx, key.ID))
+	_, err = service.Authenticate(ctx, raw)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	// Case: ключ удаленного пользователя
+	userID := uuid.New()
+	userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil).Once()
+	_, userKey := create(models.CreateAPIKeyInput{UserID: &userID})
+	userRepo.On("GetByID", userID).Return(nil, repository.ErrUserNotFound)
+	_, err = service.Authenticate(ctx, userKey)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
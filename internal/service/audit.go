@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// AuditLog получает страницу журнала аудита пользователя
+func (s *UserService) AuditLog(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error) {
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	return s.auditRepo.ListByUserID(ctx, userID, page)
+}
+
+// recordAudit записывает событие аудита; вызывается в той же транзакции, что и изменение пользователя
+func (s *UserService) recordAudit(ctx context.Context, action string, before, after *models.User, changes map[string]models.FieldChange) error {
+	userID := uuid.Nil
+	if after != nil {
+		userID = after.ID
+	} else if before != nil {
+		userID = before.ID
+	}
+
+	meta := RequestMetaFromContext(ctx)
+	return s.auditRepo.Create(ctx, &models.AuditEvent{
+		UserID:    userID,
+		Action:    action,
+		Actor:     meta.Actor,
+		SourceIP:  meta.SourceIP,
+		RequestID: meta.RequestID,
+		Changes:   changes,
+	})
+}
+
+// diffUsers вычисляет изменения полей пользователя; before или after равны nil при создании и удалении.
+// Вместо хеша пароля фиксируется только факт его изменения.
+func diffUsers(before, after *models.User) map[string]models.FieldChange {
+	var old, updated models.User
+	if before != nil {
+		old = *before
+	}
+	if after != nil {
+		updated = *after
+	}
+
+	changes := make(map[string]models.FieldChange)
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"email", old.Email, updated.Email},
+		{"first_name", old.FirstName, updated.FirstName},
+		{"last_name", old.LastName, updated.LastName},
+		{"role", old.Role, updated.Role},
+	}
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		change := models.FieldChange{}
+		if before != nil {
+			change.Old = f.old
+		}
+		if after != nil {
+			change.New = f.new
+		}
+		changes[f.name] = change
+	}
+
+	if old.Password != updated.Password && after != nil {
+		changes["password"] = models.FieldChange{New: models.AuditPasswordChanged}
+	}
+	return changes
+}
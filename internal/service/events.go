@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// eventTypes сопоставляет действиям аудита типы доменных событий
+var eventTypes = map[string]string{
+	models.AuditActionCreate: models.EventUserCreated,
+	models.AuditActionUpdate: models.EventUserUpdated,
+	models.AuditActionDelete: models.EventUserDeleted,
+}
+
+// recordChange фиксирует изменение пользователя: событие аудита и доменное событие в outbox.
+// Должна вызываться в той же транзакции, что и само изменение.
+func (s *UserService) recordChange(ctx context.Context, action string, before, after *models.User) error {
+	changes := diffUsers(before, after)
+	if err := s.recordAudit(ctx, action, before, after, changes); err != nil {
+		return err
+	}
+
+	user := after
+	if user == nil {
+		user = before
+	}
+	changedFields := make([]string, 0, len(changes))
+	for field := range changes {
+		changedFields = append(changedFields, field)
+	}
+	sort.Strings(changedFields)
+
+	payload, err := json.Marshal(models.UserEventPayload{User: user, ChangedFields: changedFields})
+	if err != nil {
+		return err
+	}
+
+	return s.outboxRepo.Create(ctx, &models.OutboxEvent{
+		EventID:     uuid.New(),
+		AggregateID: user.ID,
+		Type:        eventTypes[action],
+		Payload:     payload,
+	})
+}
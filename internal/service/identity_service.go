@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrIdentityNotLinked возвращается, если учетная запись поставщика не привязана и привязать ее нельзя:
+// нет пользователя с подтвержденным email, а создание пользователей при входе выключено
+var ErrIdentityNotLinked = errors.New("external identity is not linked to a user")
+
+// ExternalIdentity - пользователь внешнего поставщика удостоверений по утверждениям его ID-токена
+type ExternalIdentity struct {
+	Provider string
+	Subject  string
+	Email    string
+	// EmailVerified - email подтвержден поставщиком и его домен допустим для поставщика;
+	// только такой email используется для привязки к существующему пользователю и создания нового
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// IdentityServiceInterface определяет интерфейс сервиса учетных записей внешних поставщиков
+type IdentityServiceInterface interface {
+	// ResolveUser возвращает пользователя, которому принадлежит учетная запись поставщика, при необходимости
+	// привязывая ее по подтвержденному email или, если provision, создавая пользователя
+	ResolveUser(ctx context.Context, identity ExternalIdentity, provision bool) (*models.User, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error)
+}
+
+// IdentityService представляет сервис привязки учетных записей внешних поставщиков к пользователям
+type IdentityService struct {
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	// userService создает пользователей при первом входе, чтобы создание попадало в аудит и доменные события
+	userService UserServiceInterface
+}
+
+// NewIdentityService создает новый экземпляр IdentityService
+func NewIdentityService(identityRepo repository.IdentityRepository, userRepo repository.UserRepository, userService UserServiceInterface) *IdentityService {
+	return &IdentityService{identityRepo: identityRepo, userRepo: userRepo, userService: userService}
+}
+
+var _ IdentityServiceInterface = (*IdentityService)(nil)
+
+// ResolveUser ищет пользователя сначала по привязанной учетной записи (поставщик и subject), затем по подтвержденному
+// email; если пользователя нет и provision - создает его со случайным паролем. Найденная по email или созданная
+// учетная запись привязывается, и следующие входы не зависят от email.
+func (s *IdentityService) ResolveUser(ctx context.Context, identity ExternalIdentity, provision bool) (*models.User, error) {
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	switch {
+	case err == nil:
+		user, err := s.userRepo.GetByID(ctx, linked.UserID)
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return user, err
+		}
+		// Пользователь удален - привязка устарела и создается заново
+		if err := s.identityRepo.Delete(ctx, linked.ID); err != nil && !errors.Is(err, repository.ErrIdentityNotFound) {
+			return nil, err
+		}
+	case !errors.Is(err, repository.ErrIdentityNotFound):
+		return nil, err
+	}
+
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, ErrIdentityNotLinked
+	}
+	user, err := s.userRepo.GetByEmail(ctx, models.NormalizeEmail(identity.Email))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		if !provision {
+			return nil, ErrIdentityNotLinked
+		}
+		user, err = s.provision(ctx, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.identityRepo.Create(ctx, &models.Identity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    models.NormalizeEmail(identity.Email),
+	})
+	if errors.Is(err, repository.ErrIdentityAlreadyLinked) {
+		// Параллельный вход той же учетной записи успел ее привязать
+		return s.ResolveUser(ctx, identity, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListByUser возвращает учетные записи поставщиков, привязанные к пользователю
+func (s *IdentityService) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Identity, error) {
+	return s.identityRepo.ListByUserID(ctx, userID)
+}
+
+// provision создает пользователя по утверждениям поставщика. Пароль случайный и никому не известен:
+// пользователь входит через поставщика, пока администратор не задаст пароль.
+func (s *IdentityService) provision(ctx context.Context, identity ExternalIdentity) (*models.User, error) {
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	firstName, lastName := identity.FirstName, identity.LastName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	user, err := s.userService.Create(ctx, models.CreateUserInput{
+		Email:     identity.Email,
+		FirstName: firstName,
+		LastName:  lastName,
+		Password:  password,
+		Role:      models.RoleUser,
+	})
+	if errors.Is(err, ErrEmailAlreadyExists) {
+		// Пользователь с этим email создан параллельно
+		return s.userRepo.GetByEmail(ctx, models.NormalizeEmail(identity.Email))
+	}
+	return user, err
+}
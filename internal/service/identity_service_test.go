@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdentityService() (*IdentityService, *UserService) {
+	db := memory.NewDB()
+	userRepo := memory.NewUserRepository(db)
+	userService := NewUserService(userRepo, memory.NewAuditRepository(db), memory.NewOutboxRepository(db), memory.NewTransactor(db))
+	return NewIdentityService(memory.NewIdentityRepository(db), userRepo, userService), userService
+}
+
+func TestIdentityService_ResolveUser_LinksByVerifiedEmail(t *testing.T) {
+	service, userService := newTestIdentityService()
+	ctx := context.Background()
+
+	existing, err := userService.Create(ctx, models.CreateUserInput{Email: "john@example.com", FirstName: "John", LastName: "Doe", Password: "password123"})
+	require.NoError(t, err)
+
+	// Case: неподтвержденный email не привязывается даже к существующему пользователю
+	identity := ExternalIdentity{Provider: "corp", Subject: "sub-1", Email: "John@Example.com"}
+	_, err = service.ResolveUser(ctx, identity, true)
+	assert.ErrorIs(t, err, ErrIdentityNotLinked)
+
+	// Case: подтвержденный email привязывает учетную запись
+	identity.EmailVerified = true
+	user, err := service.ResolveUser(ctx, identity, false)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+
+	// Case: следующий вход - по subject, даже если email у поставщика изменился
+	user, err = service.ResolveUser(ctx, ExternalIdentity{Provider: "corp", Subject: "sub-1", Email: "johnny@example.org"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+
+	identities, err := service.ListByUser(ctx, existing.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "john@example.com", identities[0].Email)
+}
+
+func TestIdentityService_ResolveUser_Provisioning(t *testing.T) {
+	service, userService := newTestIdentityService()
+	ctx := context.Background()
+	identity := ExternalIdentity{Provider: "corp", Subject: "sub-2", Email: "jane@example.com", EmailVerified: true, LastName: "Roe"}
+
+	// Case: без разрешения пользователь не создается
+	_, err := service.ResolveUser(ctx, identity, false)
+	assert.ErrorIs(t, err, ErrIdentityNotLinked)
+
+	// Case: пользователь создается с ролью user и именем из email, если поставщик его не передал
+	user, err := service.ResolveUser(ctx, identity, true)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", user.Email)
+	assert.Equal(t, "jane", user.FirstName)
+	assert.Equal(t, "Roe", user.LastName)
+	assert.Equal(t, models.RoleUser, user.Role)
+
+	again, err := service.ResolveUser(ctx, identity, true)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+
+	// Case: после удаления пользователя устаревшая привязка заменяется новой
+	require.NoError(t, userService.Delete(ctx, user.ID))
+	recreated, err := service.ResolveUser(ctx, identity, true)
+	require.NoError(t, err)
+	assert.NotEqual(t, user.ID, recreated.ID)
+
+	_, err = service.identityRepo.GetByProviderSubject(ctx, "corp", "sub-2")
+	require.NoError(t, err)
+	stale, err := service.ListByUser(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+	_, err = userService.GetByID(ctx, user.ID)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
@@ -0,0 +1,722 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/pkg/jose"
+	"github.com/google/uuid"
+)
+
+// OAuthError - ошибка протокола OAuth2: код из RFC 6749 (или RFC 6750, RFC 7591) и описание для клиента
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// Is сравнивает ошибки по коду, поэтому errors.Is(err, ErrOAuthInvalidGrant) верно при любом описании
+func (e *OAuthError) Is(target error) bool {
+	t, ok := target.(*OAuthError)
+	return ok && t.Code == e.Code
+}
+
+// Ошибки сервера авторизации
+var (
+	ErrOAuthInvalidRequest          = &OAuthError{Code: "invalid_request"}
+	ErrOAuthInvalidClient           = &OAuthError{Code: "invalid_client"}
+	ErrOAuthInvalidGrant            = &OAuthError{Code: "invalid_grant"}
+	ErrOAuthInvalidScope            = &OAuthError{Code: "invalid_scope"}
+	ErrOAuthUnauthorizedClient      = &OAuthError{Code: "unauthorized_client"}
+	ErrOAuthUnsupportedGrantType    = &OAuthError{Code: "unsupported_grant_type"}
+	ErrOAuthUnsupportedResponseType = &OAuthError{Code: "unsupported_response_type"}
+	// ErrOAuthInvalidToken возвращается userinfo для неизвестного, отозванного или истекшего токена
+	ErrOAuthInvalidToken = &OAuthError{Code: "invalid_token"}
+	// ErrOAuthInsufficientScope возвращается userinfo для токена без области действия openid
+	ErrOAuthInsufficientScope = &OAuthError{Code: "insufficient_scope"}
+	// ErrOAuthInvalidClientMetadata возвращается при регистрации клиента с недопустимыми параметрами
+	ErrOAuthInvalidClientMetadata = &OAuthError{Code: "invalid_client_metadata"}
+	// ErrOAuthAccessDenied возвращается Authorize, если пользователь отказал клиенту в доступе
+	ErrOAuthAccessDenied = &OAuthError{Code: "access_denied"}
+)
+
+// OAuthConsentRequired возвращается Authorize для недоверенного клиента, пока пользователь не подтвердил
+// доступ: запрос проверен, и пользователю нужно показать страницу согласия с названием клиента и областями действия
+type OAuthConsentRequired struct {
+	ClientName string
+	Scopes     []string
+}
+
+func (e *OAuthConsentRequired) Error() string {
+	return "user consent is required for client " + e.ClientName
+}
+
+func oauthError(kind *OAuthError, description string) error {
+	return &OAuthError{Code: kind.Code, Description: description}
+}
+
+// oauthClientScopes - области действия клиента по умолчанию
+var oauthClientScopes = []string{models.OAuthScopeOpenID, models.OAuthScopeProfile, models.OAuthScopeEmail}
+
+// OAuthAuthorizationRequest - параметры запроса авторизации (конечная точка authorize).
+// Consented и Denied - ответ пользователя на странице согласия.
+type OAuthAuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Consented           bool
+	Denied              bool
+}
+
+// OAuthTokenRequest - параметры запроса токена (конечная точка token)
+type OAuthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+// OAuthTokenResponse - ответ конечной точки token
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// OAuthIntrospection - ответ конечной точки introspection (RFC 7662); для недействительного токена - только active=false
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
+
+// OAuthOptions - параметры сервера авторизации
+type OAuthOptions struct {
+	// Issuer - внешний адрес сервиса, которым подписываются ID-токены
+	Issuer         string
+	SigningKey     crypto.Signer
+	AccessTokenTTL time.Duration
+	CodeTTL        time.Duration
+}
+
+// OAuthServiceInterface определяет интерфейс сервера авторизации OAuth2/OpenID Connect
+type OAuthServiceInterface interface {
+	// CreateClient регистрирует клиента и возвращает его секрет; секрет показывается один раз, у публичного клиента его нет
+	CreateClient(ctx context.Context, input models.CreateOAuthClientInput) (*models.OAuthClient, string, error)
+	ListClients(ctx context.Context) ([]*models.OAuthClient, error)
+	GetClient(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error)
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+
+	// AuthenticateClient проверяет client_id и секрет клиента; при любом несовпадении возвращается ErrOAuthInvalidClient
+	AuthenticateClient(ctx context.Context, clientID, secret string) (*models.OAuthClient, error)
+	// Authorize выдает пользователю код авторизации для клиента. Непустой redirectURI означает, что адрес возврата
+	// проверен и ошибку можно вернуть клиенту перенаправлением; иначе ее нужно показать пользователю.
+	// Недоверенному клиенту код выдается только после согласия пользователя (см. OAuthConsentRequired).
+	Authorize(ctx context.Context, user *models.User, req OAuthAuthorizationRequest) (redirectURI, code string, err error)
+	Token(ctx context.Context, client *models.OAuthClient, req OAuthTokenRequest) (*OAuthTokenResponse, error)
+	Introspect(ctx context.Context, client *models.OAuthClient, token string) (*OAuthIntrospection, error)
+	// Revoke отзывает токен клиента; неизвестный или чужой токен не считается ошибкой (RFC 7009)
+	Revoke(ctx context.Context, client *models.OAuthClient, token string) error
+	// UserInfo возвращает утверждения о владельце токена согласно его областям действия
+	UserInfo(ctx context.Context, token string) (map[string]any, error)
+
+	Issuer() string
+	// JWKS возвращает открытые ключи для проверки ID-токенов
+	JWKS() jose.JWKS
+}
+
+// OAuthService представляет сервер авторизации OAuth2/OpenID Connect для внутренних приложений
+type OAuthService struct {
+	repo     repository.OAuthRepository
+	userRepo repository.UserRepository
+
+	issuer         string
+	signingKey     crypto.Signer
+	jwk            jose.JWK
+	accessTokenTTL time.Duration
+	codeTTL        time.Duration
+
+	now func() time.Time
+}
+
+// NewOAuthService создает новый экземпляр OAuthService. Идентификатор ключа подписи - его отпечаток (RFC 7638),
+// поэтому замена ключа меняет kid и клиенты заново загружают JWKS.
+func NewOAuthService(repo repository.OAuthRepository, userRepo repository.UserRepository, opts OAuthOptions) (*OAuthService, error) {
+	kid, err := jose.Thumbprint(opts.SigningKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := jose.NewJWK(opts.SigningKey.Public(), kid)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthService{
+		repo:           repo,
+		userRepo:       userRepo,
+		issuer:         strings.TrimSuffix(opts.Issuer, "/"),
+		signingKey:     opts.SigningKey,
+		jwk:            jwk,
+		accessTokenTTL: opts.AccessTokenTTL,
+		codeTTL:        opts.CodeTTL,
+		now:            time.Now,
+	}, nil
+}
+
+var _ OAuthServiceInterface = (*OAuthService)(nil)
+
+// CreateClient регистрирует клиента. Клиенту authorization_code нужен хотя бы один адрес возврата,
+// публичный клиент не может получать токены client_credentials.
+func (s *OAuthService) CreateClient(ctx context.Context, input models.CreateOAuthClientInput) (*models.OAuthClient, string, error) {
+	client := &models.OAuthClient{
+		Name:         input.Name,
+		Public:       input.Public,
+		Trusted:      input.Trusted,
+		RedirectURIs: uniqueStrings(input.RedirectURIs),
+		GrantTypes:   uniqueStrings(input.GrantTypes),
+		Scopes:       uniqueStrings(input.Scopes),
+	}
+	if len(client.GrantTypes) == 0 {
+		client.GrantTypes = []string{models.GrantAuthorizationCode}
+	}
+	if len(client.Scopes) == 0 {
+		client.Scopes = append([]string(nil), oauthClientScopes...)
+	}
+
+	if client.HasGrant(models.GrantAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, "", oauthError(ErrOAuthInvalidClientMetadata, "redirect_uris are required for the authorization_code grant")
+	}
+	if client.Public && client.HasGrant(models.GrantClientCredentials) {
+		return nil, "", oauthError(ErrOAuthInvalidClientMetadata, "public clients cannot use the client_credentials grant")
+	}
+	for _, uri := range client.RedirectURIs {
+		if !validRedirectURI(uri) {
+			return nil, "", oauthError(ErrOAuthInvalidClientMetadata, "redirect_uri "+uri+" must be an absolute https or loopback http URL without a fragment")
+		}
+	}
+	for _, scope := range client.Scopes {
+		if !validScopeToken(scope) {
+			return nil, "", oauthError(ErrOAuthInvalidClientMetadata, "invalid scope "+scope)
+		}
+	}
+
+	var secret string
+	if !client.Public {
+		var err error
+		if secret, err = randomHex(32); err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = hashOAuthSecret(secret)
+	}
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// ListClients возвращает клиентов в порядке регистрации
+func (s *OAuthService) ListClients(ctx context.Context) ([]*models.OAuthClient, error) {
+	return s.repo.ListClients(ctx)
+}
+
+// GetClient возвращает клиента по client_id
+func (s *OAuthService) GetClient(ctx context.Context, id uuid.UUID) (*models.OAuthClient, error) {
+	return s.repo.GetClient(ctx, id)
+}
+
+// DeleteClient удаляет клиента; его коды и токены перестают действовать
+func (s *OAuthService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteClient(ctx, id)
+}
+
+// AuthenticateClient проверяет клиента. Публичный клиент предъявляет только client_id, конфиденциальный - еще и секрет.
+func (s *OAuthService) AuthenticateClient(ctx context.Context, clientID, secret string) (*models.OAuthClient, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, oauthError(ErrOAuthInvalidClient, "client authentication failed")
+	}
+	client, err := s.repo.GetClient(ctx, id)
+	if errors.Is(err, repository.ErrOAuthClientNotFound) {
+		return nil, oauthError(ErrOAuthInvalidClient, "client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, oauthError(ErrOAuthInvalidClient, "public clients have no secret")
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashOAuthSecret(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, oauthError(ErrOAuthInvalidClient, "client authentication failed")
+	}
+	return client, nil
+}
+
+// Authorize проверяет запрос авторизации и выдает одноразовый код. PKCE (S256) обязателен для всех клиентов:
+// он защищает код от перехвата и у конфиденциальных клиентов. Недоверенному клиенту без согласия пользователя
+// возвращается OAuthConsentRequired, а при отказе - ErrOAuthAccessDenied.
+func (s *OAuthService) Authorize(ctx context.Context, user *models.User, req OAuthAuthorizationRequest) (string, string, error) {
+	id, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		return "", "", oauthError(ErrOAuthInvalidClient, "unknown client_id")
+	}
+	client, err := s.repo.GetClient(ctx, id)
+	if errors.Is(err, repository.ErrOAuthClientNotFound) {
+		return "", "", oauthError(ErrOAuthInvalidClient, "unknown client_id")
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	// Адрес возврата можно не передавать, только если у клиента он один
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(redirectURI) {
+		return "", "", oauthError(ErrOAuthInvalidRequest, "redirect_uri is not registered for the client")
+	}
+
+	if req.ResponseType != "code" {
+		return redirectURI, "", oauthError(ErrOAuthUnsupportedResponseType, "only the code response type is supported")
+	}
+	if !client.HasGrant(models.GrantAuthorizationCode) {
+		return redirectURI, "", oauthError(ErrOAuthUnauthorizedClient, "the client may not use the authorization_code grant")
+	}
+	if req.CodeChallengeMethod != "S256" || !validPKCEValue(req.CodeChallenge) {
+		return redirectURI, "", oauthError(ErrOAuthInvalidRequest, "code_challenge with code_challenge_method S256 is required")
+	}
+	if len(req.Nonce) > 255 {
+		return redirectURI, "", oauthError(ErrOAuthInvalidRequest, "nonce is too long")
+	}
+	scopes, err := requestedScopes(client, req.Scope, client.Scopes)
+	if err != nil {
+		return redirectURI, "", err
+	}
+	if req.Denied {
+		return redirectURI, "", oauthError(ErrOAuthAccessDenied, "the user denied access")
+	}
+	if !client.Trusted && !req.Consented {
+		return redirectURI, "", &OAuthConsentRequired{ClientName: client.Name, Scopes: scopes}
+	}
+
+	code, err := randomHex(32)
+	if err != nil {
+		return redirectURI, "", err
+	}
+	err = s.repo.CreateCode(ctx, &models.OAuthAuthorizationCode{
+		CodeHash:            hashOAuthSecret(code),
+		ClientID:            client.ID,
+		UserID:              user.ID,
+		RedirectURI:         redirectURI,
+		RedirectURIProvided: req.RedirectURI != "",
+		Scopes:              scopes,
+		Nonce:               req.Nonce,
+		CodeChallenge:       req.CodeChallenge,
+		ExpiresAt:           s.now().Add(s.codeTTL),
+	})
+	if err != nil {
+		return redirectURI, "", err
+	}
+	return redirectURI, code, nil
+}
+
+// Token выдает токен доступа аутентифицированному клиенту по коду авторизации или по client_credentials
+func (s *OAuthService) Token(ctx context.Context, client *models.OAuthClient, req OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	switch req.GrantType {
+	case models.GrantAuthorizationCode:
+		return s.exchangeCode(ctx, client, req)
+	case models.GrantClientCredentials:
+		return s.clientCredentials(ctx, client, req)
+	case "":
+		return nil, oauthError(ErrOAuthInvalidRequest, "grant_type is required")
+	default:
+		return nil, oauthError(ErrOAuthUnsupportedGrantType, "grant_type "+req.GrantType+" is not supported")
+	}
+}
+
+// exchangeCode обменивает код на токен доступа и, для области действия openid, ID-токен. Код удаляется
+// при первом предъявлении, даже неудачном, поэтому перехваченный код нельзя подобрать к верификатору.
+func (s *OAuthService) exchangeCode(ctx context.Context, client *models.OAuthClient, req OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	if !client.HasGrant(models.GrantAuthorizationCode) {
+		return nil, oauthError(ErrOAuthUnauthorizedClient, "the client may not use the authorization_code grant")
+	}
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauthError(ErrOAuthInvalidRequest, "code and code_verifier are required")
+	}
+
+	code, err := s.repo.ConsumeCode(ctx, hashOAuthSecret(req.Code))
+	if errors.Is(err, repository.ErrOAuthCodeNotFound) {
+		return nil, oauthError(ErrOAuthInvalidGrant, "the authorization code is invalid or already used")
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if code.ClientID != client.ID || !now.Before(code.ExpiresAt) {
+		return nil, oauthError(ErrOAuthInvalidGrant, "the authorization code is invalid or expired")
+	}
+	// redirect_uri обязателен, только если был передан в запросе авторизации, но переданный всегда должен совпадать
+	if (code.RedirectURIProvided || req.RedirectURI != "") && code.RedirectURI != req.RedirectURI {
+		return nil, oauthError(ErrOAuthInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if subtle.ConstantTimeCompare([]byte(pkceChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, oauthError(ErrOAuthInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, code.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, oauthError(ErrOAuthInvalidGrant, "the user no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueToken(ctx, client, &user.ID, code.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if containsScope(code.Scopes, models.OAuthScopeOpenID) {
+		if resp.IDToken, err = s.idToken(client, user, code.Scopes, code.Nonce, now); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// clientCredentials выдает токен доступа самому клиенту. Области действия OpenID Connect описывают пользователя,
+// поэтому без пользователя их запросить нельзя.
+func (s *OAuthService) clientCredentials(ctx context.Context, client *models.OAuthClient, req OAuthTokenRequest) (*OAuthTokenResponse, error) {
+	if client.Public || !client.HasGrant(models.GrantClientCredentials) {
+		return nil, oauthError(ErrOAuthUnauthorizedClient, "the client may not use the client_credentials grant")
+	}
+
+	var defaults []string
+	for _, scope := range client.Scopes {
+		if !isOIDCScope(scope) {
+			defaults = append(defaults, scope)
+		}
+	}
+	scopes, err := requestedScopes(client, req.Scope, defaults)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		if isOIDCScope(scope) {
+			return nil, oauthError(ErrOAuthInvalidScope, "scope "+scope+" requires a user")
+		}
+	}
+	return s.issueToken(ctx, client, nil, scopes)
+}
+
+// issueToken создает непрозрачный токен доступа; в хранилище попадает только его хеш
+func (s *OAuthService) issueToken(ctx context.Context, client *models.OAuthClient, userID *uuid.UUID, scopes []string) (*OAuthTokenResponse, error) {
+	raw, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.CreateToken(ctx, &models.OAuthToken{
+		TokenHash: hashOAuthSecret(raw),
+		ClientID:  client.ID,
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: s.now().Add(s.accessTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthTokenResponse{
+		AccessToken: raw,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.accessTokenTTL / time.Second),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// idToken подписывает ID-токен пользователя для клиента
+func (s *OAuthService) idToken(client *models.OAuthClient, user *models.User, scopes []string, nonce string, now time.Time) (string, error) {
+	claims := userClaims(user, scopes)
+	claims["iss"] = s.issuer
+	claims["aud"] = client.ID.String()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(s.accessTokenTTL).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return jose.Sign(claims, s.signingKey, s.jwk.Kid)
+}
+
+// Introspect сообщает, действует ли токен и кому он выдан. Проверять токены могут только конфиденциальные
+// клиенты (например, API внутренних приложений), причем токены любых клиентов.
+func (s *OAuthService) Introspect(ctx context.Context, client *models.OAuthClient, raw string) (*OAuthIntrospection, error) {
+	if client.Public {
+		return nil, oauthError(ErrOAuthUnauthorizedClient, "public clients may not introspect tokens")
+	}
+	token, user, err := s.activeToken(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return &OAuthIntrospection{Active: false}, nil
+	}
+
+	result := &OAuthIntrospection{
+		Active:    true,
+		Scope:     strings.Join(token.Scopes, " "),
+		ClientID:  token.ClientID.String(),
+		TokenType: "Bearer",
+		Exp:       token.ExpiresAt.Unix(),
+		Iat:       token.CreatedAt.Unix(),
+		Sub:       token.ClientID.String(),
+		Iss:       s.issuer,
+	}
+	if user != nil {
+		result.Sub = user.ID.String()
+		result.Username = user.Email
+	}
+	return result, nil
+}
+
+// Revoke отзывает токен, если он выдан этому клиенту
+func (s *OAuthService) Revoke(ctx context.Context, client *models.OAuthClient, raw string) error {
+	if raw == "" {
+		return oauthError(ErrOAuthInvalidRequest, "token is required")
+	}
+	token, err := s.repo.GetTokenByHash(ctx, hashOAuthSecret(raw))
+	if errors.Is(err, repository.ErrOAuthTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if token.ClientID != client.ID {
+		return nil
+	}
+	err = s.repo.RevokeToken(ctx, token.ID, s.now())
+	if errors.Is(err, repository.ErrOAuthTokenNotFound) {
+		return nil
+	}
+	return err
+}
+
+// UserInfo возвращает утверждения о пользователе, которому выдан токен с областью действия openid
+func (s *OAuthService) UserInfo(ctx context.Context, raw string) (map[string]any, error) {
+	token, user, err := s.activeToken(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || user == nil {
+		return nil, oauthError(ErrOAuthInvalidToken, "the access token is invalid or expired")
+	}
+	if !containsScope(token.Scopes, models.OAuthScopeOpenID) {
+		return nil, oauthError(ErrOAuthInsufficientScope, "the access token lacks the openid scope")
+	}
+	return userClaims(user, token.Scopes), nil
+}
+
+// activeToken возвращает действующий токен и его пользователя; для недействительного токена - nil без ошибки.
+// Токен удаленного пользователя или удаленного клиента недействителен.
+func (s *OAuthService) activeToken(ctx context.Context, raw string) (*models.OAuthToken, *models.User, error) {
+	if raw == "" {
+		return nil, nil, nil
+	}
+	token, err := s.repo.GetTokenByHash(ctx, hashOAuthSecret(raw))
+	if errors.Is(err, repository.ErrOAuthTokenNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !token.Active(s.now()) {
+		return nil, nil, nil
+	}
+	if token.UserID == nil {
+		return token, nil, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, *token.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, user, nil
+}
+
+// Issuer возвращает идентификатор сервера авторизации
+func (s *OAuthService) Issuer() string {
+	return s.issuer
+}
+
+// JWKS возвращает открытый ключ подписи ID-токенов
+func (s *OAuthService) JWKS() jose.JWKS {
+	return jose.JWKS{Keys: []jose.JWK{s.jwk}}
+}
+
+// DeleteExpired удаляет истекшие коды авторизации и токены и возвращает их количество
+func (s *OAuthService) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.repo.DeleteExpired(ctx, s.now())
+	if err == nil && deleted > 0 {
+		log.Printf("Deleted %d expired OAuth codes and tokens", deleted)
+	}
+	return deleted, err
+}
+
+// RunCleanup удаляет истекшие коды и токены каждые interval до отмены ctx
+func (s *OAuthService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("OAuth cleanup error: %v", err)
+			}
+		}
+	}
+}
+
+// userClaims возвращает стандартные утверждения OpenID Connect о пользователе для областей действия scopes
+func userClaims(user *models.User, scopes []string) map[string]any {
+	claims := map[string]any{"sub": user.ID.String()}
+	if containsScope(scopes, models.OAuthScopeEmail) {
+		claims["email"] = user.Email
+	}
+	if containsScope(scopes, models.OAuthScopeProfile) {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+	return claims
+}
+
+// requestedScopes разбирает параметр scope; пустой параметр означает области действия defaults.
+// Каждая запрошенная область должна быть разрешена клиенту.
+func requestedScopes(client *models.OAuthClient, scope string, defaults []string) ([]string, error) {
+	scopes := uniqueStrings(strings.Fields(scope))
+	if len(scopes) == 0 {
+		scopes = append([]string(nil), defaults...)
+	}
+	for _, scope := range scopes {
+		if !client.HasScope(scope) {
+			return nil, oauthError(ErrOAuthInvalidScope, "scope "+scope+" is not allowed for the client")
+		}
+	}
+	return scopes, nil
+}
+
+func isOIDCScope(scope string) bool {
+	return scope == models.OAuthScopeOpenID || scope == models.OAuthScopeProfile || scope == models.OAuthScopeEmail
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if !containsScope(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// validRedirectURI допускает абсолютные адреса https и http на loopback-адресе (для локальной разработки
+// и нативных приложений); фрагмент запрещен RFC 6749
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" || strings.Contains(raw, "#") {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return false
+	}
+}
+
+// validScopeToken проверяет область действия по грамматике scope-token из RFC 6749
+func validScopeToken(scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for _, r := range scope {
+		if r < 0x21 || r > 0x7e || r == '"' || r == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// validPKCEValue проверяет верификатор или код подтверждения PKCE: 43-128 символов из набора RFC 7636
+func validPKCEValue(value string) bool {
+	if len(value) < 43 || len(value) > 128 {
+		return false
+	}
+	for _, r := range value {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-._~", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// pkceChallenge вычисляет код подтверждения S256 для верификатора
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// hashOAuthSecret возвращает хеш секрета клиента, кода или токена. Все они содержат 256 случайных бит,
+// поэтому медленный хеш паролей не нужен.
+func hashOAuthSecret(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/Est1ege/go-user-api/pkg/jose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+func newTestOAuthService(t *testing.T) (*OAuthService, *models.User) {
+	db := memory.NewDB()
+	userRepo := memory.NewUserRepository(db)
+	user := &models.User{Email: "john@example.com", FirstName: "John", LastName: "Doe", Role: models.RoleUser}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	service, err := NewOAuthService(memory.NewOAuthRepository(db), userRepo, OAuthOptions{
+		Issuer: "https://users.example.com/", SigningKey: key, AccessTokenTTL: time.Hour, CodeTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	return service, user
+}
+
+func authorizeRequest(client *models.OAuthClient) OAuthAuthorizationRequest {
+	return OAuthAuthorizationRequest{
+		ClientID:            client.ID.String(),
+		ResponseType:        "code",
+		Scope:               "openid email",
+		Nonce:               "nonce-1",
+		CodeChallenge:       pkceChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestOAuthService_CreateClient(t *testing.T) {
+	service, _ := newTestOAuthService(t)
+	ctx := context.Background()
+
+	client, secret, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "App", RedirectURIs: []string{"https://app.example.com/cb"}})
+	require.NoError(t, err)
+	assert.Len(t, secret, 64)
+	assert.Equal(t, []string{models.GrantAuthorizationCode}, client.GrantTypes)
+	assert.Equal(t, []string{"openid", "profile", "email"}, client.Scopes)
+
+	authenticated, err := service.AuthenticateClient(ctx, client.ID.String(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, authenticated.ID)
+	_, err = service.AuthenticateClient(ctx, client.ID.String(), "wrong")
+	assert.ErrorIs(t, err, ErrOAuthInvalidClient)
+
+	// Case: у публичного клиента нет секрета
+	public, secret, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "SPA", Public: true, RedirectURIs: []string{"http://localhost:3000/cb"}})
+	require.NoError(t, err)
+	assert.Empty(t, secret)
+	_, err = service.AuthenticateClient(ctx, public.ID.String(), "")
+	assert.NoError(t, err)
+
+	// Case: недопустимые параметры
+	for _, input := range []models.CreateOAuthClientInput{
+		{Name: "No redirect"},
+		{Name: "Public service", Public: true, GrantTypes: []string{models.GrantClientCredentials}},
+		{Name: "Plain http", RedirectURIs: []string{"http://app.example.com/cb"}},
+		{Name: "Fragment", RedirectURIs: []string{"https://app.example.com/cb#x"}},
+	} {
+		_, _, err := service.CreateClient(ctx, input)
+		assert.ErrorIs(t, err, ErrOAuthInvalidClientMetadata, input.Name)
+	}
+}
+
+func TestOAuthService_AuthorizationCodeFlow(t *testing.T) {
+	service, user := newTestOAuthService(t)
+	ctx := context.Background()
+	client, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "App", Trusted: true, RedirectURIs: []string{"https://app.example.com/cb"}})
+	require.NoError(t, err)
+
+	redirectURI, code, err := service.Authorize(ctx, user, authorizeRequest(client))
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/cb", redirectURI)
+
+	// Case: неверный верификатор - код все равно израсходован
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: redirectURI, CodeVerifier: strings.Repeat("a", 43)})
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: redirectURI, CodeVerifier: testVerifier})
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+
+	_, code, err = service.Authorize(ctx, user, authorizeRequest(client))
+	require.NoError(t, err)
+	resp, err := service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: redirectURI, CodeVerifier: testVerifier})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, int64(3600), resp.ExpiresIn)
+	assert.Equal(t, "openid email", resp.Scope)
+
+	// ID-токен подписан ключом из JWKS и содержит утверждения запрошенных областей действия
+	payload, err := jose.Verify(resp.IDToken, func(header jose.Header) (crypto.PublicKey, error) {
+		key, ok := service.JWKS().Key(header.Kid)
+		require.True(t, ok)
+		return key.PublicKey()
+	})
+	require.NoError(t, err)
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, "https://users.example.com", claims["iss"])
+	assert.Equal(t, client.ID.String(), claims["aud"])
+	assert.Equal(t, user.ID.String(), claims["sub"])
+	assert.Equal(t, "nonce-1", claims["nonce"])
+	assert.Equal(t, "john@example.com", claims["email"])
+	assert.NotContains(t, claims, "given_name")
+
+	info, err := service.UserInfo(ctx, resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"sub": user.ID.String(), "email": "john@example.com"}, info)
+}
+
+func TestOAuthService_Consent(t *testing.T) {
+	service, user := newTestOAuthService(t)
+	ctx := context.Background()
+	client, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "App", RedirectURIs: []string{"https://app.example.com/cb"}})
+	require.NoError(t, err)
+	assert.False(t, client.Trusted)
+
+	// Case: стороннему клиенту код выдается только после согласия пользователя
+	req := authorizeRequest(client)
+	redirectURI, code, err := service.Authorize(ctx, user, req)
+	var consentErr *OAuthConsentRequired
+	require.ErrorAs(t, err, &consentErr)
+	assert.Equal(t, "App", consentErr.ClientName)
+	assert.Equal(t, []string{"openid", "email"}, consentErr.Scopes)
+	assert.Equal(t, "https://app.example.com/cb", redirectURI)
+	assert.Empty(t, code)
+
+	// Case: отказ возвращается клиенту как access_denied
+	req.Denied = true
+	redirectURI, code, err = service.Authorize(ctx, user, req)
+	assert.ErrorIs(t, err, ErrOAuthAccessDenied)
+	assert.Equal(t, "https://app.example.com/cb", redirectURI)
+	assert.Empty(t, code)
+
+	req.Denied = false
+	req.Consented = true
+	_, code, err = service.Authorize(ctx, user, req)
+	require.NoError(t, err)
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: code, CodeVerifier: testVerifier})
+	assert.NoError(t, err)
+}
+
+func TestOAuthService_CodeRedirectURI(t *testing.T) {
+	service, user := newTestOAuthService(t)
+	ctx := context.Background()
+	client, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "App", Trusted: true, RedirectURIs: []string{"https://app.example.com/cb"}})
+	require.NoError(t, err)
+
+	exchange := func(authorizeURI, tokenURI string) error {
+		req := authorizeRequest(client)
+		req.RedirectURI = authorizeURI
+		_, code, err := service.Authorize(ctx, user, req)
+		require.NoError(t, err)
+		_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: code, RedirectURI: tokenURI, CodeVerifier: testVerifier})
+		return err
+	}
+
+	// Case: redirect_uri не передан при авторизации - при обмене он необязателен, но если передан, должен совпадать
+	assert.NoError(t, exchange("", ""))
+	assert.NoError(t, exchange("", "https://app.example.com/cb"))
+	assert.ErrorIs(t, exchange("", "https://app.example.com/other"), ErrOAuthInvalidGrant)
+
+	// Case: redirect_uri передан при авторизации - при обмене он обязателен
+	assert.NoError(t, exchange("https://app.example.com/cb", "https://app.example.com/cb"))
+	assert.ErrorIs(t, exchange("https://app.example.com/cb", ""), ErrOAuthInvalidGrant)
+}
+
+func TestOAuthService_AuthorizeErrors(t *testing.T) {
+	service, user := newTestOAuthService(t)
+	ctx := context.Background()
+	client, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{
+		Name: "App", RedirectURIs: []string{"https://app.example.com/a", "https://app.example.com/b"}, Scopes: []string{"openid"},
+	})
+	require.NoError(t, err)
+
+	// Case: адрес возврата не проверен - ошибку нельзя вернуть перенаправлением
+	req := authorizeRequest(client)
+	redirectURI, _, err := service.Authorize(ctx, user, req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidRequest)
+	assert.Empty(t, redirectURI)
+
+	req.RedirectURI = "https://app.example.com/b"
+	redirectURI, _, err = service.Authorize(ctx, user, req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidScope)
+	assert.Equal(t, "https://app.example.com/b", redirectURI)
+
+	req.Scope = "openid"
+	req.CodeChallengeMethod = "plain"
+	_, _, err = service.Authorize(ctx, user, req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidRequest)
+
+	req.ClientID = "unknown"
+	redirectURI, _, err = service.Authorize(ctx, user, req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidClient)
+	assert.Empty(t, redirectURI)
+}
+
+func TestOAuthService_ClientCredentialsIntrospectRevoke(t *testing.T) {
+	service, _ := newTestOAuthService(t)
+	ctx := context.Background()
+	client, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{
+		Name: "Worker", GrantTypes: []string{models.GrantClientCredentials}, Scopes: []string{"reports:read", "openid"},
+	})
+	require.NoError(t, err)
+
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "client_credentials", Scope: "openid"})
+	assert.ErrorIs(t, err, ErrOAuthInvalidScope)
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "authorization_code", Code: "x", CodeVerifier: testVerifier})
+	assert.ErrorIs(t, err, ErrOAuthUnauthorizedClient)
+	_, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "password"})
+	assert.ErrorIs(t, err, ErrOAuthUnsupportedGrantType)
+
+	resp, err := service.Token(ctx, client, OAuthTokenRequest{GrantType: "client_credentials"})
+	require.NoError(t, err)
+	assert.Equal(t, "reports:read", resp.Scope)
+	assert.Empty(t, resp.IDToken)
+
+	result, err := service.Introspect(ctx, client, resp.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, result.Active)
+	assert.Equal(t, client.ID.String(), result.Sub)
+	assert.Equal(t, "reports:read", result.Scope)
+
+	// Токен без пользователя не дает доступа к userinfo
+	_, err = service.UserInfo(ctx, resp.AccessToken)
+	assert.ErrorIs(t, err, ErrOAuthInvalidToken)
+
+	// Case: чужой клиент не может отозвать токен, неизвестный токен - не ошибка
+	other, _, err := service.CreateClient(ctx, models.CreateOAuthClientInput{Name: "Other", GrantTypes: []string{models.GrantClientCredentials}})
+	require.NoError(t, err)
+	require.NoError(t, service.Revoke(ctx, other, resp.AccessToken))
+	require.NoError(t, service.Revoke(ctx, client, "unknown"))
+	result, err = service.Introspect(ctx, other, resp.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, result.Active)
+
+	require.NoError(t, service.Revoke(ctx, client, resp.AccessToken))
+	result, err = service.Introspect(ctx, client, resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, &OAuthIntrospection{Active: false}, result)
+
+	// Case: истекший токен недействителен
+	resp, err = service.Token(ctx, client, OAuthTokenRequest{GrantType: "client_credentials"})
+	require.NoError(t, err)
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	result, err = service.Introspect(ctx, client, resp.AccessToken)
+	require.NoError(t, err)
+	assert.False(t, result.Active)
+	deleted, err := service.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
@@ -0,0 +1,29 @@
+package service
+
+import "context"
+
+// AnonymousActor - исполнитель изменений, если запрос не аутентифицирован
+const AnonymousActor = "anonymous"
+
+// RequestMeta содержит сведения о запросе, инициировавшем изменение
+type RequestMeta struct {
+	Actor     string
+	SourceIP  string
+	RequestID string
+}
+
+type requestMetaKey struct{}
+
+// WithRequestMeta сохраняет сведения о запросе в контексте
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// RequestMetaFromContext возвращает сведения о запросе из контекста
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	if meta.Actor == "" {
+		meta.Actor = AnonymousActor
+	}
+	return meta
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// SessionServiceInterface определяет интерфейс сервиса управления сессиями пользователей
+type SessionServiceInterface interface {
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error)
+	Revoke(ctx context.Context, userID uuid.UUID, sessionID string) error
+	RevokeAll(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// SessionService представляет сервис управления сессиями веб-интерфейса
+type SessionService struct {
+	sessionRepo repository.SessionRepository
+	userRepo    repository.UserRepository
+
+	now func() time.Time
+}
+
+// NewSessionService создает новый экземпляр SessionService
+func NewSessionService(sessionRepo repository.SessionRepository, userRepo repository.UserRepository) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo, userRepo: userRepo, now: time.Now}
+}
+
+var _ SessionServiceInterface = (*SessionService)(nil)
+
+// ListByUser получает действующие сессии пользователя, начиная с последней активной
+func (s *SessionService) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.sessionRepo.ListByUserID(ctx, userID, s.now())
+}
+
+// Revoke завершает сессию пользователя; сессия другого пользователя считается не найденной
+func (s *SessionService) Revoke(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID == nil || *session.UserID != userID {
+		return repository.ErrSessionNotFound
+	}
+	return s.sessionRepo.Delete(ctx, sessionID)
+}
+
+// RevokeAll завершает все сессии пользователя ("выйти везде") и возвращает их количество
+func (s *SessionService) RevokeAll(ctx context.Context, userID uuid.UUID) (int64, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return 0, err
+	}
+	return s.sessionRepo.DeleteByUserID(ctx, userID)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash сравнивается с паролем, когда пользователь не найден, чтобы время ответа
+// не выдавало, зарегистрирован ли email
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// Authenticate проверяет email и пароль пользователя; при любом несовпадении возвращается ErrInvalidCredentials.
+// Пользователь читается с основной базы: кеш не хранит хеш пароля, а реплика может не знать о его смене.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(repository.WithPrimaryReads(ctx), models.NormalizeEmail(email))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// EnsureAdmin создает администратора с email и паролем password, если такого пользователя нет,
+// и назначает роль администратора существующему пользователю. Пароль существующего пользователя не меняется.
+func (s *UserService) EnsureAdmin(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByEmail(ctx, models.NormalizeEmail(email))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		if password == "" {
+			return nil, fmt.Errorf("admin %s does not exist and no password is set", email)
+		}
+		return s.Create(ctx, models.CreateUserInput{
+			Email:     email,
+			FirstName: "Admin",
+			LastName:  "Admin",
+			Password:  password,
+			Role:      models.RoleAdmin,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsAdmin() {
+		return user, nil
+	}
+	return s.Update(ctx, user.ID, models.UpdateUserInput{Role: models.RoleAdmin})
+}
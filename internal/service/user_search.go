@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+)
+
+const (
+	// DefaultSearchLimit - количество результатов поиска по умолчанию
+	DefaultSearchLimit = 20
+	// MaxSearchLimit - максимальное количество результатов поиска
+	MaxSearchLimit = 100
+)
+
+// Search ищет пользователей по email, имени и фамилии и подсвечивает совпадения
+func (s *UserService) Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	results, err := s.userRepo.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := models.SearchTerms(query)
+	for _, result := range results {
+		result.Highlights = map[string]string{
+			"email":      highlight(result.User.Email, terms),
+			"first_name": highlight(result.User.FirstName, terms),
+			"last_name":  highlight(result.User.LastName, terms),
+		}
+	}
+	return results, nil
+}
+
+// highlight экранирует text для HTML и оборачивает вхождения слов запроса в <mark>
+func highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Смена регистра изменила длину строки - подсвечивать по позициям небезопасно
+		return html.EscapeString(text)
+	}
+
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		termRunes := []rune(term)
+		for i := 0; i+len(termRunes) <= len(lower); i++ {
+			if string(lower[i:i+len(termRunes)]) == term {
+				for j := i; j < i+len(termRunes); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		chunk := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString("<mark>" + chunk + "</mark>")
+		} else {
+			b.WriteString(chunk)
+		}
+		i = j
+	}
+	return b.String()
+}
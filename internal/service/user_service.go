@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/Est1ege/go-user-api/internal/domain/models"
@@ -10,29 +11,46 @@ import (
 
 // UserServiceInterface определяет интерфейс сервиса пользователя
 type UserServiceInterface interface {
-	Create(input models.CreateUserInput) (*models.User, error)
-	GetByID(id uuid.UUID) (*models.User, error)
-	Update(id uuid.UUID, input models.UpdateUserInput) (*models.User, error)
-	Delete(id uuid.UUID) error
-	GetAll() ([]*models.User, error)
+	Create(ctx context.Context, input models.CreateUserInput) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, id uuid.UUID, input models.UpdateUserInput) (*models.User, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetAll(ctx context.Context) ([]*models.User, error)
+	List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error)
+	Export(ctx context.Context, filter models.UserFilter, fn func(users []*models.User) error) error
+	Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]models.BatchOperationResult, bool, error)
+	Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error)
+	AuditLog(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error)
+	Authenticate(ctx context.Context, email, password string) (*models.User, error)
 }
 
 // UserService представляет сервис для работы с пользователями
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	auditRepo  repository.AuditRepository
+	outboxRepo repository.OutboxRepository
+	transactor repository.Transactor
 }
 
 // NewUserService создает новый экземпляр UserService
-func NewUserService(userRepo repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+	outboxRepo repository.OutboxRepository,
+	transactor repository.Transactor,
+) *UserService {
+	return &UserService{userRepo: userRepo, auditRepo: auditRepo, outboxRepo: outboxRepo, transactor: transactor}
 }
 
 var _ UserServiceInterface = (*UserService)(nil)
 
 // Create создает нового пользователя
-func (s *UserService) Create(input models.CreateUserInput) (*models.User, error) {
+func (s *UserService) Create(ctx context.Context, input models.CreateUserInput) (*models.User, error) {
+	input.Email = models.NormalizeEmail(input.Email)
+
 	// Проверяем, существует ли пользователь с таким email
-	existingUser, err := s.userRepo.GetByEmail(input.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err == nil && existingUser != nil {
 		return nil, ErrEmailAlreadyExists
 	}
@@ -49,9 +67,20 @@ func (s *UserService) Create(input models.CreateUserInput) (*models.User, error)
 		FirstName: input.FirstName,
 		LastName:  input.LastName,
 		Password:  string(hashedPassword),
+		Role:      input.Role,
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	// Пользователь, событие аудита и доменное событие сохраняются атомарно
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, models.AuditActionCreate, nil, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -59,61 +88,178 @@ func (s *UserService) Create(input models.CreateUserInput) (*models.User, error)
 }
 
 // GetByID получает пользователя по ID
-func (s *UserService) GetByID(id uuid.UUID) (*models.User, error) {
-	return s.userRepo.GetByID(id)
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, id)
 }
 
-// Update обновляет данные пользователя
-func (s *UserService) Update(id uuid.UUID, input models.UpdateUserInput) (*models.User, error) {
-	user, err := s.userRepo.GetByID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	// Обновляем поля, если они были предоставлены
-	if input.Email != "" && input.Email != user.Email {
-		// Проверяем, не занят ли новый email
-		existingUser, err := s.userRepo.GetByEmail(input.Email)
-		if err == nil && existingUser != nil && existingUser.ID != id {
-			return nil, ErrEmailAlreadyExists
-		}
-		user.Email = input.Email
-	}
-
-	if input.FirstName != "" {
-		user.FirstName = input.FirstName
-	}
+// GetByEmail получает пользователя по email без учета регистра
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.userRepo.GetByEmail(ctx, models.NormalizeEmail(email))
+}
 
-	if input.LastName != "" {
-		user.LastName = input.LastName
-	}
+// Update обновляет данные пользователя. Запись читается и перезаписывается в одной транзакции
+// с блокировкой строки, поэтому параллельные изменения не теряются, а журнал аудита получает
+// действительное состояние до изменения.
+func (s *UserService) Update(ctx context.Context, id uuid.UUID, input models.UpdateUserInput) (*models.User, error) {
+	input.Email = models.NormalizeEmail(input.Email)
 
+	// Хешируем пароль до транзакции, чтобы не держать блокировку строки во время bcrypt
+	var hashedPassword []byte
 	if input.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		var err error
+		hashedPassword, err = bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, err
 		}
-		user.Password = string(hashedPassword)
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	var user *models.User
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		before := *user
+
+		// Обновляем поля, если они были предоставлены
+		if input.Email != "" && input.Email != user.Email {
+			// Проверяем, не занят ли новый email
+			existingUser, err := s.userRepo.GetByEmail(ctx, input.Email)
+			if err == nil && existingUser != nil && existingUser.ID != id {
+				return ErrEmailAlreadyExists
+			}
+			user.Email = input.Email
+		}
+
+		if input.FirstName != "" {
+			user.FirstName = input.FirstName
+		}
+
+		if input.LastName != "" {
+			user.LastName = input.LastName
+		}
+
+		if input.Role != "" {
+			user.Role = input.Role
+		}
+
+		if hashedPassword != nil {
+			user.Password = string(hashedPassword)
+		}
+
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, models.AuditActionUpdate, &before, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-// Delete удаляет пользователя
-func (s *UserService) Delete(id uuid.UUID) error {
-	return s.userRepo.Delete(id)
+// Delete удаляет пользователя; удаление несуществующего пользователя не считается ошибкой
+func (s *UserService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, models.AuditActionDelete, user, nil)
+	})
 }
 
 // GetAll получает список всех пользователей
-func (s *UserService) GetAll() ([]*models.User, error) {
-	return s.userRepo.GetAll()
+func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
+	return s.userRepo.GetAll(ctx)
 }
 
+// List получает страницу пользователей по фильтру
+func (s *UserService) List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error) {
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	return s.userRepo.List(ctx, filter, page)
+}
+
+// Export передает в fn пользователей по фильтру порциями по ExportBatchSize записей
+func (s *UserService) Export(ctx context.Context, filter models.UserFilter, fn func(users []*models.User) error) error {
+	return s.userRepo.FindInBatches(ctx, filter, ExportBatchSize, fn)
+}
+
+// Batch выполняет операции пакета в одной транзакции.
+// Каждая операция выполняется в собственной точке сохранения: в неатомарном режиме ошибка
+// откатывает только эту операцию, в атомарном - всю транзакцию, а оставшиеся операции
+// помечаются как пропущенные. Второе возвращаемое значение сообщает, была ли транзакция зафиксирована.
+func (s *UserService) Batch(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]models.BatchOperationResult, bool, error) {
+	results := make([]models.BatchOperationResult, len(ops))
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, op := range ops {
+			var user *models.User
+			err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				var err error
+				user, err = s.applyBatchOperation(ctx, op)
+				return err
+			})
+			results[i] = models.BatchOperationResult{User: user, Err: err}
+			if err != nil && atomic {
+				return errBatchAborted
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBatchAborted) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = models.BatchOperationResult{Skipped: true}
+			}
+		}
+		return results, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
+
+// applyBatchOperation выполняет одну операцию пакета
+func (s *UserService) applyBatchOperation(ctx context.Context, op models.BatchOperation) (*models.User, error) {
+	switch op.Op {
+	case models.BatchOpCreate:
+		return s.Create(ctx, op.Create)
+	case models.BatchOpUpdate:
+		return s.Update(ctx, op.ID, op.Update)
+	case models.BatchOpDelete:
+		return nil, s.Delete(ctx, op.ID)
+	}
+	return nil, ErrUnknownBatchOperation
+}
+
+const (
+	// DefaultPageLimit - размер страницы списка по умолчанию
+	DefaultPageLimit = 50
+	// ExportBatchSize - количество записей, читаемых из БД за один запрос при выгрузке
+	ExportBatchSize = 500
+)
+
 // Определение ошибок
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
+	// ErrEmailAlreadyExists возвращается и при проверке в сервисе, и при нарушении уникального индекса в БД
+	ErrEmailAlreadyExists    = repository.ErrEmailAlreadyExists
+	ErrUnknownBatchOperation = errors.New("unknown batch operation")
+	ErrInvalidCredentials    = errors.New("invalid email or password")
+
+	// errBatchAborted прерывает транзакцию атомарного пакета после первой ошибки
+	errBatchAborted = errors.New("batch aborted")
 )
\ No newline at end of file
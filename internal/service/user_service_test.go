@@ -1,14 +1,18 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/Est1ege/go-user-api/internal/domain/models"
 	"github.com/Est1ege/go-user-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Создаем мок для UserRepository
@@ -18,7 +22,7 @@ type MockUserRepository struct {
 
 var _ repository.UserRepository = (*MockUserRepository)(nil)
 
-func (m *MockUserRepository) GetAll() ([]*models.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
     args := m.Called()
     if args.Get(0) == nil {
         return nil, args.Error(1)
@@ -26,12 +30,48 @@ func (m *MockUserRepository) GetAll() ([]*models.User, error) {
     return args.Get(0).([]*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Create(user *models.User) error {
+func (m *MockUserRepository) List(ctx context.Context, filter models.UserFilter, page models.Page) ([]*models.User, int64, error) {
+	args := m.Called(filter, page)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) FindInBatches(ctx context.Context, filter models.UserFilter, batchSize int, fn func(users []*models.User) error) error {
+	args := m.Called(filter, batchSize, fn)
+	if batches, ok := args.Get(0).([][]*models.User); ok {
+		for _, batch := range batches {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit int) ([]*models.UserSearchResult, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.UserSearchResult), args.Error(1)
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -39,7 +79,7 @@ func (m *MockUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -47,20 +87,88 @@ func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *models.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id uuid.UUID) error {
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+// MockTransactor выполняет функцию без реальной транзакции
+type MockTransactor struct{}
+
+var _ repository.Transactor = (*MockTransactor)(nil)
+
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// FakeAuditRepository запоминает записанные события аудита
+type FakeAuditRepository struct {
+	Events []*models.AuditEvent
+}
+
+var _ repository.AuditRepository = (*FakeAuditRepository)(nil)
+
+func (f *FakeAuditRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	f.Events = append(f.Events, event)
+	return nil
+}
+
+func (f *FakeAuditRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page models.Page) ([]*models.AuditEvent, int64, error) {
+	var events []*models.AuditEvent
+	for _, event := range f.Events {
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, int64(len(events)), nil
+}
+
+// FakeOutboxRepository запоминает записанные доменные события
+type FakeOutboxRepository struct {
+	Events []*models.OutboxEvent
+}
+
+var _ repository.OutboxRepository = (*FakeOutboxRepository)(nil)
+
+func (f *FakeOutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	f.Events = append(f.Events, event)
+	return nil
+}
+
+func (f *FakeOutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+	return f.Events, nil
+}
+
+func (f *FakeOutboxRepository) Schedule(ctx context.Context, ids []uint64, at time.Time) error {
+	return nil
+}
+
+func (f *FakeOutboxRepository) MarkPublished(ctx context.Context, id uint64) error {
+	return nil
+}
+
+func (f *FakeOutboxRepository) MarkFailed(ctx context.Context, id uint64, reason string, nextAttemptAt time.Time) error {
+	return nil
+}
+
+func (f *FakeOutboxRepository) MarkDead(ctx context.Context, id uint64, reason string) error {
+	return nil
+}
+
+func (f *FakeOutboxRepository) TryLock(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
 func TestUserService_Create(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
 	
 	input := models.CreateUserInput{
 		Email:     "test@example.com",
@@ -73,7 +181,7 @@ func TestUserService_Create(t *testing.T) {
 	mockRepo.On("GetByEmail", input.Email).Return(&models.User{}, nil).Once()
 	
 	// Act
-	user, err := service.Create(input)
+	user, err := service.Create(ctx, input)
 	
 	// Assert
 	assert.Nil(t, user)
@@ -84,7 +192,7 @@ func TestUserService_Create(t *testing.T) {
 	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Once()
 	
 	// Act
-	user, err = service.Create(input)
+	user, err = service.Create(ctx, input)
 	
 	// Assert
 	assert.NotNil(t, user)
@@ -99,7 +207,8 @@ func TestUserService_Create(t *testing.T) {
 func TestUserService_GetByID(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
 	
 	id := uuid.New()
 	expectedUser := &models.User{
@@ -113,7 +222,7 @@ func TestUserService_GetByID(t *testing.T) {
 	mockRepo.On("GetByID", id).Return(expectedUser, nil).Once()
 	
 	// Act
-	user, err := service.GetByID(id)
+	user, err := service.GetByID(ctx, id)
 	
 	// Assert
 	assert.Nil(t, err)
@@ -123,7 +232,7 @@ func TestUserService_GetByID(t *testing.T) {
 	mockRepo.On("GetByID", id).Return(nil, errors.New("user not found")).Once()
 	
 	// Act
-	user, err = service.GetByID(id)
+	user, err = service.GetByID(ctx, id)
 	
 	// Assert
 	assert.Nil(t, user)
@@ -133,10 +242,60 @@ func TestUserService_GetByID(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_GetByEmail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	// Case: email нормализуется перед поиском
+	mockRepo.On("GetByEmail", "test@example.com").Return(user, nil).Once()
+
+	// Act
+	result, err := service.GetByEmail(ctx, "  Test@Example.COM ")
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, user, result)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Create_NormalizesEmail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	input := models.CreateUserInput{
+		Email:     "John.Doe@Example.com",
+		FirstName: "John",
+		LastName:  "Doe",
+		Password:  "password123",
+	}
+
+	mockRepo.On("GetByEmail", "john.doe@example.com").Return(nil, errors.New("user not found")).Once()
+	mockRepo.On("Create", mock.MatchedBy(func(user *models.User) bool {
+		return user.Email == "john.doe@example.com"
+	})).Return(nil).Once()
+
+	// Act
+	user, err := service.Create(ctx, input)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, "john.doe@example.com", user.Email)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_Update(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
 	
 	id := uuid.New()
 	existingUser := &models.User{
@@ -153,10 +312,10 @@ func TestUserService_Update(t *testing.T) {
 	}
 	
 	// Case 1: User not found
-	mockRepo.On("GetByID", id).Return(nil, errors.New("user not found")).Once()
+	mockRepo.On("GetByIDForUpdate", id).Return(nil, errors.New("user not found")).Once()
 	
 	// Act
-	user, err := service.Update(id, input)
+	user, err := service.Update(ctx, id, input)
 	
 	// Assert
 	assert.Nil(t, user)
@@ -164,23 +323,23 @@ func TestUserService_Update(t *testing.T) {
 	assert.Equal(t, "user not found", err.Error())
 	
 	// Case 2: Email already exists
-	mockRepo.On("GetByID", id).Return(existingUser, nil).Once()
+	mockRepo.On("GetByIDForUpdate", id).Return(existingUser, nil).Once()
 	mockRepo.On("GetByEmail", input.Email).Return(&models.User{ID: uuid.New()}, nil).Once()
 	
 	// Act
-	user, err = service.Update(id, input)
+	user, err = service.Update(ctx, id, input)
 	
 	// Assert
 	assert.Nil(t, user)
 	assert.Equal(t, ErrEmailAlreadyExists, err)
 	
 	// Case 3: Successful update
-	mockRepo.On("GetByID", id).Return(existingUser, nil).Once()
+	mockRepo.On("GetByIDForUpdate", id).Return(existingUser, nil).Once()
 	mockRepo.On("GetByEmail", input.Email).Return(nil, errors.New("not found")).Once()
 	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil).Once()
 	
 	// Act
-	user, err = service.Update(id, input)
+	user, err = service.Update(ctx, id, input)
 	
 	// Assert
 	assert.NotNil(t, user)
@@ -195,28 +354,330 @@ func TestUserService_Update(t *testing.T) {
 func TestUserService_Delete(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
 	
 	id := uuid.New()
 	
 	// Case 1: Successful deletion
+	mockRepo.On("GetByID", id).Return(&models.User{ID: id}, nil).Once()
 	mockRepo.On("Delete", id).Return(nil).Once()
 	
 	// Act
-	err := service.Delete(id)
+	err := service.Delete(ctx, id)
 	
 	// Assert
 	assert.Nil(t, err)
 	
 	// Case 2: Error during deletion
+	mockRepo.On("GetByID", id).Return(&models.User{ID: id}, nil).Once()
 	mockRepo.On("Delete", id).Return(errors.New("deletion error")).Once()
 	
 	// Act
-	err = service.Delete(id)
+	err = service.Delete(ctx, id)
 	
 	// Assert
 	assert.NotNil(t, err)
 	assert.Equal(t, "deletion error", err.Error())
 	
+	// Case 3: User does not exist
+	mockRepo.On("GetByID", id).Return(nil, repository.ErrUserNotFound).Once()
+	
+	// Act
+	err = service.Delete(ctx, id)
+	
+	// Assert
+	assert.Nil(t, err)
+	
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestUserService_List(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	filter := models.UserFilter{LastName: "doe"}
+	users := []*models.User{{ID: uuid.New(), LastName: "Doe"}}
+
+	// Case: лимит по умолчанию подставляется сервисом
+	mockRepo.On("List", filter, models.Page{Limit: DefaultPageLimit}).Return(users, int64(1), nil).Once()
+
+	// Act
+	result, total, err := service.List(ctx, filter, models.Page{})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, users, result)
+	assert.Equal(t, int64(1), total)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Export(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	filter := models.UserFilter{Email: "example.com"}
+	batches := [][]*models.User{
+		{{Email: "a@example.com"}, {Email: "b@example.com"}},
+		{{Email: "c@example.com"}},
+	}
+	mockRepo.On("FindInBatches", filter, ExportBatchSize, mock.Anything).Return(batches, nil).Once()
+
+	// Act
+	var emails []string
+	err := service.Export(ctx, filter, func(users []*models.User) error {
+		for _, user := range users {
+			emails = append(emails, user.Email)
+		}
+		return nil
+	})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "c@example.com"}, emails)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Batch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	deleteID, missingID := uuid.New(), uuid.New()
+	ops := []models.BatchOperation{
+		{Op: models.BatchOpCreate, Create: models.CreateUserInput{Email: "new@example.com", FirstName: "New", LastName: "User", Password: "password123"}},
+		{Op: models.BatchOpUpdate, ID: missingID, Update: models.UpdateUserInput{FirstName: "Nobody"}},
+		{Op: models.BatchOpDelete, ID: deleteID},
+	}
+
+	// Case 1: неатомарный пакет - ошибка одной операции не мешает остальным
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, errors.New("user not found")).Once()
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Once()
+	mockRepo.On("GetByIDForUpdate", missingID).Return(nil, errors.New("user not found")).Once()
+	mockRepo.On("GetByID", deleteID).Return(&models.User{ID: deleteID}, nil).Once()
+	mockRepo.On("Delete", deleteID).Return(nil).Once()
+
+	// Act
+	results, committed, err := service.Batch(ctx, ops, false)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.True(t, committed)
+	assert.Len(t, results, 3)
+	assert.NotNil(t, results[0].User)
+	assert.Nil(t, results[0].Err)
+	assert.NotNil(t, results[1].Err)
+	assert.Nil(t, results[2].Err)
+	assert.False(t, results[2].Skipped)
+
+	// Case 2: атомарный пакет - после ошибки все операции откатываются, последующие не выполняются
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, errors.New("user not found")).Once()
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Once()
+	mockRepo.On("GetByIDForUpdate", missingID).Return(nil, errors.New("user not found")).Once()
+
+	// Act
+	results, committed, err = service.Batch(ctx, ops, true)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.False(t, committed)
+	assert.True(t, results[0].Skipped)
+	assert.NotNil(t, results[1].Err)
+	assert.False(t, results[1].Skipped)
+	assert.True(t, results[2].Skipped)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Search(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	found := []*models.UserSearchResult{{
+		User: &models.User{Email: "john.doe@example.com", FirstName: "John", LastName: "<Doe>"},
+		Rank: 0.9,
+	}}
+
+	// Case: лимит по умолчанию, подсветка совпадений без учета регистра с экранированием HTML
+	mockRepo.On("Search", "JOHN doe", DefaultSearchLimit).Return(found, nil).Once()
+
+	// Act
+	results, err := service.Search(ctx, "JOHN doe", 0)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "<mark>john</mark>.<mark>doe</mark>@example.com", results[0].Highlights["email"])
+	assert.Equal(t, "<mark>John</mark>", results[0].Highlights["first_name"])
+	assert.Equal(t, "&lt;<mark>Doe</mark>&gt;", results[0].Highlights["last_name"])
+
+	// Case: лимит ограничивается максимальным значением
+	mockRepo.On("Search", "x", MaxSearchLimit).Return(nil, nil).Once()
+
+	// Act
+	results, err = service.Search(ctx, "x", 1000)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Audit(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	auditRepo := new(FakeAuditRepository)
+	service := NewUserService(mockRepo, auditRepo, new(FakeOutboxRepository), new(MockTransactor))
+	ctx := WithRequestMeta(context.Background(), RequestMeta{
+		Actor:     "admin@example.com",
+		SourceIP:  "10.0.0.1",
+		RequestID: "req-1",
+	})
+
+	id := uuid.New()
+	existingUser := &models.User{ID: id, Email: "old@example.com", FirstName: "Old", LastName: "Name", Password: "old-hash"}
+
+	mockRepo.On("GetByIDForUpdate", id).Return(existingUser, nil).Once()
+	mockRepo.On("GetByEmail", "new@example.com").Return(nil, repository.ErrUserNotFound).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+	// Act
+	_, err := service.Update(ctx, id, models.UpdateUserInput{Email: "new@example.com", Password: "new-password"})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, auditRepo.Events, 1)
+
+	event := auditRepo.Events[0]
+	assert.Equal(t, id, event.UserID)
+	assert.Equal(t, models.AuditActionUpdate, event.Action)
+	assert.Equal(t, "admin@example.com", event.Actor)
+	assert.Equal(t, "10.0.0.1", event.SourceIP)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, map[string]models.FieldChange{
+		"email":    {Old: "old@example.com", New: "new@example.com"},
+		"password": {New: models.AuditPasswordChanged},
+	}, event.Changes)
+
+	// Case: удаление фиксирует прежние значения, исполнитель по умолчанию - anonymous
+	mockRepo.On("GetByID", id).Return(existingUser, nil).Once()
+	mockRepo.On("Delete", id).Return(nil).Once()
+
+	// Act
+	err = service.Delete(context.Background(), id)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, auditRepo.Events, 2)
+	assert.Equal(t, models.AuditActionDelete, auditRepo.Events[1].Action)
+	assert.Equal(t, AnonymousActor, auditRepo.Events[1].Actor)
+	assert.Equal(t, models.FieldChange{Old: "new@example.com"}, auditRepo.Events[1].Changes["email"])
+	assert.NotContains(t, auditRepo.Events[1].Changes, "password")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_DomainEvents(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	outboxRepo := new(FakeOutboxRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), outboxRepo, new(MockTransactor))
+	ctx := context.Background()
+
+	input := models.CreateUserInput{Email: "test@example.com", FirstName: "John", LastName: "Doe", Password: "password123"}
+	mockRepo.On("GetByEmail", input.Email).Return(nil, repository.ErrUserNotFound).Once()
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+	// Act
+	user, err := service.Create(ctx, input)
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Len(t, outboxRepo.Events, 1)
+
+	event := outboxRepo.Events[0]
+	assert.Equal(t, models.EventUserCreated, event.Type)
+	assert.Equal(t, user.ID, event.AggregateID)
+	assert.NotEqual(t, uuid.Nil, event.EventID)
+
+	var payload struct {
+		User          map[string]interface{} `json:"user"`
+		ChangedFields []string               `json:"changed_fields"`
+	}
+	assert.Nil(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, "test@example.com", payload.User["email"])
+	assert.NotContains(t, payload.User, "password")
+	assert.Equal(t, []string{"email", "first_name", "last_name", "password", "role"}, payload.ChangedFields)
+
+	// Case: неудачное изменение не порождает события
+	mockRepo.On("GetByIDForUpdate", user.ID).Return(user, nil).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(errors.New("db error")).Once()
+
+	// Act
+	_, err = service.Update(ctx, user.ID, models.UpdateUserInput{FirstName: "Jane"})
+
+	// Assert
+	assert.NotNil(t, err)
+	assert.Len(t, outboxRepo.Events, 1)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_Authenticate(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: string(hash), Role: models.RoleAdmin}
+	mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+	mockRepo.On("GetByEmail", "missing@example.com").Return(nil, repository.ErrUserNotFound)
+
+	// Case: верный пароль, email нормализуется
+	result, err := service.Authenticate(ctx, " Test@Example.com", "password123")
+	assert.NoError(t, err)
+	assert.Equal(t, user, result)
+
+	// Case: неверный пароль и неизвестный email неразличимы
+	_, err = service.Authenticate(ctx, "test@example.com", "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = service.Authenticate(ctx, "missing@example.com", "password123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestUserService_EnsureAdmin(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, new(FakeAuditRepository), new(FakeOutboxRepository), new(MockTransactor))
+	ctx := context.Background()
+
+	// Case: администратора нет - он создается
+	mockRepo.On("GetByEmail", "admin@example.com").Return(nil, repository.ErrUserNotFound).Twice()
+	mockRepo.On("Create", mock.MatchedBy(func(u *models.User) bool { return u.IsAdmin() })).Return(nil).Once()
+
+	admin, err := service.EnsureAdmin(ctx, "admin@example.com", "admin-password")
+	assert.NoError(t, err)
+	assert.True(t, admin.IsAdmin())
+
+	// Case: существующий пользователь получает роль администратора
+	user := &models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+	mockRepo.On("GetByEmail", "user@example.com").Return(user, nil).Once()
+	mockRepo.On("GetByIDForUpdate", user.ID).Return(user, nil).Once()
+	mockRepo.On("Update", mock.MatchedBy(func(u *models.User) bool { return u.IsAdmin() })).Return(nil).Once()
+
+	promoted, err := service.EnsureAdmin(ctx, "user@example.com", "")
+	assert.NoError(t, err)
+	assert.True(t, promoted.IsAdmin())
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/webhook"
+	"github.com/google/uuid"
+)
+
+// ErrWebhookURLNotAllowed возвращается для адреса подписки, на который вебхуки не отправляются (см. webhook.CheckURL)
+var ErrWebhookURLNotAllowed = errors.New("webhook url is not allowed")
+
+// WebhookServiceInterface определяет интерфейс сервиса управления вебхуками
+type WebhookServiceInterface interface {
+	Create(ctx context.Context, input models.CreateWebhookInput) (*models.WebhookSubscription, error)
+	List(ctx context.Context) ([]*models.WebhookSubscription, error)
+	Get(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error)
+	Replay(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+}
+
+// WebhookService представляет сервис управления подписками на вебхуки
+type WebhookService struct {
+	webhookRepo     repository.WebhookRepository
+	allowedNetworks webhook.AllowedNetworks
+}
+
+// NewWebhookService создает новый экземпляр WebhookService
+func NewWebhookService(webhookRepo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo}
+}
+
+// WithAllowedNetworks разрешает подписки на адреса из подсетей networks, даже если они частные
+// (см. webhook.AllowedNetworks); подсети должны совпадать с подсетями клиента доставки
+func (s *WebhookService) WithAllowedNetworks(networks webhook.AllowedNetworks) *WebhookService {
+	s.allowedNetworks = networks
+	return s
+}
+
+var _ WebhookServiceInterface = (*WebhookService)(nil)
+
+// Create создает подписку; если секрет не передан, он генерируется
+func (s *WebhookService) Create(ctx context.Context, input models.CreateWebhookInput) (*models.WebhookSubscription, error) {
+	if err := webhook.CheckURL(input.URL, s.allowedNetworks); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookURLNotAllowed, err)
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = "whsec_" + hex.EncodeToString(buf)
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     secret,
+		Active:     true,
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// List получает все подписки
+func (s *WebhookService) List(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return s.webhookRepo.ListSubscriptions(ctx)
+}
+
+// Get получает подписку по ID
+func (s *WebhookService) Get(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	return s.webhookRepo.GetSubscription(ctx, id)
+}
+
+// Delete удаляет подписку; неотправленные доставки будут отмечены как неудачные
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.webhookRepo.GetSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries получает журнал доставок подписки
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error) {
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if _, err := s.webhookRepo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, subscriptionID, page)
+}
+
+// Replay ставит в очередь повторную доставку того же тела события той же подписке
+func (s *WebhookService) Replay(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.webhookRepo.GetSubscription(ctx, original.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	replay := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		ReplayOf:       &original.ID,
+	}
+	if err := s.webhookRepo.CreateDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
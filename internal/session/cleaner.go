@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/repository"
+)
+
+// Cleaner периодически удаляет истекшие сессии из хранилища
+type Cleaner struct {
+	repo     repository.SessionRepository
+	interval time.Duration
+
+	now func() time.Time
+}
+
+// NewCleaner создает новый экземпляр Cleaner
+func NewCleaner(repo repository.SessionRepository, interval time.Duration) *Cleaner {
+	return &Cleaner{repo: repo, interval: interval, now: time.Now}
+}
+
+// Run удаляет истекшие сессии каждые interval до отмены ctx
+func (c *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Cleanup(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Session cleanup error: %v", err)
+			}
+		}
+	}
+}
+
+// Cleanup удаляет сессии, истекшие к текущему моменту, и возвращает их количество
+func (c *Cleaner) Cleanup(ctx context.Context) (int64, error) {
+	deleted, err := c.repo.DeleteExpired(ctx, c.now())
+	if err == nil && deleted > 0 {
+		log.Printf("Deleted %d expired sessions", deleted)
+	}
+	return deleted, err
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"log"
+
+	"github.com/gorilla/securecookie"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Назначение ключей, выводимых из ключа сессий
+const (
+	authKeyInfo       = "go-user-api session authentication"
+	encryptionKeyInfo = "go-user-api session encryption"
+)
+
+// newCodecs создает кодеки cookie из ключей сессий: значение подписывается (HMAC-SHA256) и шифруется (AES-256)
+// ключами, выведенными из каждого ключа. Кодируется первым ключом, а декодируется любым из них.
+// Если ключей нет, используется случайный ключ процесса. maxAge ограничивает срок, в течение которого
+// принимается подписанное значение.
+func newCodecs(keys []string, maxAge int) ([]securecookie.Codec, error) {
+	if len(keys) == 0 {
+		log.Println("SESSION_KEYS is not set: using a random session key, sessions will not survive restarts")
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		keys = []string{string(random)}
+	}
+
+	var keyPairs [][]byte
+	for _, key := range keys {
+		authKey, err := deriveKey(key, authKeyInfo, 64)
+		if err != nil {
+			return nil, err
+		}
+		encryptionKey, err := deriveKey(key, encryptionKeyInfo, 32)
+		if err != nil {
+			return nil, err
+		}
+		keyPairs = append(keyPairs, authKey, encryptionKey)
+	}
+
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		if c, ok := codec.(*securecookie.SecureCookie); ok {
+			c.MaxAge(maxAge)
+		}
+	}
+	return codecs, nil
+}
+
+// deriveKey выводит из ключа сессий ключ длины size для назначения info (HKDF-SHA256)
+func deriveKey(key, info string, size int) ([]byte, error) {
+	derived := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(key), nil, []byte(info)), derived); err != nil {
+		return nil, err
+	}
+	return derived, nil
+}
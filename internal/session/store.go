@@ -0,0 +1,298 @@
+// Package session содержит хранилище сессий веб-интерфейса на стороне сервера для gin-contrib/sessions.
+// В cookie хранится только подписанный и зашифрованный токен сессии, а данные, владелец и сроки сессии -
+// в repository.SessionRepository, поэтому сессии можно перечислить и отозвать.
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/service"
+	"github.com/gin-contrib/sessions"
+	"github.com/google/uuid"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// CookieName - имя cookie сессии веб-интерфейса
+const CookieName = "user-api-session"
+
+// UserIDKey - ключ значения сессии с ID пользователя (строкой); по нему сессии привязываются к пользователю
+const UserIDKey = "user_id"
+
+// renewKey - служебное значение сессии, по которому Save выдает ей новый токен (см. Renew)
+const renewKey = "_renew"
+
+// touchInterval - как часто продлевается срок бездействия сессии при чтении: не чаще одной записи в минуту
+const touchInterval = time.Minute
+
+// Убедимся что Store реализует интерфейс sessions.Store
+var _ sessions.Store = (*Store)(nil)
+
+// Store хранит сессии на сервере. Сессия истекает после IdleTimeout без запросов или через MaxAge
+// после создания, смотря что наступит раньше; истекшая, отозванная или поддельная сессия заменяется новой.
+type Store struct {
+	repo        repository.SessionRepository
+	codecs      []securecookie.Codec
+	options     *gsessions.Options
+	idleTimeout time.Duration
+	maxAge      time.Duration
+
+	now func() time.Time
+}
+
+// NewStore создает хранилище сессий по конфигурации cfg
+func NewStore(repo repository.SessionRepository, cfg config.SessionConfig) (*Store, error) {
+	codecs, err := newCodecs(cfg.Keys, int(cfg.MaxAge.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		repo:   repo,
+		codecs: codecs,
+		options: &gsessions.Options{
+			Path:     cfg.CookiePath,
+			Domain:   cfg.CookieDomain,
+			MaxAge:   int(cfg.MaxAge.Seconds()),
+			Secure:   cfg.CookieSecure,
+			HttpOnly: cfg.CookieHTTPOnly,
+			SameSite: sameSiteMode(cfg.CookieSameSite),
+		},
+		idleTimeout: cfg.IdleTimeout,
+		maxAge:      cfg.MaxAge,
+		now:         time.Now,
+	}, nil
+}
+
+// Options задает параметры cookie новых сессий
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+// Get возвращает сессию запроса; в пределах запроса сессия загружается один раз
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New загружает сессию по cookie запроса или создает новую.
+// Ошибки никогда не возвращаются: gin-contrib/sessions не умеет с ними работать, поэтому
+// при недоступном хранилище ошибка записывается в журнал, а запрос получает новую сессию.
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		// Cookie подписана удаленным ключом, истекла или подделана
+		return session, nil
+	}
+
+	ctx := r.Context()
+	record, err := s.repo.GetByID(ctx, hashToken(token))
+	if err != nil {
+		if !errors.Is(err, repository.ErrSessionNotFound) {
+			log.Printf("Failed to load session: %v", err)
+		}
+		return session, nil
+	}
+	now := s.now()
+	if !now.Before(record.ExpiresAt) {
+		return session, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(record.Data)).Decode(&session.Values); err != nil {
+		log.Printf("Failed to decode session: %v", err)
+		return session, nil
+	}
+	session.ID = token
+	session.IsNew = false
+
+	if now.Sub(record.LastSeenAt) >= touchInterval {
+		record.LastSeenAt = now
+		record.ExpiresAt = s.expiresAt(record.CreatedAt, now)
+		if err := s.repo.Update(ctx, record); err != nil && !errors.Is(err, repository.ErrSessionNotFound) {
+			log.Printf("Failed to extend session: %v", err)
+		}
+	}
+	return session, nil
+}
+
+// Save сохраняет сессию и отправляет cookie с ее токеном.
+// Отрицательный Options.MaxAge удаляет сессию (выход). Если сессию успели отозвать, создается новая
+// пустая сессия, чтобы запрос не восстановил вход.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	ctx := r.Context()
+
+	if session.Options != nil && session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.repo.Delete(ctx, hashToken(session.ID)); err != nil {
+				return err
+			}
+		}
+		// Cookie удаляется с теми же Path и Domain, с которыми была выдана
+		options := *s.options
+		options.MaxAge = -1
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", &options))
+		return nil
+	}
+
+	// Новый токен: старая запись удаляется, а значения переносятся в новую
+	if _, ok := session.Values[renewKey]; ok {
+		delete(session.Values, renewKey)
+		if session.ID != "" {
+			if err := s.repo.Delete(ctx, hashToken(session.ID)); err != nil {
+				return err
+			}
+			session.ID = ""
+		}
+	}
+
+	now := s.now()
+	if session.ID != "" {
+		err := s.update(ctx, session, now)
+		if err == nil {
+			return s.setCookie(w, session)
+		}
+		if !errors.Is(err, repository.ErrSessionNotFound) {
+			return err
+		}
+		session.Values = make(map[interface{}]interface{})
+	}
+	return s.create(ctx, r, w, session, now)
+}
+
+// update сохраняет данные существующей сессии; repository.ErrSessionNotFound означает, что сессия отозвана или истекла
+func (s *Store) update(ctx context.Context, session *gsessions.Session, now time.Time) error {
+	record, err := s.repo.GetByID(ctx, hashToken(session.ID))
+	if err != nil {
+		return err
+	}
+	if !now.Before(record.ExpiresAt) {
+		return repository.ErrSessionNotFound
+	}
+
+	if record.Data, err = encodeValues(session.Values); err != nil {
+		return err
+	}
+	record.UserID = userID(session.Values)
+	record.LastSeenAt = now
+	record.ExpiresAt = s.expiresAt(record.CreatedAt, now)
+	return s.repo.Update(ctx, record)
+}
+
+// create сохраняет новую сессию со случайным токеном
+func (s *Store) create(ctx context.Context, r *http.Request, w http.ResponseWriter, session *gsessions.Session, now time.Time) error {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	data, err := encodeValues(session.Values)
+	if err != nil {
+		return err
+	}
+
+	session.ID = base64.RawURLEncoding.EncodeToString(token)
+	err = s.repo.Create(ctx, &models.Session{
+		ID:         hashToken(session.ID),
+		UserID:     userID(session.Values),
+		Data:       data,
+		UserAgent:  truncate(r.UserAgent(), 512),
+		SourceIP:   service.RequestMetaFromContext(ctx).SourceIP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  s.expiresAt(now, now),
+	})
+	if err != nil {
+		return err
+	}
+	session.IsNew = false
+	return s.setCookie(w, session)
+}
+
+// setCookie отправляет cookie с подписанным и зашифрованным токеном сессии
+func (s *Store) setCookie(w http.ResponseWriter, session *gsessions.Session) error {
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// Renew выдает сессии новый токен при сохранении, сохраняя ее значения. Вызывается при входе,
+// чтобы токен, полученный до входа (например, подброшенный злоумышленником), не стал токеном пользователя.
+func Renew(session sessions.Session) {
+	session.Set(renewKey, true)
+}
+
+// expiresAt возвращает срок действия сессии, созданной в createdAt и использованной в lastSeenAt
+func (s *Store) expiresAt(createdAt, lastSeenAt time.Time) time.Time {
+	expiresAt := createdAt.Add(s.maxAge)
+	if s.idleTimeout > 0 {
+		if idle := lastSeenAt.Add(s.idleTimeout); idle.Before(expiresAt) {
+			expiresAt = idle
+		}
+	}
+	return expiresAt
+}
+
+// hashToken возвращает ID сессии в хранилище для токена из cookie
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// userID возвращает ID пользователя, которому принадлежит сессия, или nil для анонимной сессии
+func userID(values map[interface{}]interface{}) *uuid.UUID {
+	value, _ := values[UserIDKey].(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func encodeValues(values map[interface{}]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func truncate(value string, size int) string {
+	if len(value) > size {
+		return value[:size]
+	}
+	return value
+}
+
+// sameSiteMode переводит значение SameSite из конфигурации в http.SameSite
+func sameSiteMode(value string) http.SameSite {
+	switch value {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
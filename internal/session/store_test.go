@@ -0,0 +1,245 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/Est1ege/go-user-api/internal/repository/memory"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClock - управляемые часы хранилища
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// testStore - хранилище сессий в памяти с управляемыми часами
+type testStore struct {
+	*Store
+	repo  repository.SessionRepository
+	clock *testClock
+}
+
+func newTestStore(t *testing.T, repo repository.SessionRepository, cfg config.SessionConfig) *testStore {
+	store, err := NewStore(repo, cfg)
+	require.NoError(t, err)
+	clock := &testClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store.now = clock.Now
+	return &testStore{Store: store, repo: repo, clock: clock}
+}
+
+func sessionConfig(keys ...string) config.SessionConfig {
+	cfg := config.Default().Session
+	cfg.Keys = keys
+	return cfg
+}
+
+// router создает маршрутизатор: /login сохраняет в сессии пользователя, /whoami читает его, /logout удаляет сессию
+func (s *testStore) router(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(sessions.Sessions(CookieName, s.Store))
+	router.GET("/login", func(c *gin.Context) {
+		session := sessions.Default(c)
+		session.Set(UserIDKey, c.Query("user"))
+		require.NoError(t, session.Save())
+	})
+	router.GET("/whoami", func(c *gin.Context) {
+		user, _ := sessions.Default(c).Get(UserIDKey).(string)
+		c.String(http.StatusOK, user)
+	})
+	router.GET("/touch", func(c *gin.Context) {
+		session := sessions.Default(c)
+		session.Set("visited", true)
+		require.NoError(t, session.Save())
+	})
+	router.GET("/renew", func(c *gin.Context) {
+		session := sessions.Default(c)
+		Renew(session)
+		require.NoError(t, session.Save())
+	})
+	router.GET("/logout", func(c *gin.Context) {
+		session := sessions.Default(c)
+		session.Options(sessions.Options{MaxAge: -1})
+		require.NoError(t, session.Save())
+	})
+	return router
+}
+
+// do выполняет запрос с cookie (если она есть) и возвращает ответ
+func do(router *gin.Engine, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// login создает сессию пользователя userID и возвращает ее cookie
+func login(t *testing.T, router *gin.Engine, userID uuid.UUID) *http.Cookie {
+	cookies := do(router, "/login?user="+userID.String(), nil).Result().Cookies()
+	require.Len(t, cookies, 1)
+	return cookies[0]
+}
+
+func whoami(router *gin.Engine, cookie *http.Cookie) string {
+	return do(router, "/whoami", cookie).Body.String()
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	repo := memory.NewSessionRepository(memory.NewDB())
+	store := newTestStore(t, repo, sessionConfig("session-key-0123456789abcdef0123"))
+	router := store.router(t)
+	userID := uuid.New()
+
+	cookie := login(t, router, userID)
+	assert.NotContains(t, cookie.Value, userID.String(), "the cookie carries only an encrypted token")
+	assert.Equal(t, userID.String(), whoami(router, cookie))
+
+	sessions, err := repo.ListByUserID(context.Background(), userID, store.clock.now)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, store.clock.now.Add(30*time.Minute), sessions[0].ExpiresAt)
+	assert.NotContains(t, sessions[0].ID, cookie.Value)
+}
+
+func TestStore_IdleAndAbsoluteExpiry(t *testing.T) {
+	cfg := sessionConfig("session-key-0123456789abcdef0123")
+	cfg.IdleTimeout = 30 * time.Minute
+	cfg.MaxAge = 2 * time.Hour
+
+	store := newTestStore(t, memory.NewSessionRepository(memory.NewDB()), cfg)
+	router := store.router(t)
+	userID := uuid.New()
+	cookie := login(t, router, userID)
+
+	// Запросы чаще срока бездействия продлевают сессию
+	for i := 0; i < 3; i++ {
+		store.clock.now = store.clock.now.Add(20 * time.Minute)
+		assert.Equal(t, userID.String(), whoami(router, cookie))
+	}
+
+	// Без запросов дольше срока бездействия сессия истекает
+	store.clock.now = store.clock.now.Add(31 * time.Minute)
+	assert.Empty(t, whoami(router, cookie))
+
+	// Активная сессия все равно истекает через MaxAge после создания
+	cookie = login(t, router, userID)
+	for elapsed := time.Duration(0); elapsed < 2*time.Hour-20*time.Minute; elapsed += 20 * time.Minute {
+		store.clock.now = store.clock.now.Add(20 * time.Minute)
+		assert.Equal(t, userID.String(), whoami(router, cookie))
+	}
+	store.clock.now = store.clock.now.Add(20 * time.Minute)
+	assert.Empty(t, whoami(router, cookie))
+}
+
+func TestStore_Revocation(t *testing.T) {
+	repo := memory.NewSessionRepository(memory.NewDB())
+	store := newTestStore(t, repo, sessionConfig("session-key-0123456789abcdef0123"))
+	router := store.router(t)
+	userID := uuid.New()
+
+	first, second := login(t, router, userID), login(t, router, userID)
+
+	// Выход удаляет только текущую сессию
+	do(router, "/logout", first)
+	assert.Empty(t, whoami(router, first))
+	assert.Equal(t, userID.String(), whoami(router, second))
+
+	// Выход везде
+	deleted, err := repo.DeleteByUserID(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Empty(t, whoami(router, second))
+
+	// Запись в отозванную сессию не восстанавливает вход
+	cookies := do(router, "/touch", second).Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Empty(t, whoami(router, cookies[0]))
+}
+
+func TestStore_Renew(t *testing.T) {
+	repo := memory.NewSessionRepository(memory.NewDB())
+	store := newTestStore(t, repo, sessionConfig("session-key-0123456789abcdef0123"))
+	router := store.router(t)
+	userID := uuid.New()
+	old := login(t, router, userID)
+
+	cookies := do(router, "/renew", old).Result().Cookies()
+	require.Len(t, cookies, 1)
+	renewed := cookies[0]
+
+	// Значения переносятся в сессию с новым токеном, а старый токен перестает действовать
+	assert.NotEqual(t, old.Value, renewed.Value)
+	assert.Equal(t, userID.String(), whoami(router, renewed))
+	assert.Empty(t, whoami(router, old))
+
+	sessions, err := repo.ListByUserID(context.Background(), userID, store.clock.now)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+func TestStore_KeyRotation(t *testing.T) {
+	const oldKey, newKey = "old-session-key-0123456789abcdef", "new-session-key-0123456789abcdef"
+	repo := memory.NewSessionRepository(memory.NewDB())
+	userID := uuid.New()
+
+	cookie := login(t, newTestStore(t, repo, sessionConfig(oldKey)).router(t), userID)
+
+	assert.Equal(t, userID.String(), whoami(newTestStore(t, repo, sessionConfig(newKey, oldKey)).router(t), cookie),
+		"sessions issued with the previous key survive rotation")
+	assert.Empty(t, whoami(newTestStore(t, repo, sessionConfig(newKey)).router(t), cookie),
+		"sessions are rejected once the old key is removed")
+}
+
+func TestStore_CookieOptions(t *testing.T) {
+	cfg := sessionConfig("session-key-0123456789abcdef0123")
+	cfg.MaxAge = 2 * time.Hour
+	cfg.CookieDomain = "example.com"
+	cfg.CookieSecure = true
+	cfg.CookieSameSite = "strict"
+
+	store := newTestStore(t, memory.NewSessionRepository(memory.NewDB()), cfg)
+	cookie := login(t, store.router(t), uuid.New())
+	assert.Equal(t, CookieName, cookie.Name)
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, "example.com", cookie.Domain)
+	assert.Equal(t, 7200, cookie.MaxAge)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+}
+
+func TestCleaner_Cleanup(t *testing.T) {
+	repo := memory.NewSessionRepository(memory.NewDB())
+	store := newTestStore(t, repo, sessionConfig("session-key-0123456789abcdef0123"))
+	router := store.router(t)
+	login(t, router, uuid.New())
+	login(t, router, uuid.New())
+
+	cleaner := NewCleaner(repo, time.Minute)
+	cleaner.now = func() time.Time { return store.clock.now.Add(10 * time.Minute) }
+	deleted, err := cleaner.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	cleaner.now = func() time.Time { return store.clock.now.Add(time.Hour) }
+	deleted, err = cleaner.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed возвращается для адреса получателя, на который вебхуки не отправляются
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace - адреса операторов связи за NAT (CGNAT, RFC 6598)
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// AllowedIP сообщает, можно ли отправлять вебхуки на адрес ip. Запрещены loopback, link-local
+// (в том числе адрес метаданных облака 169.254.169.254), частные (10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16,
+// fc00::/7) и CGNAT (100.64.0.0/10), неуказанный и групповые адреса: иначе через подписку можно обращаться
+// к службам на самом сервере и в его служебной сети.
+func AllowedIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsPrivate() && !sharedAddressSpace.Contains(ip) && !ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// AllowedNetworks - подсети, в которые вебхуки отправляются, даже если AllowedIP запрещает их адреса:
+// например, внутренняя сеть, в которой работают получатели. Пустой список ничего не разрешает.
+type AllowedNetworks []*net.IPNet
+
+// ParseNetworks разбирает подсети в нотации CIDR; отдельный IP-адрес задает подсеть из одного адреса
+func ParseNetworks(values []string) (AllowedNetworks, error) {
+	networks := make(AllowedNetworks, 0, len(values))
+	for _, value := range values {
+		if ip := net.ParseIP(value); ip != nil {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Allows сообщает, можно ли отправлять вебхуки на адрес ip: его разрешает AllowedIP или он входит в одну из подсетей
+func (n AllowedNetworks) Allows(ip net.IP) bool {
+	if AllowedIP(ip) {
+		return true
+	}
+	for _, network := range n {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL проверяет адрес подписки: схема http или https, указан хост, и хост не является
+// запрещенным IP-адресом (см. AllowedNetworks.Allows) или localhost. Имена хостов окончательно проверяются
+// при соединении (см. NewClient).
+func CheckURL(raw string, allowed AllowedNetworks) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("host is required")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !allowed.Allows(ip) {
+		return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+	}
+	return nil
+}
+
+// NewClient создает HTTP-клиент доставки с таймаутом timeout, который не соединяется с запрещенными адресами,
+// кроме адресов из allowed. Адрес проверяется после разрешения имени, поэтому отклоняются и имена, указывающие
+// на 127.0.0.1, и перенаправления на такие адреса. Прокси из окружения не используется: через него проверка бы не работала.
+func NewClient(timeout time.Duration, allowed AllowedNetworks) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed.Allows(ip) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
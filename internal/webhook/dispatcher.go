@@ -0,0 +1,268 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/outbox"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Заголовки запроса доставки
+const (
+	EventIDHeader   = "X-Webhook-ID"
+	EventTypeHeader = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Параметры доставки по умолчанию
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBaseBackoff  = 10 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 20
+	DefaultTimeout      = 10 * time.Second
+	// DefaultLease - на сколько захватываются доставки порции; должно превышать BatchSize запросов подряд
+	DefaultLease = 5 * time.Minute
+)
+
+// Убедимся что Dispatcher реализует интерфейс outbox.Publisher
+var _ outbox.Publisher = (*Dispatcher)(nil)
+
+// Envelope - тело запроса доставки
+type Envelope struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Dispatcher ставит события в очередь доставки подписчикам и отправляет их с повторами
+type Dispatcher struct {
+	repo       repository.WebhookRepository
+	transactor repository.Transactor
+	client     *http.Client
+
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+
+	now func() time.Time
+}
+
+// NewDispatcher создает новый экземпляр Dispatcher; если client равен nil, используется NewClient(DefaultTimeout, nil)
+func NewDispatcher(repo repository.WebhookRepository, transactor repository.Transactor, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = NewClient(DefaultTimeout, nil)
+	}
+	return &Dispatcher{
+		repo:         repo,
+		transactor:   transactor,
+		client:       client,
+		MaxAttempts:  DefaultMaxAttempts,
+		BaseBackoff:  DefaultBaseBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		Lease:        DefaultLease,
+		now:          time.Now,
+	}
+}
+
+// Publish создает доставки события для всех активных подписок на него.
+// Вызывается публикатором outbox вне транзакции: если отметить событие опубликованным не удалось,
+// при повторе доставки будут созданы еще раз, и получатель увидит тот же X-Webhook-ID.
+func (d *Dispatcher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	subscriptions, err := d.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(Envelope{
+		ID:        event.EventID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Active || !subscription.Subscribed(event.Type) {
+			continue
+		}
+		err := d.repo.CreateDelivery(ctx, &models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.EventID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  d.now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run отправляет доставки до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Webhook dispatcher error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue отправляет доставки, время которых подошло, и возвращает количество успешных.
+//
+// Доставки захватываются в короткой транзакции: время следующей попытки сдвигается на Lease, поэтому
+// ни этот, ни другие экземпляры сервиса не выберут их повторно. Запросы к получателям выполняются вне
+// транзакции, а результат каждой попытки сохраняется отдельной записью. Если процесс остановится посреди
+// порции, неотправленные доставки будут выбраны снова после истечения захвата.
+func (d *Dispatcher) ProcessDue(ctx context.Context) (int, error) {
+	deliveries, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return succeeded, err
+		}
+		d.attempt(ctx, delivery)
+		if delivery.Status == models.WebhookDeliverySucceeded {
+			succeeded++
+		}
+		if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return succeeded, err
+		}
+	}
+	return succeeded, nil
+}
+
+// claim выбирает доставки, которые пора отправить, и захватывает их на время Lease
+func (d *Dispatcher) claim(ctx context.Context) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := d.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := d.now()
+		due, err := d.repo.ClaimDueDeliveries(ctx, now, d.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range due {
+			delivery.NextAttemptAt = now.Add(d.Lease)
+			if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+		deliveries = due
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// attempt выполняет одну попытку доставки и обновляет ее состояние
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+
+	subscription, err := d.repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if errors.Is(err, repository.ErrWebhookNotFound) || (err == nil && !subscription.Active) {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "subscription deleted or disabled"
+		return
+	}
+	if err == nil {
+		delivery.ResponseCode, err = d.send(ctx, subscription, delivery)
+	}
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+}
+
+// send отправляет подписанный запрос и возвращает код ответа; ответ вне диапазона 2xx считается ошибкой
+func (d *Dispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-user-api-webhooks")
+	req.Header.Set(EventIDHeader, delivery.EventID.String())
+	req.Header.Set(EventTypeHeader, delivery.EventType)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff возвращает задержку перед следующей попыткой: BaseBackoff * 2^(attempts-1), но не более MaxBackoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	return outbox.Backoff(d.BaseBackoff, d.MaxBackoff, attempts)
+}
+
+// Sign вычисляет подпись доставки: "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+// Получатель должен вычислить ту же подпись и сравнить ее с заголовком X-Webhook-Signature.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,396 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"github.com/Est1ege/go-user-api/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookRepository хранит подписки и доставки в памяти
+type fakeWebhookRepository struct {
+	subscriptions []*models.WebhookSubscription
+	deliveries    []*models.WebhookDelivery
+}
+
+func (f *fakeWebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	subscription.ID = uuid.New()
+	f.subscriptions = append(f.subscriptions, subscription)
+	return nil
+}
+
+func (f *fakeWebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	for _, subscription := range f.subscriptions {
+		if subscription.ID == id {
+			return subscription, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (f *fakeWebhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return f.subscriptions, nil
+}
+
+func (f *fakeWebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	for i, subscription := range f.subscriptions {
+		if subscription.ID == id {
+			f.subscriptions = append(f.subscriptions[:i], f.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrWebhookNotFound
+}
+
+func (f *fakeWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = uuid.New()
+	f.deliveries = append(f.deliveries, delivery)
+	return nil
+}
+
+func (f *fakeWebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	for _, delivery := range f.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+func (f *fakeWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, page models.Page) ([]*models.WebhookDelivery, int64, error) {
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, int64(len(deliveries)), nil
+}
+
+func (f *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var due []*models.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return nil
+}
+
+// fakeTransactor выполняет fn без транзакции и отмечает, что транзакция открыта
+type fakeTransactor struct {
+	active *bool
+}
+
+func (f fakeTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if f.active != nil {
+		*f.active = true
+		defer func() { *f.active = false }()
+	}
+	return fn(ctx)
+}
+
+// receiver - тестовый получатель вебхуков, отвечающий кодами из statuses по очереди
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestDispatcher(repo *fakeWebhookRepository, clock *time.Time) *Dispatcher {
+	// Тестовые получатели слушают 127.0.0.1, поэтому используется клиент без проверки адресов
+	d := NewDispatcher(repo, fakeTransactor{}, &http.Client{Timeout: DefaultTimeout})
+	d.now = func() time.Time { return *clock }
+	return d
+}
+
+func userEvent(eventType string) *models.OutboxEvent {
+	return &models.OutboxEvent{
+		EventID:   uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Payload:   json.RawMessage(`{"user":{"name":"Alice"}}`),
+	}
+}
+
+func TestDispatcher_DeliversSignedRequest(t *testing.T) {
+	// Arrange
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	repo := &fakeWebhookRepository{}
+	subscription := &models.WebhookSubscription{URL: server.URL, EventTypes: []string{models.EventUserCreated}, Secret: "0123456789abcdef", Active: true}
+	_ = repo.CreateSubscription(context.Background(), subscription)
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, &clock)
+	event := userEvent(models.EventUserCreated)
+
+	// Act
+	require.NoError(t, d.Publish(context.Background(), event))
+	succeeded, err := d.ProcessDue(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+	require.Len(t, recv.requests, 1)
+
+	req := recv.requests[0]
+	assert.Equal(t, event.EventID.String(), req.Header.Get(EventIDHeader))
+	assert.Equal(t, models.EventUserCreated, req.Header.Get(EventTypeHeader))
+	timestamp, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, clock.Unix(), timestamp)
+	assert.Equal(t, Sign(subscription.Secret, timestamp, recv.bodies[0]), req.Header.Get(SignatureHeader))
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(recv.bodies[0], &envelope))
+	assert.Equal(t, event.EventID, envelope.ID)
+	assert.JSONEq(t, string(event.Payload), string(envelope.Data))
+
+	require.Len(t, repo.deliveries, 1)
+	assert.Equal(t, models.WebhookDeliverySucceeded, repo.deliveries[0].Status)
+	assert.Equal(t, http.StatusOK, repo.deliveries[0].ResponseCode)
+	assert.Equal(t, 1, repo.deliveries[0].Attempts)
+}
+
+func TestDispatcher_Publish_SkipsInactiveAndUnsubscribed(t *testing.T) {
+	// Arrange
+	repo := &fakeWebhookRepository{}
+	subscribed := &models.WebhookSubscription{URL: "http://example.com/a", EventTypes: []string{models.EventUserDeleted}, Active: true}
+	inactive := &models.WebhookSubscription{URL: "http://example.com/b", EventTypes: []string{models.EventUserDeleted}, Active: false}
+	other := &models.WebhookSubscription{URL: "http://example.com/c", EventTypes: []string{models.EventUserCreated}, Active: true}
+	for _, s := range []*models.WebhookSubscription{subscribed, inactive, other} {
+		_ = repo.CreateSubscription(context.Background(), s)
+	}
+
+	clock := time.Now()
+	d := newTestDispatcher(repo, &clock)
+
+	// Act
+	err := d.Publish(context.Background(), userEvent(models.EventUserDeleted))
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, repo.deliveries, 1)
+	assert.Equal(t, subscribed.ID, repo.deliveries[0].SubscriptionID)
+	assert.Equal(t, models.WebhookDeliveryPending, repo.deliveries[0].Status)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	// Arrange
+	recv := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	repo := &fakeWebhookRepository{}
+	_ = repo.CreateSubscription(context.Background(), &models.WebhookSubscription{URL: server.URL, EventTypes: []string{models.EventUserUpdated}, Secret: "0123456789abcdef", Active: true})
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, &clock)
+	require.NoError(t, d.Publish(context.Background(), userEvent(models.EventUserUpdated)))
+	delivery := repo.deliveries[0]
+
+	// Act & Assert
+	// Первая попытка: 500, следующая через BaseBackoff
+	_, err := d.ProcessDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+	assert.Equal(t, clock.Add(d.BaseBackoff), delivery.NextAttemptAt)
+
+	// До истечения задержки доставка не отправляется
+	_, _ = d.ProcessDue(context.Background())
+	assert.Len(t, recv.requests, 1)
+
+	// Вторая попытка: 502, задержка удваивается
+	clock = delivery.NextAttemptAt
+	_, err = d.ProcessDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, delivery.ResponseCode)
+	assert.Equal(t, clock.Add(2*d.BaseBackoff), delivery.NextAttemptAt)
+
+	// Третья попытка успешна
+	clock = delivery.NextAttemptAt
+	succeeded, err := d.ProcessDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Empty(t, delivery.LastError)
+	assert.Len(t, recv.requests, 3)
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &fakeWebhookRepository{}
+	_ = repo.CreateSubscription(context.Background(), &models.WebhookSubscription{URL: server.URL, EventTypes: []string{models.EventUserCreated}, Active: true})
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, &clock)
+	d.MaxAttempts = 3
+	require.NoError(t, d.Publish(context.Background(), userEvent(models.EventUserCreated)))
+	delivery := repo.deliveries[0]
+
+	// Act
+	for i := 0; i < d.MaxAttempts; i++ {
+		clock = delivery.NextAttemptAt
+		_, err := d.ProcessDue(context.Background())
+		require.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseCode)
+	assert.Contains(t, delivery.LastError, "503")
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(&fakeWebhookRepository{}, fakeTransactor{}, nil)
+	d.BaseBackoff = time.Second
+	d.MaxBackoff = 10 * time.Second
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(5))
+	assert.Equal(t, 10*time.Second, d.backoff(30))
+}
+
+func TestDispatcher_SendsOutsideTransaction(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	repo := &fakeWebhookRepository{}
+	_ = repo.CreateSubscription(context.Background(), &models.WebhookSubscription{URL: server.URL, EventTypes: []string{models.EventUserCreated}, Active: true})
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, &clock)
+	var inTransaction, sentInTransaction bool
+	d.transactor = fakeTransactor{active: &inTransaction}
+	d.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sentInTransaction = inTransaction
+		// Пока запрос выполняется, доставка захвачена и повторно не выбирается
+		due, err := repo.ClaimDueDeliveries(req.Context(), clock, d.BatchSize)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	require.NoError(t, d.Publish(context.Background(), userEvent(models.EventUserCreated)))
+
+	// Act
+	succeeded, err := d.ProcessDue(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+	assert.False(t, sentInTransaction)
+}
+
+// roundTripFunc позволяет использовать функцию в качестве http.RoundTripper
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClient_RejectsLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not reach a loopback address")
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second, nil).Get(server.URL)
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+}
+
+func TestNewClient_RejectsPrivateAddress(t *testing.T) {
+	// Адрес проверяется до соединения, поэтому получатель может и не существовать
+	client := NewClient(time.Second, nil)
+	for _, target := range []string{"http://10.0.0.5:8080/hook", "http://172.16.0.1/hook", "http://192.168.1.10/hook", "http://100.64.0.1/hook"} {
+		_, err := client.Get(target)
+		assert.ErrorIs(t, err, ErrAddressNotAllowed, target)
+	}
+}
+
+func TestNewClient_AllowedNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	networks, err := ParseNetworks([]string{"127.0.0.1", "10.0.0.0/8"})
+	require.NoError(t, err)
+	client := NewClient(time.Second, networks)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Case: адрес вне разрешенных подсетей
+	_, err = client.Get("http://192.168.1.10/hook")
+	assert.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	_, err = ParseNetworks([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, raw := range []string{"https://example.com/hook", "http://8.8.8.8:8080/hook", "https://[2001:4860::1]/hook"} {
+		assert.NoError(t, CheckURL(raw, nil), raw)
+	}
+	for _, raw := range []string{
+		"http://127.0.0.1/hook", "http://localhost:8080", "http://169.254.169.254/latest/meta-data", "http://[::1]/", "http://0.0.0.0/", "http://[fe80::1]/",
+		"http://10.0.0.5:8080/hook", "http://172.31.255.1/", "http://192.168.0.1/", "http://[fd00::1]/", "http://100.100.0.1/", "http://[::ffff:10.0.0.1]/",
+	} {
+		assert.ErrorIs(t, CheckURL(raw, nil), ErrAddressNotAllowed, raw)
+	}
+	assert.Error(t, CheckURL("ftp://example.com/hook", nil))
+
+	// Case: частный адрес из разрешенной подсети
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+	assert.NoError(t, CheckURL("http://10.0.0.5:8080/hook", networks))
+	assert.NoError(t, CheckURL("http://[fd00::1]/", networks))
+	assert.ErrorIs(t, CheckURL("http://192.168.0.1/", networks), ErrAddressNotAllowed)
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// maxConnectBackoff - максимальная пауза между попытками подключения
+const maxConnectBackoff = 30 * time.Second
+
+// New подключается к базе данных, указанной в cfg.DB.Driver, и применяет миграции
+func New(cfg *config.Config) (*gorm.DB, error) {
+	switch cfg.DB.Driver {
+	case "postgres":
+		return NewPostgresDB(cfg)
+	case "sqlite":
+		return NewSQLiteDB(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.DB.Driver)
+	}
+}
+
+// newGormConfig создает общую для всех драйверов конфигурацию GORM
+func newGormConfig(cfg config.DBConfig) (*gorm.Config, error) {
+	level, err := ParseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gorm.Config{
+		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             cfg.SlowQueryThreshold,
+			LogLevel:                  level,
+			IgnoreRecordNotFoundError: true,
+		}),
+		// Ошибки драйвера не переводятся в ошибки GORM: при переводе теряется имя нарушенного ограничения,
+		// по которому репозитории различают, например, занятый email и повтор первичного ключа
+	}, nil
+}
+
+// ParseLogLevel переводит название уровня журнала в уровень GORM; пустая строка означает warn
+func ParseLogLevel(level string) (logger.LogLevel, error) {
+	switch strings.ToLower(level) {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn", "":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	default:
+		return 0, fmt.Errorf("unknown database log level %q", level)
+	}
+}
+
+// configurePool применяет к пулу соединений ограничения из конфигурации
+func configurePool(db *gorm.DB, cfg config.DBConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	return nil
+}
+
+// retry вызывает fn, пока она не завершится успешно, но не более attempts раз (минимум один).
+// Пауза между попытками начинается с backoff и удваивается, но не превышает maxConnectBackoff.
+func retry(attempts int, backoff time.Duration, fn func() error) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			return fmt.Errorf("database is unavailable after %d attempts: %w", attempts, err)
+		}
+
+		log.Printf("Database is unavailable (attempt %d of %d), retrying in %s: %v", attempt, attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
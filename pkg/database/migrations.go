@@ -0,0 +1,161 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/domain/models"
+	"gorm.io/gorm"
+)
+
+// Migration описывает версионированное изменение схемы, которое не выразить через AutoMigrate
+// (расширения, индексы по выражениям, преобразование данных)
+type Migration struct {
+	ID string
+	Up func(tx *gorm.DB) error
+}
+
+// schemaMigration - запись о примененной миграции
+type schemaMigration struct {
+	ID        string `gorm:"type:varchar(255);primaryKey"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// schemaModels - модели, таблицы которых создаются и дополняются автомиграциями
+var schemaModels = []interface{}{
+	&models.User{},
+	&models.AuditEvent{},
+	&models.OutboxEvent{},
+	&models.WebhookSubscription{},
+	&models.WebhookDelivery{},
+	&models.Session{},
+	&models.APIKey{},
+	&models.Identity{},
+	&models.OAuthClient{},
+	&models.OAuthAuthorizationCode{},
+	&models.OAuthToken{},
+	&schemaMigration{},
+}
+
+// migrations - упорядоченный список миграций; новые миграции добавляются только в конец
+var migrations = []Migration{
+	{ID: "0001_users_search_indexes", Up: createUserSearchIndexes},
+	{ID: "0002_users_normalize_email", Up: normalizeUserEmails},
+	{ID: "0003_audit_events_append_only", Up: protectAuditEvents},
+}
+
+// Migrate приводит схему базы данных к актуальному состоянию:
+// сначала выполняются автомиграции моделей, затем еще не примененные миграции по порядку
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(schemaModels...); err != nil {
+		return err
+	}
+
+	var applied []schemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, m := range applied {
+		done[m.ID] = true
+	}
+
+	for _, m := range migrations {
+		if done[m.ID] {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+		log.Printf("Applied migration %s", m.ID)
+	}
+	return nil
+}
+
+// createUserSearchIndexes создает индексы для полнотекстового и нечеткого поиска пользователей.
+// В SQLite поиск выполняется без специальных индексов, поэтому там миграция ничего не делает.
+func createUserSearchIndexes(tx *gorm.DB) error {
+	if !isPostgres(tx) {
+		return nil
+	}
+	return execAll(tx,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_users_search_tsv ON users
+			USING GIN (to_tsvector('simple', coalesce(email, '') || ' ' || coalesce(first_name, '') || ' ' || coalesce(last_name, '')))`,
+		`CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users
+			USING GIN (lower(coalesce(email, '') || ' ' || coalesce(first_name, '') || ' ' || coalesce(last_name, '')) gin_trgm_ops)`,
+	)
+}
+
+// normalizeUserEmails приводит email к нижнему регистру и создает уникальный индекс по lower(email).
+// Если после нормализации адреса совпадут, миграция прерывается: такие учетные записи нужно объединить вручную.
+func normalizeUserEmails(tx *gorm.DB) error {
+	var duplicates []string
+	err := tx.Raw(`SELECT lower(trim(email)) FROM users GROUP BY lower(trim(email)) HAVING count(*) > 1`).
+		Scan(&duplicates).Error
+	if err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("emails differ only by case, resolve them before migrating: %s", strings.Join(duplicates, ", "))
+	}
+
+	return execAll(tx,
+		`UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email))`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email))`,
+	)
+}
+
+// protectAuditEvents запрещает изменение и удаление записей журнала аудита
+func protectAuditEvents(tx *gorm.DB) error {
+	if !isPostgres(tx) {
+		return execAll(tx,
+			`CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+			BEGIN
+				SELECT RAISE(ABORT, 'audit_events is append-only');
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+			BEGIN
+				SELECT RAISE(ABORT, 'audit_events is append-only');
+			END`,
+		)
+	}
+	return execAll(tx,
+		`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+		`CREATE TRIGGER audit_events_append_only
+			BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`,
+	)
+}
+
+// isPostgres сообщает, подключен ли tx к PostgreSQL; иначе это SQLite
+func isPostgres(tx *gorm.DB) bool {
+	return tx.Dialector.Name() == "postgres"
+}
+
+// execAll последовательно выполняет SQL-выражения
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
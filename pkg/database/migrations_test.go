@@ -0,0 +1,40 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMigrate_SQLiteFileIsIdempotent(t *testing.T) {
+	cfg := &config.Config{DB: config.DBConfig{Path: filepath.Join(t.TempDir(), "test.db")}}
+
+	// Повторное открытие базы снова выполняет автомиграции: они не должны удалить
+	// созданные миграциями индексы и триггеры
+	for i := 0; i < 2; i++ {
+		db, err := NewSQLiteDB(cfg)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(len(migrations)), count(t, db, "SELECT count(*) FROM schema_migrations"))
+		assert.Equal(t, int64(1), count(t, db, "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_users_email_lower'"))
+		assert.Equal(t, int64(2), count(t, db, "SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND tbl_name = 'audit_events'"))
+
+		var journalMode string
+		require.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+		assert.Equal(t, "wal", journalMode)
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+	}
+}
+
+func count(t *testing.T, db *gorm.DB, query string) int64 {
+	var n int64
+	require.NoError(t, db.Raw(query).Scan(&n).Error)
+	return n
+}
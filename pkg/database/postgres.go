@@ -3,38 +3,118 @@ package database
 import (
 	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"strconv"
 
 	"github.com/Est1ege/go-user-api/internal/config"
-	"github.com/Est1ege/go-user-api/internal/domain/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-// NewPostgresDB создает новое подключение к PostgreSQL
+// NewPostgresDB создает новое подключение к PostgreSQL, повторяя попытки, пока база не станет доступна
 func NewPostgresDB(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DB.Host,
-		cfg.DB.Port,
-		cfg.DB.User,
-		cfg.DB.Password,
-		cfg.DB.Name,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	dsn, err := PostgresDSN(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+	gormConfig, err := newGormConfig(cfg.DB)
 	if err != nil {
 		return nil, err
 	}
 
-	// Автомиграции для моделей
-	err = db.AutoMigrate(&models.User{})
+	var db *gorm.DB
+	err = retry(cfg.DB.ConnectAttempts, cfg.DB.ConnectBackoff, func() error {
+		db, err = gorm.Open(postgres.Open(dsn), gormConfig)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	if err := configurePool(db, cfg.DB); err != nil {
+		return nil, err
+	}
+
+	// Миграции схемы
+	if err := Migrate(db); err != nil {
+		return nil, err
+	}
 
 	log.Println("Connected to PostgreSQL database")
 	return db, nil
-}
\ No newline at end of file
+}
+
+// PostgresDSN строит строку подключения к PostgreSQL в виде URL, экранируя имя пользователя и пароль.
+// Если задан cfg.URL, он используется как основа, а SSL-параметры и statement_timeout
+// добавляются, только если их в нем нет.
+func PostgresDSN(cfg config.DBConfig) (string, error) {
+	var u *url.URL
+	if cfg.URL != "" {
+		parsed, err := url.Parse(cfg.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+		if parsed.Scheme != "postgres" && parsed.Scheme != "postgresql" {
+			return "", fmt.Errorf("invalid DATABASE_URL: unsupported scheme %q", parsed.Scheme)
+		}
+		u = parsed
+	} else {
+		u = &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(cfg.User, cfg.Password),
+			Host:   net.JoinHostPort(cfg.Host, cfg.Port),
+			Path:   "/" + cfg.Name,
+		}
+	}
+
+	query := u.Query()
+	setDefault := func(key, value string) {
+		if value != "" && query.Get(key) == "" {
+			query.Set(key, value)
+		}
+	}
+	setDefault("sslmode", cfg.SSLMode)
+	setDefault("sslrootcert", cfg.SSLRootCert)
+	setDefault("sslcert", cfg.SSLCert)
+	setDefault("sslkey", cfg.SSLKey)
+	if cfg.StatementTimeout > 0 {
+		// Неизвестные драйверу параметры передаются серверу как параметры сеанса
+		setDefault("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// NewPostgresReplicas подключается к репликам из cfg.DB.ReplicaURLs с теми же параметрами SSL, пула и журнала,
+// что и основная база. Миграции на репликах не выполняются, а недоступная при запуске реплика
+// не мешает старту: она будет исключена при первой проверке ReplicaSet.Check.
+func NewPostgresReplicas(cfg *config.Config, primary *gorm.DB) (*ReplicaSet, error) {
+	replicas := NewReplicaSet(primary)
+	for _, replicaURL := range cfg.DB.ReplicaURLs {
+		replicaCfg := cfg.DB
+		replicaCfg.URL = replicaURL
+
+		dsn, err := PostgresDSN(replicaCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica URL: %w", err)
+		}
+		gormConfig, err := newGormConfig(replicaCfg)
+		if err != nil {
+			return nil, err
+		}
+		gormConfig.DisableAutomaticPing = true
+
+		db, err := gorm.Open(postgres.Open(dsn), gormConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := configurePool(db, replicaCfg); err != nil {
+			return nil, err
+		}
+
+		u, _ := url.Parse(dsn)
+		replicas.Add(u.Host+u.Path, db)
+	}
+	return replicas, nil
+}
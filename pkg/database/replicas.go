@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultReplicaCheckTimeout - время ожидания ответа реплики при проверке доступности
+const DefaultReplicaCheckTimeout = 2 * time.Second
+
+// ReplicaSet распределяет чтения между доступными репликами по кругу.
+// Недоступные реплики исключаются по результатам периодической проверки; если доступных реплик нет,
+// чтения выполняются на основной базе.
+type ReplicaSet struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+
+	primaryReads atomic.Int64
+
+	CheckTimeout time.Duration
+}
+
+// replica - подключение к реплике и его состояние
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+	reads   atomic.Int64
+
+	mu      sync.Mutex
+	lastErr string
+}
+
+// ReplicaStats - состояние реплики
+type ReplicaStats struct {
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	Reads     int64  `json:"reads"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// ReplicaSetStats - состояние набора реплик; PrimaryReads - чтения, выполненные на основной базе,
+// потому что доступных реплик не было
+type ReplicaSetStats struct {
+	Replicas     []ReplicaStats `json:"replicas"`
+	PrimaryReads int64          `json:"primary_reads"`
+}
+
+// NewReplicaSet создает пустой набор реплик основной базы primary
+func NewReplicaSet(primary *gorm.DB) *ReplicaSet {
+	return &ReplicaSet{primary: primary, CheckTimeout: DefaultReplicaCheckTimeout}
+}
+
+// Add добавляет реплику; до первой проверки она считается доступной.
+// name используется в журнале и статистике и не должен содержать пароль.
+func (s *ReplicaSet) Add(name string, db *gorm.DB) {
+	r := &replica{name: name, db: db}
+	r.healthy.Store(true)
+	s.replicas = append(s.replicas, r)
+}
+
+// Len возвращает количество реплик
+func (s *ReplicaSet) Len() int {
+	return len(s.replicas)
+}
+
+// Reader возвращает подключение к следующей доступной реплике или к основной базе, если доступных реплик нет
+func (s *ReplicaSet) Reader() *gorm.DB {
+	n := uint64(len(s.replicas))
+	if n > 0 {
+		start := s.next.Add(1)
+		for i := uint64(0); i < n; i++ {
+			r := s.replicas[(start+i)%n]
+			if r.healthy.Load() {
+				r.reads.Add(1)
+				return r.db
+			}
+		}
+	}
+	s.primaryReads.Add(1)
+	return s.primary
+}
+
+// Check проверяет доступность всех реплик и обновляет их состояние
+func (s *ReplicaSet) Check(ctx context.Context) {
+	for _, r := range s.replicas {
+		err := s.ping(ctx, r.db)
+
+		r.mu.Lock()
+		if err != nil {
+			r.lastErr = err.Error()
+		} else {
+			r.lastErr = ""
+		}
+		r.mu.Unlock()
+
+		if wasHealthy := r.healthy.Swap(err == nil); wasHealthy != (err == nil) {
+			if err != nil {
+				log.Printf("Database replica %s is unavailable, reads fall back to other replicas or primary: %v", r.name, err)
+			} else {
+				log.Printf("Database replica %s is available again", r.name)
+			}
+		}
+	}
+}
+
+// ping проверяет подключение к реплике с ограничением времени CheckTimeout
+func (s *ReplicaSet) ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.CheckTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// Run проверяет реплики с интервалом interval до отмены ctx
+func (s *ReplicaSet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Check(ctx)
+		}
+	}
+}
+
+// Stats возвращает состояние реплик
+func (s *ReplicaSet) Stats() ReplicaSetStats {
+	stats := ReplicaSetStats{PrimaryReads: s.primaryReads.Load()}
+	for _, r := range s.replicas {
+		r.mu.Lock()
+		lastErr := r.lastErr
+		r.mu.Unlock()
+
+		stats.Replicas = append(stats.Replicas, ReplicaStats{
+			Name:      r.name,
+			Healthy:   r.healthy.Load(),
+			Reads:     r.reads.Load(),
+			LastError: lastErr,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func openMemoryDB(t *testing.T) *gorm.DB {
+	db, err := NewSQLiteDB(&config.Config{DB: config.DBConfig{Path: SQLiteInMemory, LogLevel: "silent"}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestReplicaSet_Reader(t *testing.T) {
+	primary, first, second := openMemoryDB(t), openMemoryDB(t), openMemoryDB(t)
+
+	t.Run("Without replicas reads from primary", func(t *testing.T) {
+		replicas := NewReplicaSet(primary)
+		assert.Same(t, primary, replicas.Reader())
+		assert.Equal(t, int64(1), replicas.Stats().PrimaryReads)
+	})
+
+	t.Run("Round robin over replicas", func(t *testing.T) {
+		replicas := NewReplicaSet(primary)
+		replicas.Add("first", first)
+		replicas.Add("second", second)
+
+		seen := map[*gorm.DB]int{}
+		for i := 0; i < 4; i++ {
+			seen[replicas.Reader()]++
+		}
+		assert.Equal(t, map[*gorm.DB]int{first: 2, second: 2}, seen)
+	})
+
+	t.Run("Unavailable replica is skipped", func(t *testing.T) {
+		broken := openMemoryDB(t)
+		sqlDB, err := broken.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		replicas := NewReplicaSet(primary)
+		replicas.Add("broken", broken)
+		replicas.Add("first", first)
+		replicas.Check(context.Background())
+
+		for i := 0; i < 3; i++ {
+			assert.Same(t, first, replicas.Reader())
+		}
+
+		stats := replicas.Stats()
+		require.Len(t, stats.Replicas, 2)
+		assert.False(t, stats.Replicas[0].Healthy)
+		assert.NotEmpty(t, stats.Replicas[0].LastError)
+		assert.True(t, stats.Replicas[1].Healthy)
+		assert.Equal(t, int64(3), stats.Replicas[1].Reads)
+	})
+
+	t.Run("Falls back to primary when all replicas are down", func(t *testing.T) {
+		broken := openMemoryDB(t)
+		sqlDB, err := broken.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+
+		replicas := NewReplicaSet(primary)
+		replicas.Add("broken", broken)
+		replicas.Check(context.Background())
+
+		assert.Same(t, primary, replicas.Reader())
+		assert.Equal(t, int64(1), replicas.Stats().PrimaryReads)
+	})
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"github.com/Est1ege/go-user-api/internal/config"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteInMemory - значение cfg.DB.Path для базы SQLite в памяти процесса
+const SQLiteInMemory = ":memory:"
+
+// NewSQLiteDB открывает базу SQLite из файла cfg.DB.Path или в памяти (SQLiteInMemory).
+//
+// Транзакции открываются сразу с блокировкой записи (BEGIN IMMEDIATE), поэтому пишущие транзакции
+// выполняются по очереди, а не завершаются ошибкой SQLITE_BUSY при попытке повысить блокировку.
+// Чтение вне транзакций в режиме WAL не блокируется. База в памяти существует, пока открыто
+// единственное соединение, поэтому для нее пул ограничен одним соединением.
+// Параметры SSL, URL и StatementTimeout к SQLite не относятся и игнорируются.
+func NewSQLiteDB(cfg *config.Config) (*gorm.DB, error) {
+	path := cfg.DB.Path
+	inMemory := path == SQLiteInMemory
+
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)&_txlock=immediate"
+	if !inMemory {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+
+	gormConfig, err := newGormConfig(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite сравнивает время как строки, поэтому все значения записываются в UTC
+	gormConfig.NowFunc = func() time.Time { return time.Now().UTC() }
+
+	db, err := gorm.Open(sqlite.Open(dsn), gormConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig := cfg.DB
+	if inMemory {
+		// Соединения не закрываются: вместе с последним соединением исчезает и сама база
+		poolConfig.MaxOpenConns, poolConfig.MaxIdleConns = 1, 1
+		poolConfig.ConnMaxLifetime, poolConfig.ConnMaxIdleTime = 0, 0
+	}
+	if err := configurePool(db, poolConfig); err != nil {
+		return nil, err
+	}
+
+	// Миграции схемы
+	if err := Migrate(db); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Connected to SQLite database %s", path)
+	return db, nil
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Поддерживаемые форматы выгрузки
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Record представляет одну строку выгрузки: значения в порядке выбранных полей
+type Record []interface{}
+
+// Writer последовательно записывает записи в выходной поток
+type Writer interface {
+	// Write записывает одну запись
+	Write(record Record) error
+	// Close завершает выгрузку (закрывающая скобка JSON, сброс буфера CSV)
+	Close() error
+}
+
+// NewWriter создает Writer для указанного формата
+func NewWriter(format string, w io.Writer, fields []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(fields); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w, fields: fields}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, fields: fields}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// ContentType возвращает MIME-тип для формата
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json; charset=utf-8"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) Write(record Record) error {
+	row := make([]string, len(record))
+	for i, value := range record {
+		row[i] = fmt.Sprint(value)
+	}
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+	// Сбрасываем буфер после каждой строки, чтобы не копить данные в памяти
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonWriter struct {
+	w      io.Writer
+	fields []string
+	count  int
+}
+
+func (j *jsonWriter) Write(record Record) error {
+	data, err := encodeObject(j.fields, record)
+	if err != nil {
+		return err
+	}
+
+	prefix := ","
+	if j.count == 0 {
+		prefix = "["
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	closing := "]"
+	if j.count == 0 {
+		closing = "[]"
+	}
+	_, err := io.WriteString(j.w, closing+"\n")
+	return err
+}
+
+type ndjsonWriter struct {
+	w      io.Writer
+	fields []string
+}
+
+func (n *ndjsonWriter) Write(record Record) error {
+	data, err := encodeObject(n.fields, record)
+	if err != nil {
+		return err
+	}
+	_, err = n.w.Write(append(data, '\n'))
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// encodeObject записывает запись JSON-объектом, ключи которого идут в порядке выбранных полей
+func encodeObject(fields []string, record Record) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i >= len(record) {
+			break
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(record[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,239 @@
+// Package jose реализует подмножество JOSE, нужное для OpenID Connect: подписи JWS в компактной форме
+// с алгоритмами RS256 и ES256 и открытые ключи в формате JWK (RFC 7515, 7517, 7518)
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Поддерживаемые алгоритмы подписи
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// ErrInvalidSignature возвращается, если подпись токена не сходится с ключом
+var ErrInvalidSignature = errors.New("jose: invalid signature")
+
+// Header - заголовок JWS
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// JWK - открытый ключ RSA или EC P-256 в формате JWK
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS - набор ключей, который публикует поставщик удостоверений
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key возвращает ключ с идентификатором kid
+func (s JWKS) Key(kid string) (JWK, bool) {
+	for _, key := range s.Keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+	return JWK{}, false
+}
+
+// NewJWK описывает открытый ключ RSA или EC P-256 для подписи с идентификатором kid
+func NewJWK(key crypto.PublicKey, kid string) (JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", Kid: kid, Use: "sig", Alg: RS256,
+			N: encode(key.N.Bytes()), E: encode(big.NewInt(int64(key.E)).Bytes())}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return JWK{}, errors.New("jose: only the P-256 curve is supported")
+		}
+		return JWK{Kty: "EC", Kid: kid, Use: "sig", Alg: ES256, Crv: "P-256",
+			X: encode(key.X.FillBytes(make([]byte, 32))), Y: encode(key.Y.FillBytes(make([]byte, 32)))}, nil
+	default:
+		return JWK{}, fmt.Errorf("jose: unsupported key type %T", key)
+	}
+}
+
+// PublicKey возвращает открытый ключ, описанный JWK
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("jose: invalid key %q", k.Kid)
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("jose: invalid key %q", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jose: unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, fmt.Errorf("jose: key %q is not on the curve", k.Kid)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("jose: unsupported key type %q", k.Kty)
+	}
+}
+
+// Sign подписывает claims ключом key (RSA - RS256, EC P-256 - ES256) и возвращает токен в компактной форме
+func Sign(claims any, key crypto.Signer, kid string) (string, error) {
+	header := Header{Kid: kid, Typ: "JWT"}
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		header.Alg = RS256
+	case *ecdsa.PublicKey:
+		header.Alg = ES256
+	default:
+		return "", fmt.Errorf("jose: unsupported key type %T", key.Public())
+	}
+
+	encodedHeader, err := encodeSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		// JWS требует подпись ES256 в виде r||s по 32 байта, а не в DER
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return "", err
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		if header.Alg != RS256 {
+			return "", fmt.Errorf("jose: unsupported signer %T", key)
+		}
+		if signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify проверяет подпись токена ключом, который keyFor выбирает по заголовку, и возвращает полезную нагрузку.
+// Принимаются только RS256 и ES256, причем алгоритм должен соответствовать типу ключа.
+func Verify(token string, keyFor func(header Header) (crypto.PublicKey, error)) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jose: malformed token")
+	}
+	var header Header
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("jose: malformed payload")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("jose: malformed signature")
+	}
+
+	key, err := keyFor(header)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != RS256 || rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return nil, ErrInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != ES256 || len(signature) != 64 {
+			return nil, ErrInvalidSignature
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return nil, ErrInvalidSignature
+		}
+	default:
+		return nil, fmt.Errorf("jose: unsupported key type %T", key)
+	}
+	return payload, nil
+}
+
+// DecodeHeader возвращает заголовок токена без проверки подписи
+func DecodeHeader(token string) (Header, error) {
+	var header Header
+	encoded, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return header, errors.New("jose: malformed token")
+	}
+	return header, decodeSegment(encoded, &header)
+}
+
+func encodeSegment(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("jose: malformed header")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("jose: malformed header")
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{RS256: rsaKey, ES256: ecKey} {
+		t.Run(name, func(t *testing.T) {
+			// Ключ проходит через JWKS, как у настоящего поставщика
+			jwk, err := NewJWK(key.Public(), "key-1")
+			require.NoError(t, err)
+			data, err := json.Marshal(JWKS{Keys: []JWK{jwk}})
+			require.NoError(t, err)
+			var set JWKS
+			require.NoError(t, json.Unmarshal(data, &set))
+			keyFor := func(header Header) (crypto.PublicKey, error) {
+				found, _ := set.Key(header.Kid)
+				return found.PublicKey()
+			}
+
+			token, err := Sign(map[string]string{"sub": "42"}, key, "key-1")
+			require.NoError(t, err)
+			header, err := DecodeHeader(token)
+			require.NoError(t, err)
+			assert.Equal(t, Header{Alg: name, Kid: "key-1", Typ: "JWT"}, header)
+
+			payload, err := Verify(token, keyFor)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"sub":"42"}`, string(payload))
+
+			// Измененная полезная нагрузка не проходит проверку
+			parts := strings.Split(token, ".")
+			forged, err := encodeSegment(map[string]string{"sub": "1"})
+			require.NoError(t, err)
+			_, err = Verify(parts[0]+"."+forged+"."+parts[2], keyFor)
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+}
+
+func TestVerify_RejectsAlgorithmMismatch(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	token, err := Sign(map[string]string{"sub": "42"}, ecKey, "")
+	require.NoError(t, err)
+
+	// Токен без подписи (alg "none") и заголовок с чужим алгоритмом отклоняются
+	parts := strings.Split(token, ".")
+	for _, alg := range []string{"none", RS256, "HS256"} {
+		header, err := encodeSegment(Header{Alg: alg})
+		require.NoError(t, err)
+		_, err = Verify(header+"."+parts[1]+"."+parts[2], func(Header) (crypto.PublicKey, error) { return ecKey.Public(), nil })
+		assert.ErrorIs(t, err, ErrInvalidSignature, alg)
+	}
+	_, err = Verify(parts[0]+"."+parts[1]+".", func(Header) (crypto.PublicKey, error) { return ecKey.Public(), nil })
+	assert.Error(t, err)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	for name, block := range map[string]*pem.Block{
+		"PKCS8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+		"SEC1":  {Type: "EC PRIVATE KEY", Bytes: sec1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			key, err := ParsePrivateKey(pem.EncodeToMemory(block))
+			require.NoError(t, err)
+			assert.True(t, ecKey.Equal(key))
+		})
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(weak)}))
+	assert.ErrorContains(t, err, "2048")
+	_, err = ParsePrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestThumbprint(t *testing.T) {
+	// Пример из RFC 7638, раздел 3.1
+	jwk := JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMs" +
+			"tn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91" +
+			"CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	key, err := jwk.PublicKey()
+	require.NoError(t, err)
+	thumbprint, err := Thumbprint(key)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}